@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/service"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+type AdminHandler struct {
+	log     common.Logger
+	service service.AdminService
+}
+
+func NewAdminHandler(l common.Logger, s service.AdminService) *AdminHandler {
+	return &AdminHandler{
+		log:     l,
+		service: s,
+	}
+}
+
+func (ah *AdminHandler) GetTokenUsage(w http.ResponseWriter, r *http.Request) {
+	res, err := ah.service.GetTokenUsage(r)
+	if err != nil {
+		ah.log.Error("getting token usage failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(res)
+	if err != nil {
+		ah.log.Error("marshaling response failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/service"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+type ApiKeyHandler struct {
+	log     common.Logger
+	service service.ApiKeyService
+}
+
+func NewApiKeyHandler(l common.Logger, s service.ApiKeyService) *ApiKeyHandler {
+	return &ApiKeyHandler{
+		log:     l,
+		service: s,
+	}
+}
+
+func (akh *ApiKeyHandler) GetApiKeys(w http.ResponseWriter, r *http.Request) {
+	res, err := akh.service.GetApiKeys(r)
+	if err != nil {
+		akh.log.Error("getting api keys failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (akh *ApiKeyHandler) CreateApiKey(w http.ResponseWriter, r *http.Request) {
+	res, err := akh.service.CreateApiKey(r)
+	if err != nil {
+		akh.log.Error("creating api key failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, res)
+}
+
+func (akh *ApiKeyHandler) RevokeApiKey(w http.ResponseWriter, r *http.Request) {
+	if err := akh.service.RevokeApiKey(r); err != nil {
+		akh.log.Error("revoking api key failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
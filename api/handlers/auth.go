@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/service"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+type AuthHandler struct {
+	log     common.Logger
+	service service.AuthService
+}
+
+func NewAuthHandler(l common.Logger, s service.AuthService) *AuthHandler {
+	return &AuthHandler{
+		log:     l,
+		service: s,
+	}
+}
+
+// Login redirects to GitHub to sign in
+func (ah *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	url, stateCookie, err := ah.service.StartLogin(r)
+	if err != nil {
+		ah.log.Error("starting login failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	http.SetCookie(w, stateCookie)
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// Callback completes signing in, then redirects to the app
+func (ah *AuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	cookies, err := ah.service.CompleteLogin(r)
+	if err != nil {
+		ah.log.Error("completing login failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	for _, cookie := range cookies {
+		http.SetCookie(w, cookie)
+	}
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+func (ah *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	cookie, err := ah.service.Logout(r)
+	if err != nil {
+		ah.log.Error("logout failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	http.SetCookie(w, cookie)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *AuthHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	res, err := ah.service.GetCurrentUser(r)
+	if err != nil {
+		ah.log.Error("getting current user failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(res)
+	if err != nil {
+		ah.log.Error("marshaling response failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
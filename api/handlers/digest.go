@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/service"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+type DigestHandler struct {
+	log     common.Logger
+	service service.DigestService
+}
+
+func NewDigestHandler(l common.Logger, s service.DigestService) *DigestHandler {
+	return &DigestHandler{
+		log:     l,
+		service: s,
+	}
+}
+
+func (dh *DigestHandler) GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	res, err := dh.service.GetSubscriptions(r)
+	if err != nil {
+		dh.log.Error("getting digest subscriptions failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (dh *DigestHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	res, err := dh.service.CreateSubscription(r)
+	if err != nil {
+		dh.log.Error("creating digest subscription failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, res)
+}
+
+func (dh *DigestHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	if err := dh.service.DeleteSubscription(r); err != nil {
+		dh.log.Error("deleting digest subscription failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// digestPage is shown for the links in digest emails. Opening a link only shows a form, so link scanners
+// opening every link in an email don't confirm or unsubscribe for the recipient.
+var digestPage = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .Token}}<form method="post"><input type="hidden" name="token" value="{{.Token}}"><button type="submit">{{.Button}}</button></form>{{end}}
+</body>
+</html>
+`))
+
+type digestPageData struct {
+	Title   string
+	Message string
+	Token   string
+	Button  string
+}
+
+// ConfirmPage is opened from the link in the confirmation email, and asks the recipient to confirm
+func (dh *DigestHandler) ConfirmPage(w http.ResponseWriter, r *http.Request) {
+	dh.writePage(w, digestPageData{
+		Title:   "Confirm digest subscription",
+		Message: "Confirm that you want to be emailed digests of the goo.gl links found in this author's repos.",
+		Token:   r.URL.Query().Get("token"),
+		Button:  "Confirm",
+	})
+}
+
+func (dh *DigestHandler) ConfirmSubscription(w http.ResponseWriter, r *http.Request) {
+	if err := dh.service.ConfirmSubscription(r); err != nil {
+		dh.log.Error("confirming digest subscription failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	dh.writePage(w, digestPageData{
+		Title:   "Subscription confirmed",
+		Message: "You will be emailed digests of new goo.gl links. Every digest has a link to unsubscribe.",
+	})
+}
+
+// UnsubscribePage is opened from the link in digest emails, and asks the recipient to confirm unsubscribing
+func (dh *DigestHandler) UnsubscribePage(w http.ResponseWriter, r *http.Request) {
+	dh.writePage(w, digestPageData{
+		Title:   "Unsubscribe from digest",
+		Message: "Unsubscribe from digests of the goo.gl links found in this author's repos?",
+		Token:   r.URL.Query().Get("token"),
+		Button:  "Unsubscribe",
+	})
+}
+
+func (dh *DigestHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	if err := dh.service.Unsubscribe(r); err != nil {
+		dh.log.Error("unsubscribing from digest failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	dh.writePage(w, digestPageData{
+		Title:   "Unsubscribed",
+		Message: "You have been unsubscribed and will not receive this digest again.",
+	})
+}
+
+func (dh *DigestHandler) writePage(w http.ResponseWriter, data digestPageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := digestPage.Execute(w, data); err != nil {
+		dh.log.Error("writing digest page failed with error: ", err)
+	}
+}
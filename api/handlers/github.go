@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/models"
 	"github.com/jwtly10/googl-bye/internal/service"
 	"github.com/jwtly10/googl-bye/internal/utils"
 )
@@ -29,6 +30,28 @@ func (gh *GithubHandler) SearchRepos(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The body stays a list of repos, the query that was actually searched is sent as a header
+	jsonResponse, err := json.Marshal(res.Repos)
+	if err != nil {
+		gh.log.Error("marshaling response failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Search-Query", res.Query)
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (gh *GithubHandler) SearchCode(w http.ResponseWriter, r *http.Request) {
+	res, err := gh.service.GithubCodeSearch(r)
+	if err != nil {
+		gh.log.Error("github code search failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
 	jsonResponse, err := json.Marshal(res)
 	if err != nil {
 		gh.log.Error("marshaling response failed with error: ", err)
@@ -61,6 +84,57 @@ func (gh *GithubHandler) SearchReposForUser(w http.ResponseWriter, r *http.Reque
 	w.Write(jsonResponse)
 }
 
+// StreamOwnerRepos streams repos as NDJSON as each page is listed.
+// Errors before the first repo are returned as usual. After that, the error is written as a last
+// {"error": ..., "message": ...} record, so clients can tell the listing is incomplete.
+func (gh *GithubHandler) StreamOwnerRepos(w http.ResponseWriter, r *http.Request) {
+	listing, err := gh.service.NewOwnerRepoListing(r)
+	if err != nil {
+		gh.log.Error("validating repo listing failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	started := false
+	start := func() {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	err = gh.service.StreamOwnerRepos(r.Context(), listing, func(repo models.RepositoryModel) error {
+		if !started {
+			start()
+		}
+		if err := enc.Encode(repo); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		gh.log.Error("streaming repos failed with error: ", err)
+		if !started {
+			utils.HandleCustomErrors(w, err)
+			return
+		}
+		// The status was already sent, so the error is the last record, telling clients the listing is incomplete
+		_, res := utils.ErrorResponseFor(err)
+		if err := enc.Encode(res); err != nil {
+			gh.log.Error("writing stream error failed with error: ", err)
+		}
+		return
+	}
+
+	if !started {
+		start()
+	}
+}
+
 func (gh *GithubHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 	res, err := gh.service.GithubSearchUsers(r)
 	if err != nil {
@@ -80,3 +154,83 @@ func (gh *GithubHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write(jsonResponse)
 }
+
+func (gh *GithubHandler) GetSavedSearches(w http.ResponseWriter, r *http.Request) {
+	res, err := gh.service.GetSavedSearches(r)
+	if err != nil {
+		gh.log.Error("getting saved searches failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(res)
+	if err != nil {
+		gh.log.Error("marshaling response failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (gh *GithubHandler) GetSavedSearch(w http.ResponseWriter, r *http.Request) {
+	res, err := gh.service.GetSavedSearch(r)
+	if err != nil {
+		gh.log.Error("getting saved search failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(res)
+	if err != nil {
+		gh.log.Error("marshaling response failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (gh *GithubHandler) ResumeSavedSearch(w http.ResponseWriter, r *http.Request) {
+	res, err := gh.service.ResumeSavedSearch(r)
+	if err != nil {
+		gh.log.Error("resuming saved search failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(res)
+	if err != nil {
+		gh.log.Error("marshaling response failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (gh *GithubHandler) SetSavedSearchSchedule(w http.ResponseWriter, r *http.Request) {
+	res, err := gh.service.SetSavedSearchSchedule(r)
+	if err != nil {
+		gh.log.Error("setting saved search schedule failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(res)
+	if err != nil {
+		gh.log.Error("marshaling response failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/service"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+type OutboundWebhookHandler struct {
+	log     common.Logger
+	service service.OutboundWebhookService
+}
+
+func NewOutboundWebhookHandler(l common.Logger, s service.OutboundWebhookService) *OutboundWebhookHandler {
+	return &OutboundWebhookHandler{
+		log:     l,
+		service: s,
+	}
+}
+
+func (owh *OutboundWebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	res, err := owh.service.GetWebhooks(r)
+	if err != nil {
+		owh.log.Error("getting webhooks failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (owh *OutboundWebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	res, err := owh.service.CreateWebhook(r)
+	if err != nil {
+		owh.log.Error("creating webhook failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, res)
+}
+
+func (owh *OutboundWebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := owh.service.DeleteWebhook(r); err != nil {
+		owh.log.Error("deleting webhook failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (owh *OutboundWebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	res, err := owh.service.GetDeliveries(r)
+	if err != nil {
+		owh.log.Error("getting webhook deliveries failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, res)
+}
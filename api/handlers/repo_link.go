@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/export"
 	"github.com/jwtly10/googl-bye/internal/service"
 	"github.com/jwtly10/googl-bye/internal/utils"
 )
@@ -61,3 +63,47 @@ func (rlh *RepoLinkHandler) GetUserRepoLinks(w http.ResponseWriter, r *http.Requ
 	w.WriteHeader(http.StatusOK)
 	w.Write(jsonResponse)
 }
+
+func (rlh *RepoLinkHandler) GetRepoSarif(w http.ResponseWriter, r *http.Request) {
+	sarif, err := rlh.service.GetRepoSarif(r)
+	if err != nil {
+		rlh.log.Error("generating sarif for repo failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(sarif)
+	if err != nil {
+		rlh.log.Error("marshaling response failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", export.SarifContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+// ExportRepoLinks streams the report as it is read from the db. Once streaming has started errors can only be logged.
+func (rlh *RepoLinkHandler) ExportRepoLinks(w http.ResponseWriter, r *http.Request) {
+	e, err := rlh.service.NewRepoLinkExport(r)
+	if err != nil {
+		rlh.log.Error("validating export request failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	lw, err := export.NewLinkWriter(e.Format, w)
+	if err != nil {
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", lw.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="googl-bye-links.%s"`, lw.FileExtension()))
+	w.WriteHeader(http.StatusOK)
+
+	if err := rlh.service.WriteRepoLinkExport(e, lw); err != nil {
+		rlh.log.Error("streaming repo links export failed with error: ", err)
+	}
+}
@@ -30,3 +30,19 @@ func (rh *RepoHandler) Save(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusCreated)
 }
+
+func (rh *RepoHandler) CreateIssue(w http.ResponseWriter, r *http.Request) {
+	res, created, err := rh.service.CreateRepoIssue(r)
+	if err != nil {
+		rh.log.Error("raising issue against repo failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	// The issue that was already raised is returned, rather than raising another
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	utils.WriteJSON(w, status, res)
+}
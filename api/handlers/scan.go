@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/service"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+type ScanHandler struct {
+	log     common.Logger
+	service service.ScanService
+}
+
+func NewScanHandler(l common.Logger, s service.ScanService) *ScanHandler {
+	return &ScanHandler{
+		log:     l,
+		service: s,
+	}
+}
+
+func (sh *ScanHandler) Scan(w http.ResponseWriter, r *http.Request) {
+	report, err := sh.service.Scan(w, r)
+	if err != nil {
+		sh.log.Error("scan failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(report)
+	if err != nil {
+		sh.log.Error("marshaling response failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/service"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+type StatsHandler struct {
+	log     common.Logger
+	service *service.StatsService
+}
+
+func NewStatsHandler(l common.Logger, s *service.StatsService) *StatsHandler {
+	return &StatsHandler{
+		log:     l,
+		service: s,
+	}
+}
+
+func (sh *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := sh.service.GetStats(r)
+	if err != nil {
+		sh.log.Error("getting stats failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(stats)
+	if err != nil {
+		sh.log.Error("marshaling response failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/service"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+type TeamHandler struct {
+	log     common.Logger
+	service service.TeamService
+}
+
+func NewTeamHandler(l common.Logger, s service.TeamService) *TeamHandler {
+	return &TeamHandler{
+		log:     l,
+		service: s,
+	}
+}
+
+func (th *TeamHandler) GetTeams(w http.ResponseWriter, r *http.Request) {
+	res, err := th.service.GetTeams(r)
+	if err != nil {
+		th.log.Error("getting teams failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (th *TeamHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
+	res, err := th.service.CreateTeam(r)
+	if err != nil {
+		th.log.Error("creating team failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, res)
+}
+
+func (th *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
+	res, err := th.service.GetTeam(r)
+	if err != nil {
+		th.log.Error("getting team failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (th *TeamHandler) AddTeamMember(w http.ResponseWriter, r *http.Request) {
+	res, err := th.service.AddTeamMember(r)
+	if err != nil {
+		th.log.Error("adding team member failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	utils.WriteJSON(w, http.StatusCreated, res)
+}
+
+func (th *TeamHandler) RemoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	if err := th.service.RemoveTeamMember(r); err != nil {
+		th.log.Error("removing team member failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
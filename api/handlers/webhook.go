@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/service"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+type WebhookHandler struct {
+	log     common.Logger
+	service service.WebhookService
+}
+
+func NewWebhookHandler(l common.Logger, s service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		log:     l,
+		service: s,
+	}
+}
+
+func (wh *WebhookHandler) HandleGithubWebhook(w http.ResponseWriter, r *http.Request) {
+	res, err := wh.service.HandleGithubWebhook(w, r)
+	if err != nil {
+		wh.log.Error("handling github webhook failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(res)
+	if err != nil {
+		wh.log.Error("marshaling response failed with error: ", err)
+		utils.HandleCustomErrors(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
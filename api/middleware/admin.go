@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jwtly10/googl-bye/internal/auth"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+// ServerAdminMiddleware only allows requests from the server admins, the users whose GitHub logins are configured.
+// It must run after the auth middleware. Without admins, every request is rejected.
+type ServerAdminMiddleware struct {
+	logins map[string]bool
+	log    common.Logger
+}
+
+func NewServerAdminMiddleware(logins []string, log common.Logger) *ServerAdminMiddleware {
+	admins := make(map[string]bool, len(logins))
+	for _, login := range logins {
+		admins[strings.ToLower(login)] = true
+	}
+	return &ServerAdminMiddleware{
+		logins: admins,
+		log:    log,
+	}
+}
+
+func (amw *ServerAdminMiddleware) BeforeNext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			utils.HandleCustomErrors(w, errors.NewUnauthorizedError("not signed in"))
+			return
+		}
+		// GitHub logins are case insensitive
+		if !amw.logins[strings.ToLower(user.Login)] {
+			amw.log.Infof("User '%s' rejected from admin route %s", user.Login, r.URL.Path)
+			utils.HandleCustomErrors(w, errors.NewForbiddenError("only server admins can do this"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
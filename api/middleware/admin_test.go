@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jwtly10/googl-bye/internal/auth"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestServerAdminMiddleware(t *testing.T) {
+	logger := common.NewLogger(false, zapcore.DebugLevel)
+
+	handler := NewServerAdminMiddleware([]string{"Alice"}, logger).BeforeNext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(user *models.UserModel) int {
+		r := httptest.NewRequest("GET", "/v1/api/admin/tokens", nil)
+		if user != nil {
+			r = r.WithContext(auth.WithUser(r.Context(), user))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request(&models.UserModel{Login: "alice"}), "admins are matched case insensitively")
+	assert.Equal(t, http.StatusForbidden, request(&models.UserModel{Login: "bob"}))
+	assert.Equal(t, http.StatusUnauthorized, request(nil))
+}
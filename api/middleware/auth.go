@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/auth"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+// Authenticator gets the signed in user of a request, and the API key it was made with if any
+type Authenticator interface {
+	Authenticate(r *http.Request) (*models.UserModel, *models.ApiKeyModel, error)
+}
+
+type AuthMiddleware struct {
+	auth Authenticator
+	log  common.Logger
+}
+
+func NewAuthMiddleware(a Authenticator, log common.Logger) *AuthMiddleware {
+	return &AuthMiddleware{
+		auth: a,
+		log:  log,
+	}
+}
+
+// BeforeNext rejects requests without a signed in user, and adds the user and API key to the context of those with one.
+func (amw *AuthMiddleware) BeforeNext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, key, err := amw.auth.Authenticate(r)
+		if err != nil {
+			amw.log.Infof("Unauthenticated request to %s: %v", r.URL.Path, err)
+			utils.HandleCustomErrors(w, err)
+			return
+		}
+		ctx := auth.WithUser(r.Context(), user)
+		if key != nil {
+			ctx = auth.WithApiKey(ctx, key)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
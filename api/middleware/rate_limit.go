@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/auth"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+// maxBuckets is the most clients tracked at once. Clients over the cap share a bucket, so floods of new clients
+// (e.g. from many IPs) can't use up memory, and only limit each other.
+const maxBuckets = 100_000
+
+// overflowKey is the bucket shared by clients over the cap
+const overflowKey = "overflow"
+
+// RateLimitMiddleware limits how many requests each client can make to the routes it is applied to.
+// Every client has a token bucket holding up to limit tokens, refilled at limit tokens per period.
+// Clients are told their limit with the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+// It must run after the auth middleware, so clients are identified by their verified API key or user.
+type RateLimitMiddleware struct {
+	limit  int
+	period time.Duration
+	log    common.Logger
+	// now is overridden in tests
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimitMiddleware limits clients to limit requests per period. A limit below 1 disables limiting.
+func NewRateLimitMiddleware(limit int, period time.Duration, log common.Logger) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		limit:   limit,
+		period:  period,
+		log:     log,
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+func (rlmw *RateLimitMiddleware) BeforeNext(next http.Handler) http.Handler {
+	if rlmw.limit < 1 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, remaining, reset, retryAfter := rlmw.take(clientKey(r))
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(rlmw.limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+
+		if !allowed {
+			rlmw.log.Infof("Rate limited request to %s", r.URL.Path)
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+			utils.WriteErrorResponse(w, http.StatusTooManyRequests, utils.ErrorResponse{
+				Error:   "RATE_LIMITED",
+				Message: fmt.Sprintf("too many requests, limit is %d per %s", rlmw.limit, rlmw.period),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// take takes a token from the client's bucket. It returns if the request is allowed, the tokens left,
+// how long until the bucket is full again, and how long until the next token when there are none.
+func (rlmw *RateLimitMiddleware) take(key string) (bool, int, time.Duration, time.Duration) {
+	rlmw.mu.Lock()
+	defer rlmw.mu.Unlock()
+
+	now := rlmw.now()
+	rlmw.prune(now)
+
+	capacity := float64(rlmw.limit)
+	perToken := rlmw.period / time.Duration(rlmw.limit)
+
+	bucket, ok := rlmw.buckets[key]
+	if !ok && len(rlmw.buckets) >= maxBuckets {
+		key = overflowKey
+		bucket, ok = rlmw.buckets[key]
+	}
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, last: now}
+		rlmw.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(capacity, bucket.tokens+float64(now.Sub(bucket.last))/float64(perToken))
+	bucket.last = now
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+
+	reset := time.Duration((capacity - bucket.tokens) * float64(perToken))
+	retryAfter := time.Duration((1 - bucket.tokens) * float64(perToken))
+	return allowed, int(bucket.tokens), reset, retryAfter
+}
+
+// prune forgets clients whose buckets have refilled, at most once a period, so the buckets don't grow forever
+func (rlmw *RateLimitMiddleware) prune(now time.Time) {
+	if now.Sub(rlmw.lastPrune) < rlmw.period {
+		return
+	}
+	rlmw.lastPrune = now
+	for key, bucket := range rlmw.buckets {
+		if now.Sub(bucket.last) >= rlmw.period {
+			delete(rlmw.buckets, key)
+		}
+	}
+}
+
+// clientKey identifies who made a request, by the API key or user verified by the auth middleware, then IP.
+// Credentials are only trusted once verified, so requests with made up keys or sessions are limited by IP.
+func clientKey(r *http.Request) string {
+	if key, ok := auth.ApiKeyFromContext(r.Context()); ok {
+		return fmt.Sprintf("key:%d", key.ID)
+	}
+	if user, ok := auth.UserFromContext(r.Context()); ok {
+		return fmt.Sprintf("user:%d", user.ID)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ceilSeconds rounds up, so clients never retry before they can
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/auth"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	logger := common.NewLogger(false, zapcore.DebugLevel)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rlmw := NewRateLimitMiddleware(2, time.Minute, logger)
+	rlmw.now = func() time.Time { return now }
+
+	handler := rlmw.BeforeNext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// key is the API key verified by the auth middleware, if any
+	request := func(remoteAddr string, key *models.ApiKeyModel) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/v1/api/search", nil)
+		r.RemoteAddr = remoteAddr
+		if key != nil {
+			r = r.WithContext(auth.WithApiKey(r.Context(), key))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("Requests within the limit are allowed", func(t *testing.T) {
+		w := request("10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+
+		w = request("10.0.0.1:5678", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+	})
+
+	t.Run("Requests over the limit are rejected", func(t *testing.T) {
+		w := request("10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+		assert.Contains(t, w.Body.String(), "RATE_LIMITED")
+	})
+
+	t.Run("Clients have their own buckets", func(t *testing.T) {
+		w := request("10.0.0.2:1234", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// API keys are limited separately from the IP they are used from
+		w = request("10.0.0.1:1234", &models.ApiKeyModel{Model: models.Model{ID: 1}})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Unverified credentials are limited by IP", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/v1/api/search", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set("Authorization", "Bearer gb_made_up_key")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("Buckets refill over time", func(t *testing.T) {
+		now = now.Add(30 * time.Second)
+		w := request("10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+		w = request("10.0.0.1:1234", nil)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+
+	t.Run("Idle clients are pruned", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		request("10.0.0.3:1234", nil)
+		assert.Len(t, rlmw.buckets, 1)
+	})
+}
+
+func TestRateLimitMiddlewareCapsBuckets(t *testing.T) {
+	rlmw := NewRateLimitMiddleware(1, time.Minute, common.NewLogger(false, zapcore.DebugLevel))
+	for i := 0; i < maxBuckets; i++ {
+		rlmw.buckets[fmt.Sprintf("ip:%d", i)] = &tokenBucket{tokens: 1, last: rlmw.now()}
+	}
+
+	// New clients over the cap share a bucket instead of adding more
+	allowed, _, _, _ := rlmw.take("ip:new-client")
+	assert.True(t, allowed)
+	allowed, _, _, _ = rlmw.take("ip:another-new-client")
+	assert.False(t, allowed)
+	assert.Len(t, rlmw.buckets, maxBuckets+1)
+}
+
+func TestRateLimitMiddlewareDisabled(t *testing.T) {
+	rlmw := NewRateLimitMiddleware(0, time.Minute, common.NewLogger(false, zapcore.DebugLevel))
+	handler := rlmw.BeforeNext(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/api/search", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+}
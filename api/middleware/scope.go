@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/auth"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/utils"
+)
+
+// ScopeMiddleware rejects requests made with an API key that is missing a scope.
+// It must run after the auth middleware. Requests with a session can do anything.
+type ScopeMiddleware struct {
+	scope string
+	log   common.Logger
+}
+
+func NewScopeMiddleware(scope string, log common.Logger) *ScopeMiddleware {
+	return &ScopeMiddleware{
+		scope: scope,
+		log:   log,
+	}
+}
+
+func (smw *ScopeMiddleware) BeforeNext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, ok := auth.ApiKeyFromContext(r.Context()); ok && !key.HasScope(smw.scope) {
+			smw.log.Infof("API key %d without scope '%s' rejected from %s", key.ID, smw.scope, r.URL.Path)
+			utils.HandleCustomErrors(w, errors.NewForbiddenError(fmt.Sprintf("api key is missing the '%s' scope", smw.scope)))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
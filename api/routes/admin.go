@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/api"
+	"github.com/jwtly10/googl-bye/api/handlers"
+	"github.com/jwtly10/googl-bye/api/middleware"
+	"github.com/jwtly10/googl-bye/internal/common"
+)
+
+type AdminRoutes struct {
+	l common.Logger
+	h handlers.AdminHandler
+}
+
+// NewAdminRoutes sets up the server admin routes. mws must include the server admin middleware,
+// as these routes show details of the whole server rather than the caller's own data.
+func NewAdminRoutes(router api.AppRouter, l common.Logger, h handlers.AdminHandler, mws ...middleware.Middleware) AdminRoutes {
+	routes := AdminRoutes{
+		l: l,
+		h: h,
+	}
+
+	BASE_PATH := "/v1/api"
+
+	tokenUsageHandler := http.HandlerFunc(routes.h.GetTokenUsage)
+	router.Get(
+		BASE_PATH+"/admin/tokens",
+		middleware.Chain(tokenUsageHandler, mws...),
+	)
+
+	return routes
+}
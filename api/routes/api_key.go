@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/api"
+	"github.com/jwtly10/googl-bye/api/handlers"
+	"github.com/jwtly10/googl-bye/api/middleware"
+	"github.com/jwtly10/googl-bye/internal/common"
+)
+
+type ApiKeyRoutes struct {
+	l common.Logger
+	h handlers.ApiKeyHandler
+}
+
+func NewApiKeyRoutes(router api.AppRouter, l common.Logger, h handlers.ApiKeyHandler, mws ...middleware.Middleware) ApiKeyRoutes {
+	routes := ApiKeyRoutes{
+		l: l,
+		h: h,
+	}
+
+	BASE_PATH := "/v1/api"
+
+	getApiKeysHandler := http.HandlerFunc(routes.h.GetApiKeys)
+	router.Get(
+		BASE_PATH+"/apikeys",
+		middleware.Chain(getApiKeysHandler, mws...),
+	)
+
+	createApiKeyHandler := http.HandlerFunc(routes.h.CreateApiKey)
+	router.Post(
+		BASE_PATH+"/apikeys",
+		middleware.Chain(createApiKeyHandler, mws...),
+	)
+
+	revokeApiKeyHandler := http.HandlerFunc(routes.h.RevokeApiKey)
+	router.Delete(
+		BASE_PATH+"/apikeys/{id}",
+		middleware.Chain(revokeApiKeyHandler, mws...),
+	)
+
+	return routes
+}
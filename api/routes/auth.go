@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/api"
+	"github.com/jwtly10/googl-bye/api/handlers"
+	"github.com/jwtly10/googl-bye/api/middleware"
+	"github.com/jwtly10/googl-bye/internal/common"
+)
+
+type AuthRoutes struct {
+	l common.Logger
+	h handlers.AuthHandler
+}
+
+// NewAuthRoutes maps the sign in routes. The auth middleware protects /me, so it is passed separately.
+func NewAuthRoutes(router api.AppRouter, l common.Logger, h handlers.AuthHandler, authMw middleware.Middleware, mws ...middleware.Middleware) AuthRoutes {
+	routes := AuthRoutes{
+		l: l,
+		h: h,
+	}
+
+	BASE_PATH := "/v1/api"
+
+	loginHandler := http.HandlerFunc(routes.h.Login)
+	router.Get(
+		BASE_PATH+"/auth/login",
+		middleware.Chain(loginHandler, mws...),
+	)
+
+	callbackHandler := http.HandlerFunc(routes.h.Callback)
+	router.Get(
+		BASE_PATH+"/auth/callback",
+		middleware.Chain(callbackHandler, mws...),
+	)
+
+	logoutHandler := http.HandlerFunc(routes.h.Logout)
+	router.Post(
+		BASE_PATH+"/auth/logout",
+		middleware.Chain(logoutHandler, mws...),
+	)
+
+	meHandler := http.HandlerFunc(routes.h.GetCurrentUser)
+	router.Get(
+		BASE_PATH+"/me",
+		middleware.Chain(meHandler, append([]middleware.Middleware{authMw}, mws...)...),
+	)
+
+	return routes
+}
@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/api"
+	"github.com/jwtly10/googl-bye/api/handlers"
+	"github.com/jwtly10/googl-bye/api/middleware"
+	"github.com/jwtly10/googl-bye/internal/common"
+)
+
+type DigestRoutes struct {
+	l common.Logger
+	h handlers.DigestHandler
+}
+
+// NewDigestRoutes sets up the digest subscription routes with mws. The confirm and unsubscribe links in emails
+// are opened without signing in, so they only get publicMws. Opening them shows a form that posts back to them.
+func NewDigestRoutes(router api.AppRouter, l common.Logger, h handlers.DigestHandler, publicMws []middleware.Middleware, mws ...middleware.Middleware) DigestRoutes {
+	routes := DigestRoutes{
+		l: l,
+		h: h,
+	}
+
+	BASE_PATH := "/v1/api"
+
+	getSubscriptionsHandler := http.HandlerFunc(routes.h.GetSubscriptions)
+	router.Get(
+		BASE_PATH+"/digests",
+		middleware.Chain(getSubscriptionsHandler, mws...),
+	)
+
+	createSubscriptionHandler := http.HandlerFunc(routes.h.CreateSubscription)
+	router.Post(
+		BASE_PATH+"/digests",
+		middleware.Chain(createSubscriptionHandler, mws...),
+	)
+
+	deleteSubscriptionHandler := http.HandlerFunc(routes.h.DeleteSubscription)
+	router.Delete(
+		BASE_PATH+"/digests/{id}",
+		middleware.Chain(deleteSubscriptionHandler, mws...),
+	)
+
+	confirmPageHandler := http.HandlerFunc(routes.h.ConfirmPage)
+	router.Get(
+		BASE_PATH+"/digests/confirm",
+		middleware.Chain(confirmPageHandler, publicMws...),
+	)
+
+	confirmHandler := http.HandlerFunc(routes.h.ConfirmSubscription)
+	router.Post(
+		BASE_PATH+"/digests/confirm",
+		middleware.Chain(confirmHandler, publicMws...),
+	)
+
+	unsubscribePageHandler := http.HandlerFunc(routes.h.UnsubscribePage)
+	router.Get(
+		BASE_PATH+"/digests/unsubscribe",
+		middleware.Chain(unsubscribePageHandler, publicMws...),
+	)
+
+	unsubscribeHandler := http.HandlerFunc(routes.h.Unsubscribe)
+	router.Post(
+		BASE_PATH+"/digests/unsubscribe",
+		middleware.Chain(unsubscribeHandler, publicMws...),
+	)
+
+	return routes
+}
@@ -28,17 +28,53 @@ func NewGithubRoutes(router api.AppRouter, l common.Logger, h handlers.GithubHan
 		middleware.Chain(searchRepoHandler, mws...),
 	)
 
+	searchCodeHandler := http.HandlerFunc(routes.h.SearchCode)
+	router.Post(
+		BASE_PATH+"/search/code",
+		middleware.Chain(searchCodeHandler, mws...),
+	)
+
 	searchUserRepoHandler := http.HandlerFunc(routes.h.SearchReposForUser)
 	router.Get(
 		BASE_PATH+"/search",
 		middleware.Chain(searchUserRepoHandler, mws...),
 	)
 
+	ownerReposHandler := http.HandlerFunc(routes.h.StreamOwnerRepos)
+	router.Get(
+		BASE_PATH+"/owners/{owner}/repos",
+		middleware.Chain(ownerReposHandler, mws...),
+	)
+
 	searchUserHandler := http.HandlerFunc(routes.h.SearchUsers)
 	router.Get(
 		BASE_PATH+"/search-user",
 		middleware.Chain(searchUserHandler, mws...),
 	)
 
+	savedSearchesHandler := http.HandlerFunc(routes.h.GetSavedSearches)
+	router.Get(
+		BASE_PATH+"/searches",
+		middleware.Chain(savedSearchesHandler, mws...),
+	)
+
+	savedSearchHandler := http.HandlerFunc(routes.h.GetSavedSearch)
+	router.Get(
+		BASE_PATH+"/searches/{name}",
+		middleware.Chain(savedSearchHandler, mws...),
+	)
+
+	resumeSearchHandler := http.HandlerFunc(routes.h.ResumeSavedSearch)
+	router.Post(
+		BASE_PATH+"/searches/{name}/resume",
+		middleware.Chain(resumeSearchHandler, mws...),
+	)
+
+	scheduleSearchHandler := http.HandlerFunc(routes.h.SetSavedSearchSchedule)
+	router.Put(
+		BASE_PATH+"/searches/{name}/schedule",
+		middleware.Chain(scheduleSearchHandler, mws...),
+	)
+
 	return routes
 }
@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/api"
+	"github.com/jwtly10/googl-bye/api/handlers"
+	"github.com/jwtly10/googl-bye/api/middleware"
+	"github.com/jwtly10/googl-bye/internal/common"
+)
+
+type OutboundWebhookRoutes struct {
+	l common.Logger
+	h handlers.OutboundWebhookHandler
+}
+
+func NewOutboundWebhookRoutes(router api.AppRouter, l common.Logger, h handlers.OutboundWebhookHandler, mws ...middleware.Middleware) OutboundWebhookRoutes {
+	routes := OutboundWebhookRoutes{
+		l: l,
+		h: h,
+	}
+
+	BASE_PATH := "/v1/api"
+
+	getWebhooksHandler := http.HandlerFunc(routes.h.GetWebhooks)
+	router.Get(
+		BASE_PATH+"/webhooks",
+		middleware.Chain(getWebhooksHandler, mws...),
+	)
+
+	createWebhookHandler := http.HandlerFunc(routes.h.CreateWebhook)
+	router.Post(
+		BASE_PATH+"/webhooks",
+		middleware.Chain(createWebhookHandler, mws...),
+	)
+
+	deleteWebhookHandler := http.HandlerFunc(routes.h.DeleteWebhook)
+	router.Delete(
+		BASE_PATH+"/webhooks/{id}",
+		middleware.Chain(deleteWebhookHandler, mws...),
+	)
+
+	getDeliveriesHandler := http.HandlerFunc(routes.h.GetDeliveries)
+	router.Get(
+		BASE_PATH+"/webhooks/{id}/deliveries",
+		middleware.Chain(getDeliveriesHandler, mws...),
+	)
+
+	return routes
+}
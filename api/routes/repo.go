@@ -14,7 +14,8 @@ type RepoRoutes struct {
 	h handlers.RepoHandler
 }
 
-func NewRepoRoutes(router api.AppRouter, l common.Logger, h handlers.RepoHandler, mws ...middleware.Middleware) RepoRoutes {
+// NewRepoRoutes sets up the routes for saving repos with mws. Raising issues posts to the repo's forge, so it gets issueMws.
+func NewRepoRoutes(router api.AppRouter, l common.Logger, h handlers.RepoHandler, issueMws []middleware.Middleware, mws ...middleware.Middleware) RepoRoutes {
 	routes := RepoRoutes{
 		l: l,
 		h: h,
@@ -28,5 +29,11 @@ func NewRepoRoutes(router api.AppRouter, l common.Logger, h handlers.RepoHandler
 		middleware.Chain(saveHandler, mws...),
 	)
 
+	issueHandler := http.HandlerFunc(routes.h.CreateIssue)
+	router.Post(
+		BASE_PATH+"/repos/{id}/issues",
+		middleware.Chain(issueHandler, issueMws...),
+	)
+
 	return routes
 }
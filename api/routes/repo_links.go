@@ -14,7 +14,8 @@ type RepoLinkRoutes struct {
 	h handlers.RepoLinkHandler
 }
 
-func NewRepoLinkRoutes(router api.AppRouter, l common.Logger, h handlers.RepoLinkHandler, mws ...middleware.Middleware) RepoLinkRoutes {
+// NewRepoLinkRoutes sets up the routes for reading links with mws. Exports read every link, so they get exportMws.
+func NewRepoLinkRoutes(router api.AppRouter, l common.Logger, h handlers.RepoLinkHandler, exportMws []middleware.Middleware, mws ...middleware.Middleware) RepoLinkRoutes {
 	routes := RepoLinkRoutes{
 		l: l,
 		h: h,
@@ -34,5 +35,17 @@ func NewRepoLinkRoutes(router api.AppRouter, l common.Logger, h handlers.RepoLin
 		middleware.Chain(userRepoLinkHandler, mws...),
 	)
 
+	exportHandler := http.HandlerFunc(routes.h.ExportRepoLinks)
+	router.Get(
+		BASE_PATH+"/repoLinks/export",
+		middleware.Chain(exportHandler, exportMws...),
+	)
+
+	sarifHandler := http.HandlerFunc(routes.h.GetRepoSarif)
+	router.Get(
+		BASE_PATH+"/repos/{id}/sarif",
+		middleware.Chain(sarifHandler, exportMws...),
+	)
+
 	return routes
 }
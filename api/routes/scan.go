@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/api"
+	"github.com/jwtly10/googl-bye/api/handlers"
+	"github.com/jwtly10/googl-bye/api/middleware"
+	"github.com/jwtly10/googl-bye/internal/common"
+)
+
+type ScanRoutes struct {
+	l common.Logger
+	h handlers.ScanHandler
+}
+
+func NewScanRoutes(router api.AppRouter, l common.Logger, h handlers.ScanHandler, mws ...middleware.Middleware) ScanRoutes {
+	routes := ScanRoutes{
+		l: l,
+		h: h,
+	}
+
+	BASE_PATH := "/v1/api"
+
+	scanHandler := http.HandlerFunc(routes.h.Scan)
+	router.Post(
+		BASE_PATH+"/scan",
+		middleware.Chain(scanHandler, mws...),
+	)
+
+	return routes
+}
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/api"
+	"github.com/jwtly10/googl-bye/api/handlers"
+	"github.com/jwtly10/googl-bye/api/middleware"
+	"github.com/jwtly10/googl-bye/internal/common"
+)
+
+type StatsRoutes struct {
+	l common.Logger
+	h handlers.StatsHandler
+}
+
+func NewStatsRoutes(router api.AppRouter, l common.Logger, h handlers.StatsHandler, mws ...middleware.Middleware) StatsRoutes {
+	routes := StatsRoutes{
+		l: l,
+		h: h,
+	}
+
+	BASE_PATH := "/v1/api"
+
+	statsHandler := http.HandlerFunc(routes.h.GetStats)
+	router.Get(
+		BASE_PATH+"/stats",
+		middleware.Chain(statsHandler, mws...),
+	)
+
+	return routes
+}
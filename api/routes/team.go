@@ -0,0 +1,56 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/api"
+	"github.com/jwtly10/googl-bye/api/handlers"
+	"github.com/jwtly10/googl-bye/api/middleware"
+	"github.com/jwtly10/googl-bye/internal/common"
+)
+
+type TeamRoutes struct {
+	l common.Logger
+	h handlers.TeamHandler
+}
+
+func NewTeamRoutes(router api.AppRouter, l common.Logger, h handlers.TeamHandler, mws ...middleware.Middleware) TeamRoutes {
+	routes := TeamRoutes{
+		l: l,
+		h: h,
+	}
+
+	BASE_PATH := "/v1/api"
+
+	getTeamsHandler := http.HandlerFunc(routes.h.GetTeams)
+	router.Get(
+		BASE_PATH+"/teams",
+		middleware.Chain(getTeamsHandler, mws...),
+	)
+
+	createTeamHandler := http.HandlerFunc(routes.h.CreateTeam)
+	router.Post(
+		BASE_PATH+"/teams",
+		middleware.Chain(createTeamHandler, mws...),
+	)
+
+	getTeamHandler := http.HandlerFunc(routes.h.GetTeam)
+	router.Get(
+		BASE_PATH+"/teams/{id}",
+		middleware.Chain(getTeamHandler, mws...),
+	)
+
+	addMemberHandler := http.HandlerFunc(routes.h.AddTeamMember)
+	router.Post(
+		BASE_PATH+"/teams/{id}/members",
+		middleware.Chain(addMemberHandler, mws...),
+	)
+
+	removeMemberHandler := http.HandlerFunc(routes.h.RemoveTeamMember)
+	router.Delete(
+		BASE_PATH+"/teams/{id}/members/{userId}",
+		middleware.Chain(removeMemberHandler, mws...),
+	)
+
+	return routes
+}
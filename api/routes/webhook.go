@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/api"
+	"github.com/jwtly10/googl-bye/api/handlers"
+	"github.com/jwtly10/googl-bye/api/middleware"
+	"github.com/jwtly10/googl-bye/internal/common"
+)
+
+type WebhookRoutes struct {
+	l common.Logger
+	h handlers.WebhookHandler
+}
+
+// NewWebhookRoutes maps the webhook routes. Deliveries are verified by their signature, so they don't need auth.
+func NewWebhookRoutes(router api.AppRouter, l common.Logger, h handlers.WebhookHandler, mws ...middleware.Middleware) WebhookRoutes {
+	routes := WebhookRoutes{
+		l: l,
+		h: h,
+	}
+
+	BASE_PATH := "/v1/api"
+
+	githubWebhookHandler := http.HandlerFunc(routes.h.HandleGithubWebhook)
+	router.Post(
+		BASE_PATH+"/webhooks/github",
+		middleware.Chain(githubWebhookHandler, mws...),
+	)
+
+	return routes
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/jwtly10/googl-bye/internal/parser"
+)
+
+type expandResult struct {
+	Url         string `json:"url"`
+	ExpandedUrl string `json:"expandedUrl"`
+	Error       string `json:"error,omitempty"`
+}
+
+func runExpand(args []string) (int, error) {
+	fs := flag.NewFlagSet("expand", flag.ContinueOnError)
+	format := addFormatFlag(fs)
+	if err := parseFlags(fs, args, "expand [flags] <url>..."); err != nil {
+		return exitError, err
+	}
+	if fs.NArg() == 0 {
+		return exitError, fmt.Errorf("expand expects at least one url")
+	}
+
+	code := exitClean
+	results := make([]expandResult, 0, fs.NArg())
+	for _, link := range fs.Args() {
+		result := expandResult{Url: link}
+		expanded, err := parser.ExpandGooGlLink(link)
+		if err != nil {
+			result.Error = err.Error()
+			code = exitLinksFound
+		}
+		result.ExpandedUrl = expanded
+		results = append(results, result)
+	}
+
+	if format.value == formatJSON {
+		return code, writeJSON(stdout, results)
+	}
+
+	t := newTable("URL", "EXPANDED")
+	for _, r := range results {
+		expanded := r.ExpandedUrl
+		if r.Error != "" {
+			expanded = "ERROR: " + r.Error
+		}
+		t.row(r.Url, expanded)
+	}
+	return code, t.flush()
+}
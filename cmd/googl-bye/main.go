@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"go.uber.org/zap/zapcore"
+)
+
+const usage = `Usage: googl-bye <command> [flags] [arguments]
+
+Commands:
+  scan [flags] <path|git-url>    Scan a local directory, archive (.zip, .tar.gz, .tgz, .tar) or git repository
+  expand [flags] <url>...        Expand one or more goo.gl links
+  search [flags] <query>         Search a forge for repositories
+  report [flags]                 Report links found by the server (reads the database configured in .env)
+  migrate [flags] <command>      Migrate the database configured in .env: up, down or status
+
+Run 'googl-bye <command> -h' for the flags of a command.
+
+Exit codes:
+  0  No links found
+  1  Links found (scan, report) or failed to expand (expand)
+  2  Usage or runtime error
+`
+
+const (
+	exitClean      = 0
+	exitLinksFound = 1
+	exitError      = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command in args, returning the exit code
+func run(args []string) int {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, usage)
+		return exitError
+	}
+
+	// Logs go to stderr, so stdout only contains the output of the command
+	logger := common.NewLogger(false, zapcore.WarnLevel)
+
+	var code int
+	var err error
+	switch args[0] {
+	case "scan":
+		code, err = runScan(logger, args[1:])
+	case "expand":
+		code, err = runExpand(args[1:])
+	case "search":
+		code, err = runSearch(logger, args[1:])
+	case "report":
+		code, err = runReport(args[1:])
+	case "migrate":
+		code, err = runMigrate(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitClean
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command '%s'\n\n%s", args[0], usage)
+		return exitError
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return exitClean
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return code
+}
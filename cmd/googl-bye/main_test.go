@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{"No command", nil, exitError},
+		{"Unknown command", []string{"unknown"}, exitError},
+		{"Help", []string{"help"}, exitClean},
+		{"Command help", []string{"scan", "-h"}, exitClean},
+		{"Unknown flag", []string{"scan", "-unknown", "."}, exitError},
+		{"Unsupported format", []string{"search", "-format", "sarif", "language:go"}, exitError},
+		{"Missing arguments", []string{"expand"}, exitError},
+		{"Too many arguments", []string{"scan", "a", "b"}, exitError},
+		{"Missing path", []string{"scan", "does-not-exist"}, exitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureStdout(t)
+			assert.Equal(t, tt.expected, run(tt.args))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+
+	dbmigrations "github.com/jwtly10/googl-bye/db"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/migrate"
+)
+
+const migrateUsage = "migrate [flags] <up|down|status>"
+
+func runMigrate(args []string) (int, error) {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	format := addFormatFlag(fs)
+	steps := fs.Int("steps", 1, "number of migrations to roll back with down")
+	if err := parseFlags(fs, args, migrateUsage); err != nil {
+		return exitError, err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitError, fmt.Errorf("expected one of up, down or status")
+	}
+	if *steps < 1 {
+		return exitError, fmt.Errorf("invalid steps '%d', expected at least 1", *steps)
+	}
+
+	config, err := common.LoadConfig()
+	if err != nil {
+		return exitError, fmt.Errorf("failed to load config: %v", err)
+	}
+	db, err := common.ConnectDB(config)
+	if err != nil {
+		return exitError, err
+	}
+	defer db.Close()
+
+	migrator, err := migrate.NewMigrator(db, dbmigrations.Migrations())
+	if err != nil {
+		return exitError, err
+	}
+
+	ctx := context.Background()
+	switch fs.Arg(0) {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		printMigrations("Applied", applied)
+		if err != nil {
+			return exitError, err
+		}
+	case "down":
+		rolledBack, err := migrator.Down(ctx, *steps)
+		printMigrations("Rolled back", rolledBack)
+		if err != nil {
+			return exitError, err
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return exitError, err
+		}
+		if format.value == formatJSON {
+			return exitClean, writeJSON(stdout, statuses)
+		}
+		return exitClean, writeMigrationTable(statuses)
+	default:
+		fs.Usage()
+		return exitError, fmt.Errorf("unknown migrate command '%s', expected one of up, down or status", fs.Arg(0))
+	}
+	return exitClean, nil
+}
+
+func printMigrations(verb string, migrations []migrate.Migration) {
+	if len(migrations) == 0 {
+		fmt.Fprintf(stdout, "%s no migrations\n", verb)
+	}
+	for _, m := range migrations {
+		fmt.Fprintf(stdout, "%s %d_%s\n", verb, m.Version, m.Name)
+	}
+}
+
+func writeMigrationTable(statuses []migrate.MigrationStatus) error {
+	t := newTable("VERSION", "NAME", "APPLIED")
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Local().Format(time.DateTime)
+		}
+		if s.Missing {
+			applied += " (not in this binary)"
+		}
+		t.row(strconv.FormatInt(s.Version, 10), s.Name, applied)
+	}
+	return t.flush()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatSarif = "sarif"
+)
+
+// stdout is where commands write their output, so tests can read it
+var stdout io.Writer = os.Stdout
+
+// outputFormat is a flag value restricted to the formats a command supports
+type outputFormat struct {
+	value   string
+	allowed []string
+}
+
+func (f *outputFormat) String() string {
+	return f.value
+}
+
+func (f *outputFormat) Set(value string) error {
+	for _, a := range f.allowed {
+		if value == a {
+			f.value = value
+			return nil
+		}
+	}
+	return fmt.Errorf("expected one of: %s", strings.Join(f.allowed, ", "))
+}
+
+// addFormatFlag registers the --format flag shared by all commands. Table and json are always supported.
+func addFormatFlag(fs *flag.FlagSet, extra ...string) *outputFormat {
+	f := &outputFormat{
+		value:   formatTable,
+		allowed: append([]string{formatTable, formatJSON}, extra...),
+	}
+	fs.Var(f, "format", "output format: "+strings.Join(f.allowed, ", "))
+	return f
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// table writes tab aligned columns to stdout
+type table struct {
+	tw *tabwriter.Writer
+}
+
+func newTable(headers ...string) *table {
+	t := &table{tw: tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)}
+	t.row(headers...)
+	return t
+}
+
+func (t *table) row(columns ...string) {
+	for i, c := range columns {
+		// Tabs and newlines would break the alignment
+		columns[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(c)
+	}
+	fmt.Fprintln(t.tw, strings.Join(columns, "\t"))
+}
+
+func (t *table) flush() error {
+	return t.tw.Flush()
+}
+
+// parseFlags parses the flags of a command, printing usage on error
+func parseFlags(fs *flag.FlagSet, args []string, usage string) error {
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: googl-bye %s\n\nFlags:\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs.Parse(args)
+}
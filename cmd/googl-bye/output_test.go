@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// captureStdout returns a buffer with the output of commands run during the test
+func captureStdout(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := stdout
+	stdout = &buf
+	t.Cleanup(func() { stdout = previous })
+	return &buf
+}
+
+func TestTable(t *testing.T) {
+	out := captureStdout(t)
+
+	tbl := newTable("NAME", "URL")
+	tbl.row("repo", "https://goo.gl/abc")
+	tbl.row("multi\nline", "tab\tbed")
+	assert.NoError(t, tbl.flush())
+
+	assert.Equal(t, "NAME        URL\nrepo        https://goo.gl/abc\nmulti line  tab bed\n", out.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, writeJSON(&buf, map[string]int{"links": 2}))
+	assert.Equal(t, "{\n  \"links\": 2\n}\n", buf.String())
+}
+
+func TestFormatFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		extra    []string
+		expected string
+		wantErr  bool
+	}{
+		{"Defaults to table", nil, nil, formatTable, false},
+		{"Accepts json", []string{"-format", "json"}, nil, formatJSON, false},
+		{"Accepts extra formats", []string{"-format", "sarif"}, []string{formatSarif}, formatSarif, false},
+		{"Rejects formats the command doesn't support", []string{"-format", "sarif"}, nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			format := addFormatFlag(fs, tt.extra...)
+
+			err := fs.Parse(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, format.value)
+		})
+	}
+}
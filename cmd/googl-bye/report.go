@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+)
+
+func runReport(args []string) (int, error) {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	format := addFormatFlag(fs)
+	author := fs.String("author", "", "only report repositories by this author")
+	if err := parseFlags(fs, args, "report [flags]"); err != nil {
+		return exitError, err
+	}
+
+	config, err := common.LoadConfig()
+	if err != nil {
+		return exitError, fmt.Errorf("failed to load config: %v", err)
+	}
+	db, err := common.ConnectDB(config)
+	if err != nil {
+		return exitError, err
+	}
+	defer db.Close()
+
+	repoLinkRepo := repository.NewRepoLinkRepository(db)
+
+	// Reports can be shared, so only public repos are included
+	var repos []*models.RepoWithLinks
+	if *author != "" {
+		repos, err = repoLinkRepo.GetRepositoryWithLinksForUser(*author, repository.NoViewer)
+	} else {
+		repos, err = repoLinkRepo.GetRepositoryWithLinks(repository.NoViewer)
+	}
+	if err != nil {
+		return exitError, err
+	}
+
+	linkCount := 0
+	for _, r := range repos {
+		linkCount += len(r.Links)
+	}
+
+	if format.value == formatJSON {
+		err = writeJSON(stdout, repos)
+	} else {
+		err = writeReportTable(repos, linkCount)
+	}
+	if err != nil {
+		return exitError, err
+	}
+
+	if linkCount > 0 {
+		return exitLinksFound, nil
+	}
+	return exitClean, nil
+}
+
+func writeReportTable(repos []*models.RepoWithLinks, linkCount int) error {
+	if linkCount == 0 {
+		fmt.Fprintln(stdout, "No goo.gl links found")
+		return nil
+	}
+
+	t := newTable("REPOSITORY", "LOCATION", "URL", "EXPANDED")
+	for _, r := range repos {
+		for _, l := range r.Links {
+			t.row(
+				r.Author+"/"+r.Name,
+				fmt.Sprintf("%s:%d", l.File, l.LineNumber),
+				l.Url,
+				l.ExpandedURL,
+			)
+		}
+	}
+	if err := t.flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "\nFound %d goo.gl links in %d repositories\n", linkCount, len(repos))
+	return nil
+}
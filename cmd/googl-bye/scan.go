@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/export"
+	"github.com/jwtly10/googl-bye/internal/forge"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/parser"
+	"github.com/jwtly10/googl-bye/internal/service"
+)
+
+func runScan(logger common.Logger, args []string) (int, error) {
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	format := addFormatFlag(fs, formatSarif)
+	if err := parseFlags(fs, args, "scan [flags] <path|git-url>"); err != nil {
+		return exitError, err
+	}
+	if fs.NArg() != 1 {
+		return exitError, fmt.Errorf("scan expects a single path or git url")
+	}
+	target := fs.Arg(0)
+
+	forges := forge.NewRegistry(forge.NewGithubForge(nil), forge.NewGitlabForge("", ""))
+	git := parser.NewGitCmdLine(logger)
+	repoParser := parser.NewRepoParser(git, logger, forges)
+
+	var links []models.ParserLinksModel
+	var err error
+	switch {
+	case isGitURL(target):
+		links, err = scanGitURL(repoParser, git, target)
+	case parser.IsSupportedArchive(target):
+		links, err = scanArchive(repoParser, target)
+	default:
+		links, err = repoParser.ParseDirectory(context.Background(), target, target)
+	}
+	if err != nil {
+		return exitError, err
+	}
+
+	report := service.NewScanReport(target, links)
+	switch format.value {
+	case formatJSON:
+		err = writeJSON(stdout, report)
+	case formatSarif:
+		err = writeJSON(stdout, export.NewScanSarif(report))
+	default:
+		err = writeScanTable(report)
+	}
+	if err != nil {
+		return exitError, err
+	}
+
+	if report.LinkCount > 0 {
+		return exitLinksFound, nil
+	}
+	return exitClean, nil
+}
+
+func scanArchive(repoParser *parser.RepoParser, path string) ([]models.ParserLinksModel, error) {
+	dir, err := os.MkdirTemp("", "scan-extract-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := parser.ExtractArchive(path, filepath.Base(path), dir); err != nil {
+		return nil, err
+	}
+
+	links, err := repoParser.ParseDirectory(context.Background(), path, dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range links {
+		links[i].Path = links[i].File
+	}
+	return links, nil
+}
+
+// scanGitURL clones and parses a repository. Repos on known forges get links to the matched lines.
+func scanGitURL(repoParser *parser.RepoParser, git parser.GitCmdLineI, target string) ([]models.ParserLinksModel, error) {
+	if repo, ok := repoFromGitURL(target); ok {
+		return repoParser.ParseRepository(repo)
+	}
+
+	dir, err := os.MkdirTemp("", "repo-clone-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := git.Clone(target, dir, ""); err != nil {
+		return nil, err
+	}
+	return repoParser.ParseDirectory(context.Background(), target, dir)
+}
+
+func isGitURL(target string) bool {
+	if _, err := os.Stat(target); err == nil {
+		return false
+	}
+	return strings.HasPrefix(target, "https://") ||
+		strings.HasPrefix(target, "http://") ||
+		strings.HasPrefix(target, "git@") ||
+		strings.HasPrefix(target, "ssh://")
+}
+
+// repoFromGitURL builds a repository model from a github.com or gitlab.com url
+func repoFromGitURL(target string) (models.RepositoryModel, bool) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return models.RepositoryModel{}, false
+	}
+
+	var repoForge string
+	switch u.Host {
+	case "github.com":
+		repoForge = models.ForgeGitHub
+	case "gitlab.com":
+		repoForge = models.ForgeGitLab
+	default:
+		return models.RepositoryModel{}, false
+	}
+
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return models.RepositoryModel{}, false
+	}
+
+	return models.RepositoryModel{
+		Author:   path[:i],
+		Name:     path[i+1:],
+		Forge:    repoForge,
+		CloneUrl: target,
+	}, true
+}
+
+func writeScanTable(report *models.ScanReport) error {
+	if report.LinkCount == 0 {
+		fmt.Fprintln(stdout, "No goo.gl links found")
+		return nil
+	}
+
+	t := newTable("LOCATION", "TYPE", "URL", "EXPANDED")
+	for _, l := range report.Links {
+		t.row(
+			fmt.Sprintf("%s:%d:%d", l.File, l.LineNumber, l.ColumnNumber),
+			l.Classification,
+			l.Url,
+			l.ExpandedURL,
+		)
+	}
+	if err := t.flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "\nFound %d goo.gl links\n", report.LinkCount)
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGitURL(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		target   string
+		expected bool
+	}{
+		{"Https url", "https://github.com/owner/repo", true},
+		{"Http url", "http://git.example.com/owner/repo.git", true},
+		{"Scp style url", "git@github.com:owner/repo.git", true},
+		{"Ssh url", "ssh://git@github.com/owner/repo.git", true},
+		{"Relative path", "./repo", false},
+		{"Absolute path", dir, false},
+		{"Archive", "repo.tar.gz", false},
+		{"Other scheme", "ftp://example.com/repo", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isGitURL(tt.target))
+		})
+	}
+}
+
+func TestRepoFromGitURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		expected models.RepositoryModel
+		ok       bool
+	}{
+		{
+			"GitHub repo",
+			"https://github.com/owner/repo",
+			models.RepositoryModel{Author: "owner", Name: "repo", Forge: models.ForgeGitHub, CloneUrl: "https://github.com/owner/repo"},
+			true,
+		},
+		{
+			"GitHub clone url",
+			"https://github.com/owner/repo.git",
+			models.RepositoryModel{Author: "owner", Name: "repo", Forge: models.ForgeGitHub, CloneUrl: "https://github.com/owner/repo.git"},
+			true,
+		},
+		{
+			"GitLab subgroup",
+			"https://gitlab.com/group/sub/repo/",
+			models.RepositoryModel{Author: "group/sub", Name: "repo", Forge: models.ForgeGitLab, CloneUrl: "https://gitlab.com/group/sub/repo/"},
+			true,
+		},
+		{"Unknown host", "https://git.example.com/owner/repo", models.RepositoryModel{}, false},
+		{"Ssh url", "git@github.com:owner/repo.git", models.RepositoryModel{}, false},
+		{"Missing repo", "https://github.com/owner", models.RepositoryModel{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, ok := repoFromGitURL(tt.target)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, repo)
+		})
+	}
+}
+
+func TestRunScan(t *testing.T) {
+	// Links are expanded through a proxy that refuses connections, so the scan doesn't depend on goo.gl
+	t.Setenv("HTTPS_PROXY", "http://127.0.0.1:1")
+
+	clean := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(clean, "main.go"), []byte("package main\n"), 0644))
+
+	out := captureStdout(t)
+	assert.Equal(t, exitClean, run([]string{"scan", clean}))
+	assert.Equal(t, "No goo.gl links found\n", out.String())
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Docs\n\nSee https://goo.gl/abc123\n"), 0644))
+
+	out.Reset()
+	assert.Equal(t, exitLinksFound, run([]string{"scan", dir}))
+	assert.Contains(t, out.String(), "README.md:3:5")
+	assert.Contains(t, out.String(), "https://goo.gl/abc123")
+	assert.Contains(t, out.String(), "Found 1 goo.gl links")
+
+	out.Reset()
+	assert.Equal(t, exitLinksFound, run([]string{"scan", "-format", "json", dir}))
+	var report models.ScanReport
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, 1, report.LinkCount)
+	assert.Equal(t, "https://goo.gl/abc123", report.Links[0].Url)
+	assert.Equal(t, "README.md", report.Links[0].File)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/forge"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/search"
+)
+
+func runSearch(logger common.Logger, args []string) (int, error) {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	format := addFormatFlag(fs)
+	forgeName := fs.String("forge", models.ForgeGitHub, "forge to search: github, gitlab or gitea")
+	forgeURL := fs.String("forge-url", "", "base url of a self-hosted gitlab or gitea instance")
+	pages := fs.Int("pages", 1, "number of pages of results to fetch")
+	perPage := fs.Int("per-page", 50, "results per page")
+	if err := parseFlags(fs, args, "search [flags] <query>"); err != nil {
+		return exitError, err
+	}
+	if fs.NArg() == 0 {
+		return exitError, fmt.Errorf("search expects a query")
+	}
+	query := strings.Join(fs.Args(), " ")
+
+	// Tokens are read from the same env vars as the server
+	var f forge.Forge
+	switch *forgeName {
+	case models.ForgeGitHub:
+		token := os.Getenv("GH_TOKEN")
+		if token == "" {
+			return exitError, fmt.Errorf("GH_TOKEN must be set to search github")
+		}
+		f = forge.NewGithubForge(common.NewGitHubClient(token, logger))
+
+		// GitHub searches get the same guardrails as the server
+		config, err := common.LoadSearchConfig()
+		if err != nil {
+			return exitError, err
+		}
+		if query, err = search.NewGuardrails(config).Apply(query); err != nil {
+			return exitError, err
+		}
+		fmt.Fprintf(os.Stderr, "Searching: %s\n", query)
+	case models.ForgeGitLab:
+		f = forge.NewGitlabForge(*forgeURL, os.Getenv("GITLAB_TOKEN"))
+	case models.ForgeGitea:
+		if *forgeURL == "" {
+			return exitError, fmt.Errorf("--forge-url is required to search gitea")
+		}
+		f = forge.NewGiteaForge(*forgeURL, os.Getenv("GITEA_TOKEN"))
+	default:
+		return exitError, fmt.Errorf("unknown forge '%s'", *forgeName)
+	}
+
+	repos := []models.RepositoryModel{}
+	page := 1
+	for i := 0; i < *pages; i++ {
+		found, nextPage, err := f.SearchRepositories(context.Background(), query, page, *perPage)
+		if err != nil {
+			return exitError, err
+		}
+		repos = append(repos, found...)
+		if nextPage == 0 {
+			break
+		}
+		page = nextPage
+	}
+
+	if format.value == formatJSON {
+		return exitClean, writeJSON(stdout, repos)
+	}
+
+	t := newTable("REPOSITORY", "LANGUAGE", "STARS", "URL")
+	for _, r := range repos {
+		t.row(r.Author+"/"+r.Name, r.Language, strconv.Itoa(r.Stars), r.GhUrl)
+	}
+	return exitClean, t.flush()
+}
@@ -14,11 +14,19 @@ import (
 	"github.com/jwtly10/googl-bye/api/handlers"
 	"github.com/jwtly10/googl-bye/api/middleware"
 	"github.com/jwtly10/googl-bye/api/routes"
+	dbmigrations "github.com/jwtly10/googl-bye/db"
+	"github.com/jwtly10/googl-bye/internal/auth"
 	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/digest"
+	"github.com/jwtly10/googl-bye/internal/forge"
+	"github.com/jwtly10/googl-bye/internal/mail"
+	"github.com/jwtly10/googl-bye/internal/migrate"
+	"github.com/jwtly10/googl-bye/internal/models"
 	"github.com/jwtly10/googl-bye/internal/parser"
 	"github.com/jwtly10/googl-bye/internal/repository"
 	"github.com/jwtly10/googl-bye/internal/search"
 	"github.com/jwtly10/googl-bye/internal/service"
+	"github.com/jwtly10/googl-bye/internal/webhook"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -42,12 +50,34 @@ func main() {
 		logger.Errorf("Error connecting to DB: %v", err)
 	}
 
+	// Migrate the db. Instances starting at the same time wait for each other to finish.
+	migrator, err := migrate.NewMigrator(db, dbmigrations.Migrations())
+	if err != nil {
+		logger.Fatalf("Failed to load migrations: %v", err)
+	}
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		logger.Fatalf("Failed to migrate DB: %v", err)
+	}
+	for _, m := range applied {
+		logger.Infof("Applied migration %d_%s", m.Version, m.Name)
+	}
+
 	// Init repos
 	repoRepo := repository.NewRepoRepository(db)
 	repoLinkRepo := repository.NewRepoLinkRepository(db)
 	stateRepo := repository.NewParserStateRepository(db)
 	linkRepo := repository.NewParserLinkRepository(db)
 	searchRepo := repository.NewSearchParamRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+	httpCacheRepo := repository.NewHttpCacheRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+	apiKeyRepo := repository.NewApiKeyRepository(db)
+	webhookDeliveryRepo := repository.NewWebhookDeliveryRepository(db)
+	outboundWebhookRepo := repository.NewOutboundWebhookRepository(db)
+	digestSubscriptionRepo := repository.NewDigestSubscriptionRepository(db)
 
 	// Init repo cache
 	repoCache, err := common.NewRepoCache(repoRepo, logger)
@@ -55,6 +85,23 @@ func main() {
 		logger.Fatalf("Failed to create a repo cache on init: %v", err)
 	}
 
+	// Init github client. Requests rotate between the configured tokens, and responses are cached in the db
+	ghCache := common.NewCachingTransport(http.DefaultTransport, httpCacheRepo, logger)
+	tokenPool, err := common.NewTokenPoolFromConfig(config, ghCache, logger)
+	if err != nil {
+		logger.Fatalf("Failed to load github tokens: %v", err)
+	}
+	ghClient := common.NewGitHubClientWithPool(tokenPool, logger)
+
+	// Init forges. GitLab defaults to gitlab.com, Gitea is only available when configured
+	forges := forge.NewRegistry(
+		forge.NewGithubForge(ghClient),
+		forge.NewGitlabForge(config.GitlabURL, config.GitlabToken),
+	)
+	if config.GiteaURL != "" {
+		forges.Register(forge.NewGiteaForge(config.GiteaURL, config.GiteaToken))
+	}
+
 	// ***** SERVER SETUP *****
 
 	// Setup main router
@@ -63,25 +110,112 @@ func main() {
 	// Attach global middleware
 	loggerMw := middleware.NewRequestLoggerMiddleware(logger)
 
+	// Setup Auth route. Signing in with GitHub is optional, API routes are only protected when it is configured
+	var ghOAuth *auth.GithubOAuth
+	var tokenCipher *auth.TokenCipher
+	if config.GHOAuthClientID != "" {
+		ghOAuth = auth.NewGithubOAuth(config.GHOAuthClientID, config.GHOAuthClientSecret, config.GHOAuthRedirectURL)
+		tokenCipher, err = auth.NewTokenCipher(config.SessionSecret)
+		if err != nil {
+			logger.Fatalf("Failed to create token cipher: %v", err)
+		}
+	}
+	authService := service.NewAuthService(ghOAuth, tokenCipher, userRepo, sessionRepo, apiKeyRepo, config, logger)
+	authHandler := handlers.NewAuthHandler(logger, *authService)
+	authMw := middleware.NewAuthMiddleware(authService, logger)
+
+	// Rate limits are per client. Expensive routes get their own lower limits, so they don't use up the budget of cheap reads:
+	// searches use the GitHub search quota, scans parse uploads, issues are raised on the forge, and exports read every link
+	apiLimitMw := middleware.NewRateLimitMiddleware(config.RateLimitPerMinute, time.Minute, logger)
+	searchLimitMw := middleware.NewRateLimitMiddleware(config.RateLimitSearchPerMinute, time.Minute, logger)
+	scanLimitMw := middleware.NewRateLimitMiddleware(config.RateLimitScanPerMinute, time.Minute, logger)
+	issueLimitMw := middleware.NewRateLimitMiddleware(config.RateLimitIssuePerMinute, time.Minute, logger)
+	exportLimitMw := middleware.NewRateLimitMiddleware(config.RateLimitExportPerMinute, time.Minute, logger)
+
+	routes.NewAuthRoutes(router, logger, *authHandler, authMw, apiLimitMw, loggerMw)
+
+	// Middleware is applied in order, so the logger wraps everything and logs rejected requests,
+	// and rate limits run after auth so clients are limited by their verified key or user, not by credentials they send
+	apiMws := []middleware.Middleware{}
+	if authService.Enabled() {
+		apiMws = append(apiMws, authMw)
+	} else {
+		logger.Warn("GH_OAUTH_CLIENT_ID is not set, API routes are not protected")
+	}
+	// scoped adds a check that API keys have the scope of the routes, and the rate limit of the routes
+	scoped := func(scope string, limitMw middleware.Middleware) []middleware.Middleware {
+		mws := []middleware.Middleware{middleware.NewScopeMiddleware(scope, logger), limitMw}
+		mws = append(mws, apiMws...)
+		return append(mws, loggerMw)
+	}
+
 	// Setup frontend routes
 	// router.SetupSwagger() // TODO
 	router.ServeStaticFiles("./react/dist") // TODO: Should this only happen in dev. In prod we package binary with the frontend, and just ship the binary
 
 	// Setup Github route
-	ghs := search.NewGithubSearch(config, logger, searchRepo, repoRepo)
-	githubService := service.NewGithubService(*ghs, logger)
+	ghs := search.NewGithubSearch(config, ghClient, logger, searchRepo, repoRepo)
+	githubService := service.NewGithubService(*ghs, forges, repoCache, search.NewGuardrails(config), authService, teamRepo, logger)
 	githubHandler := handlers.NewGithubHandler(logger, *githubService)
-	routes.NewGithubRoutes(router, logger, *githubHandler, loggerMw)
+	routes.NewGithubRoutes(router, logger, *githubHandler, scoped(models.ScopeSearch, searchLimitMw)...)
 
 	// Setup Repo route
-	repoService := service.NewRepoService(repoRepo, logger, repoCache)
+	repoService := service.NewRepoService(repoRepo, repoLinkRepo, teamRepo, forges, authService, logger, repoCache)
 	repoHandler := handlers.NewRepoHandler(logger, *repoService)
-	routes.NewRepoRoutes(router, logger, *repoHandler, loggerMw)
+	routes.NewRepoRoutes(router, logger, *repoHandler, scoped(models.ScopeManage, issueLimitMw), scoped(models.ScopeSave, apiLimitMw)...)
 
 	// Setup RepoLink route
 	repoLinkService := service.NewRepoLinkService(*repoLinkRepo, logger)
 	repoLinkHandler := handlers.NewRepoLinkHandler(logger, *repoLinkService)
-	routes.NewRepoLinkRoutes(router, logger, *repoLinkHandler, loggerMw)
+	routes.NewRepoLinkRoutes(router, logger, *repoLinkHandler, scoped(models.ScopeRead, exportLimitMw), scoped(models.ScopeRead, apiLimitMw)...)
+
+	// Setup Scan route
+	scanParser := parser.NewRepoParser(parser.NewGitCmdLine(logger), logger, forges)
+	scanService := service.NewScanService(scanParser, config, logger)
+	scanHandler := handlers.NewScanHandler(logger, *scanService)
+	routes.NewScanRoutes(router, logger, *scanHandler, scoped(models.ScopeSearch, scanLimitMw)...)
+
+	// Setup Stats route
+	statsService := service.NewStatsService(statsRepo, logger)
+	statsHandler := handlers.NewStatsHandler(logger, statsService)
+	routes.NewStatsRoutes(router, logger, *statsHandler, scoped(models.ScopeRead, apiLimitMw)...)
+
+	// Setup Team route
+	teamService := service.NewTeamService(teamRepo, userRepo, logger)
+	teamHandler := handlers.NewTeamHandler(logger, *teamService)
+	routes.NewTeamRoutes(router, logger, *teamHandler, scoped(models.ScopeManage, apiLimitMw)...)
+
+	// Setup ApiKey route
+	apiKeyService := service.NewApiKeyService(apiKeyRepo, logger)
+	apiKeyHandler := handlers.NewApiKeyHandler(logger, *apiKeyService)
+	routes.NewApiKeyRoutes(router, logger, *apiKeyHandler, scoped(models.ScopeManage, apiLimitMw)...)
+
+	// Setup Webhook route
+	webhookService := service.NewWebhookService(config.GHWebhookSecret, repoRepo, webhookDeliveryRepo, logger)
+	webhookHandler := handlers.NewWebhookHandler(logger, *webhookService)
+	routes.NewWebhookRoutes(router, logger, *webhookHandler, loggerMw)
+
+	// Setup outbound Webhook route
+	outboundWebhookService := service.NewOutboundWebhookService(outboundWebhookRepo, logger)
+	outboundWebhookHandler := handlers.NewOutboundWebhookHandler(logger, *outboundWebhookService)
+	routes.NewOutboundWebhookRoutes(router, logger, *outboundWebhookHandler, scoped(models.ScopeManage, apiLimitMw)...)
+
+	// Setup Digest route
+	// Digests and their confirmation emails are only sent when an SMTP server is configured
+	var sender mail.Sender
+	if config.SMTPHost != "" {
+		sender = mail.NewSMTPSender(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.SMTPFrom)
+	}
+	digestService := service.NewDigestService(digestSubscriptionRepo, sender, config.AppURL, logger)
+	digestHandler := handlers.NewDigestHandler(logger, *digestService)
+	routes.NewDigestRoutes(router, logger, *digestHandler, []middleware.Middleware{apiLimitMw, loggerMw}, scoped(models.ScopeRead, apiLimitMw)...)
+
+	// Setup Admin route
+	adminService := service.NewAdminService(ghClient, logger)
+	adminHandler := handlers.NewAdminHandler(logger, *adminService)
+	// Admin routes are only for the server admins, checked after the user is authenticated
+	adminMws := append([]middleware.Middleware{middleware.NewServerAdminMiddleware(config.AdminLogins, logger)}, scoped(models.ScopeRead, apiLimitMw)...)
+	routes.NewAdminRoutes(router, logger, *adminHandler, adminMws...)
 
 	// Create a context that we can cancel to stop all goroutines
 	ctx, cancel := context.WithCancel(context.Background())
@@ -105,7 +239,9 @@ func main() {
 	}()
 
 	// Start parser
-	parser := parser.NewParser(logger, repoRepo, stateRepo, linkRepo)
+	// Outbound webhooks are sent when repos finish parsing
+	dispatcher := webhook.NewDispatcher(outboundWebhookRepo, logger)
+	parser := parser.NewParser(logger, repoRepo, stateRepo, linkRepo, forges, authService, dispatcher)
 	limit := 10
 	ticker := time.NewTicker(time.Duration(config.ParserInterval) * time.Second)
 	logger.Infof("Parser Job running every '%d' seconds", config.ParserInterval)
@@ -124,6 +260,48 @@ func main() {
 		}
 	}()
 
+	// Start crawler for scheduled searches
+	crawler := search.NewCrawler(ghs, logger, config.CrawlRateLimitReserve)
+	crawlTicker := time.NewTicker(time.Duration(config.CrawlInterval) * time.Second)
+	logger.Infof("Crawler Job running every '%d' seconds", config.CrawlInterval)
+	defer crawlTicker.Stop()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-crawlTicker.C:
+				crawler.RunDue(ctx)
+			}
+		}
+	}()
+
+	// Start digest emails, when an SMTP server is configured
+	if sender != nil {
+		digester := digest.NewDigester(repoLinkRepo, digestSubscriptionRepo, sender, config.AppURL, logger)
+		digestTicker := time.NewTicker(time.Duration(config.DigestInterval) * time.Second)
+		logger.Infof("Digest Job running every '%d' seconds", config.DigestInterval)
+		defer digestTicker.Stop()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-digestTicker.C:
+					digester.SendDigests(ctx)
+				}
+			}
+		}()
+	} else {
+		logger.Warn("SMTP_HOST is not set, digest emails are not sent")
+	}
+
 	// Wait for interrupt signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -0,0 +1,20 @@
+// Package db has the database schema, as migrations embedded in the binary
+package db
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrations are the schema migrations, named <version>_<name>.up.sql and <version>_<name>.down.sql
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		// The directory is embedded, so this can't happen
+		panic(err)
+	}
+	return sub
+}
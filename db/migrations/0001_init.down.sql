@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS parser_links_tb;
+DROP TABLE IF EXISTS parser_state_tb;
+DROP TABLE IF EXISTS search_params_history_tb;
+DROP TABLE IF EXISTS repository_tb;
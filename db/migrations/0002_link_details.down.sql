@@ -0,0 +1,7 @@
+ALTER TABLE parser_links_tb
+    DROP COLUMN IF EXISTS column_number,
+    DROP COLUMN IF EXISTS classification,
+    DROP COLUMN IF EXISTS snippet,
+    DROP COLUMN IF EXISTS snippet_start_line,
+    DROP COLUMN IF EXISTS match_start,
+    DROP COLUMN IF EXISTS match_end;
@@ -0,0 +1,7 @@
+ALTER TABLE parser_links_tb
+    ADD COLUMN IF NOT EXISTS column_number INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS classification VARCHAR(20) NOT NULL DEFAULT 'UNKNOWN',
+    ADD COLUMN IF NOT EXISTS snippet TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS snippet_start_line INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS match_start INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS match_end INTEGER NOT NULL DEFAULT 0;
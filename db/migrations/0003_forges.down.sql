@@ -0,0 +1,6 @@
+-- Fails if the same repo is tracked on more than one forge
+ALTER TABLE repository_tb DROP CONSTRAINT IF EXISTS repository_tb_forge_name_author_key;
+ALTER TABLE repository_tb ADD CONSTRAINT repository_tb_name_author_key UNIQUE (name, author);
+ALTER TABLE repository_tb DROP COLUMN IF EXISTS forge;
+
+ALTER TABLE search_params_history_tb DROP COLUMN IF EXISTS forge;
@@ -0,0 +1,11 @@
+-- Repos are unique per forge, existing repos are all from GitHub
+ALTER TABLE repository_tb ADD COLUMN IF NOT EXISTS forge VARCHAR(20) NOT NULL DEFAULT 'github';
+ALTER TABLE repository_tb DROP CONSTRAINT IF EXISTS repository_tb_name_author_key;
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'repository_tb_forge_name_author_key') THEN
+        ALTER TABLE repository_tb ADD CONSTRAINT repository_tb_forge_name_author_key UNIQUE (forge, name, author);
+    END IF;
+END $$;
+
+ALTER TABLE search_params_history_tb ADD COLUMN IF NOT EXISTS forge VARCHAR(20) NOT NULL DEFAULT 'github';
@@ -0,0 +1,7 @@
+ALTER TABLE search_params_history_tb
+    DROP COLUMN IF EXISTS auto_save,
+    DROP COLUMN IF EXISTS repos_found,
+    DROP COLUMN IF EXISTS exhausted,
+    DROP COLUMN IF EXISTS schedule,
+    DROP COLUMN IF EXISTS last_run_at,
+    DROP COLUMN IF EXISTS next_run_at;
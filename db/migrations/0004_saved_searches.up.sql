@@ -0,0 +1,7 @@
+ALTER TABLE search_params_history_tb
+    ADD COLUMN IF NOT EXISTS auto_save BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS repos_found INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS exhausted BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS schedule TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS last_run_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS next_run_at TIMESTAMPTZ;
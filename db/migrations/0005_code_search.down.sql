@@ -0,0 +1,3 @@
+ALTER TABLE repository_tb
+    DROP COLUMN IF EXISTS priority,
+    DROP COLUMN IF EXISTS candidate_files;
@@ -0,0 +1,3 @@
+ALTER TABLE repository_tb
+    ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS candidate_files TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS http_cache_tb (
+    id SERIAL PRIMARY KEY,
+    key TEXT NOT NULL,
+    url TEXT NOT NULL,
+    etag TEXT NOT NULL DEFAULT '',
+    last_modified TEXT NOT NULL DEFAULT '',
+    status_code INTEGER NOT NULL,
+    header JSONB NOT NULL DEFAULT '{}',
+    body BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (key)
+);
@@ -0,0 +1,6 @@
+ALTER TABLE repository_tb
+    DROP COLUMN IF EXISTS private,
+    DROP COLUMN IF EXISTS owner_user_id;
+
+DROP TABLE IF EXISTS session_tb;
+DROP TABLE IF EXISTS user_tb;
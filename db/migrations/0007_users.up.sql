@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS user_tb (
+    id SERIAL PRIMARY KEY,
+    github_id BIGINT NOT NULL,
+    login TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    avatar_url TEXT NOT NULL DEFAULT '',
+    access_token BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (github_id)
+);
+
+CREATE TABLE IF NOT EXISTS session_tb (
+    id SERIAL PRIMARY KEY,
+    token_hash TEXT NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES user_tb(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (token_hash)
+);
+
+ALTER TABLE repository_tb
+    ADD COLUMN IF NOT EXISTS private BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS owner_user_id INTEGER REFERENCES user_tb(id) ON DELETE CASCADE;
@@ -0,0 +1,6 @@
+ALTER TABLE repository_tb
+    DROP COLUMN IF EXISTS team_id,
+    DROP COLUMN IF EXISTS visibility;
+
+DROP TABLE IF EXISTS team_member_tb;
+DROP TABLE IF EXISTS team_tb;
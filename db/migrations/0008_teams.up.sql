@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS team_tb (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS team_member_tb (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES team_tb(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES user_tb(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'MEMBER',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (team_id, user_id)
+);
+
+ALTER TABLE repository_tb
+    ADD COLUMN IF NOT EXISTS team_id INTEGER REFERENCES team_tb(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) NOT NULL DEFAULT 'public';
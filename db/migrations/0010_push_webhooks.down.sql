@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS webhook_delivery_tb;
+
+ALTER TABLE repository_tb DROP COLUMN IF EXISTS changed_files;
@@ -0,0 +1,10 @@
+ALTER TABLE repository_tb ADD COLUMN IF NOT EXISTS changed_files TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_tb (
+    id SERIAL PRIMARY KEY,
+    delivery_id TEXT NOT NULL,
+    event TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (delivery_id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS outbound_delivery_tb;
+DROP TABLE IF EXISTS outbound_webhook_tb;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS digest_subscription_tb (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL,
+    author VARCHAR(255) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES user_tb(id) ON DELETE CASCADE,
+    unsubscribe_token TEXT NOT NULL,
+    last_digest_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, email, author),
+    UNIQUE (unsubscribe_token)
+);
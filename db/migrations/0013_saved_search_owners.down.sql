@@ -0,0 +1,8 @@
+-- Names were unique across all users, so only the searches that existed before owners are kept
+DROP INDEX IF EXISTS search_params_history_tb_owner_name_key;
+DELETE FROM search_params_history_tb WHERE owner_user_id IS NOT NULL;
+ALTER TABLE search_params_history_tb ADD CONSTRAINT search_params_history_tb_name_key UNIQUE (name);
+ALTER TABLE search_params_history_tb
+    DROP COLUMN IF EXISTS visibility,
+    DROP COLUMN IF EXISTS team_id,
+    DROP COLUMN IF EXISTS owner_user_id;
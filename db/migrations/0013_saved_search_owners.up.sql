@@ -0,0 +1,10 @@
+-- Saved searches belong to the user who saved them, and are only visible like repos are.
+-- Existing searches have no owner and stay public.
+ALTER TABLE search_params_history_tb
+    ADD COLUMN IF NOT EXISTS owner_user_id INTEGER REFERENCES user_tb(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS team_id INTEGER REFERENCES team_tb(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) NOT NULL DEFAULT 'public';
+
+-- Names are unique per owner, so users can't overwrite each other's searches
+ALTER TABLE search_params_history_tb DROP CONSTRAINT IF EXISTS search_params_history_tb_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS search_params_history_tb_owner_name_key ON search_params_history_tb ((COALESCE(owner_user_id, 0)), name);
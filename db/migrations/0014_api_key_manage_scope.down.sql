@@ -0,0 +1,3 @@
+UPDATE api_key_tb
+SET scopes = ARRAY['admin'], updated_at = CURRENT_TIMESTAMP
+WHERE scopes @> ARRAY['read', 'search', 'save', 'manage'];
@@ -0,0 +1,4 @@
+-- The admin scope allowed every scope, it is replaced with all the scopes other than server admin, which is now configured per user
+UPDATE api_key_tb
+SET scopes = ARRAY['read', 'search', 'save', 'manage'], updated_at = CURRENT_TIMESTAMP
+WHERE 'admin' = ANY(scopes);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS outbound_delivery_tb_created_at_idx;
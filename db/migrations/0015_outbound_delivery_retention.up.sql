@@ -0,0 +1,2 @@
+-- Old delivery attempts are deleted by age
+CREATE INDEX IF NOT EXISTS outbound_delivery_tb_created_at_idx ON outbound_delivery_tb (created_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS digest_sent_link_tb;
+DELETE FROM digest_subscription_tb WHERE confirmed_at IS NULL;
+ALTER TABLE digest_subscription_tb DROP COLUMN IF EXISTS confirmed_at;
+ALTER TABLE digest_subscription_tb DROP COLUMN IF EXISTS confirm_token;
@@ -0,0 +1,16 @@
+-- Subscriptions are only sent digests once the email address confirms them.
+-- Existing subscriptions were created before confirmation was needed, so they are kept as confirmed.
+ALTER TABLE digest_subscription_tb ADD COLUMN IF NOT EXISTS confirm_token TEXT UNIQUE;
+ALTER TABLE digest_subscription_tb ADD COLUMN IF NOT EXISTS confirmed_at TIMESTAMPTZ;
+UPDATE digest_subscription_tb SET confirmed_at = created_at WHERE confirmed_at IS NULL;
+
+-- Links sent to a subscription, so links found again when a repo is parsed again aren't sent twice
+CREATE TABLE IF NOT EXISTS digest_sent_link_tb (
+    subscription_id INTEGER NOT NULL REFERENCES digest_subscription_tb(id) ON DELETE CASCADE,
+    repo_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    file TEXT NOT NULL,
+    line_number INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (subscription_id, repo_id, url, file, line_number)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS repository_access_tb;
@@ -0,0 +1,11 @@
+-- Users (and their teams) who saved a repo that was already saved by someone else, so they can see it too
+CREATE TABLE IF NOT EXISTS repository_access_tb (
+    repo_id INTEGER NOT NULL REFERENCES repository_tb(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES user_tb(id) ON DELETE CASCADE,
+    team_id INTEGER REFERENCES team_tb(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS repository_access_tb_grant_key ON repository_access_tb (repo_id, (COALESCE(user_id, 0)), (COALESCE(team_id, 0)));
+CREATE INDEX IF NOT EXISTS repository_access_tb_user_id_idx ON repository_access_tb (user_id);
+CREATE INDEX IF NOT EXISTS repository_access_tb_team_id_idx ON repository_access_tb (team_id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS http_cache_tb_updated_at_idx;
@@ -0,0 +1,2 @@
+-- Cached responses are deleted once they are old, or the cache is too large, least recently saved first
+CREATE INDEX IF NOT EXISTS http_cache_tb_updated_at_idx ON http_cache_tb (updated_at);
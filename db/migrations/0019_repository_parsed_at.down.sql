@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS repository_tb_parsed_at_idx;
+ALTER TABLE repository_tb DROP COLUMN IF EXISTS parsed_at;
@@ -0,0 +1,6 @@
+-- When the parser last finished a repo, for the stats of repos scanned per day
+ALTER TABLE repository_tb ADD COLUMN IF NOT EXISTS parsed_at TIMESTAMPTZ;
+
+UPDATE repository_tb SET parsed_at = updated_at WHERE parsed_at IS NULL AND state IN ('COMPLETED', 'ERROR');
+
+CREATE INDEX IF NOT EXISTS repository_tb_parsed_at_idx ON repository_tb (parsed_at);
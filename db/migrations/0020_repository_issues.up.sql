@@ -0,0 +1,7 @@
+-- Issues raised against repos on their forge. Each repo only gets one, so the issue is returned instead of raised again.
+CREATE TABLE IF NOT EXISTS repository_issue_tb (
+    repo_id INTEGER PRIMARY KEY REFERENCES repository_tb(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    user_id INTEGER REFERENCES user_tb(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	// ApiKeyPrefix starts every API key, so leaked keys are easy to spot
+	ApiKeyPrefix = "gb_"
+	// apiKeyDisplayLength is how much of a key is stored in the clear to tell keys apart
+	apiKeyDisplayLength = len(ApiKeyPrefix) + 8
+)
+
+// NewApiKey returns a new random API key, and the prefix of it that is safe to show
+func NewApiKey() (string, string, error) {
+	token, err := NewRandomToken()
+	if err != nil {
+		return "", "", err
+	}
+	key := ApiKeyPrefix + token
+	return key, key[:apiKeyDisplayLength], nil
+}
+
+// BearerToken gets the token of an 'Authorization: Bearer' header
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewApiKey(t *testing.T) {
+	key, prefix, err := NewApiKey()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, ApiKeyPrefix))
+	assert.True(t, strings.HasPrefix(key, prefix))
+	assert.Less(t, len(prefix), len(key))
+
+	other, _, err := NewApiKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		token  string
+		ok     bool
+	}{
+		{name: "bearer token", header: "Bearer gb_key", token: "gb_key", ok: true},
+		{name: "scheme is case insensitive", header: "bearer gb_key", token: "gb_key", ok: true},
+		{name: "no header", header: "", ok: false},
+		{name: "basic auth", header: "Basic dXNlcjpwYXNz", ok: false},
+		{name: "empty token", header: "Bearer  ", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/api/stats", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+
+			token, ok := BearerToken(r)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.token, token)
+		})
+	}
+}
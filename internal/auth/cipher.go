@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// TokenCipher encrypts tokens at rest with AES-256-GCM. The key is derived from a secret, so it can be set from an env var.
+type TokenCipher struct {
+	aead cipher.AEAD
+}
+
+func NewTokenCipher(secret string) (*TokenCipher, error) {
+	if secret == "" {
+		return nil, errors.New("token cipher secret is empty")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenCipher{aead: aead}, nil
+}
+
+// Encrypt returns the nonce followed by the encrypted token
+func (c *TokenCipher) Encrypt(token string) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, []byte(token), nil), nil
+}
+
+func (c *TokenCipher) Decrypt(data []byte) (string, error) {
+	if len(data) < c.aead.NonceSize() {
+		return "", errors.New("encrypted token is too short")
+	}
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	token, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt token: %w", err)
+	}
+	return string(token), nil
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenCipher(t *testing.T) {
+	c, err := NewTokenCipher("secret")
+	assert.NoError(t, err)
+
+	encrypted, err := c.Encrypt("gho_user_token")
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "gho_user_token")
+
+	// Nonces are random, so the same token never encrypts the same way twice
+	again, err := c.Encrypt("gho_user_token")
+	assert.NoError(t, err)
+	assert.NotEqual(t, encrypted, again)
+
+	token, err := c.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "gho_user_token", token)
+
+	other, err := NewTokenCipher("other secret")
+	assert.NoError(t, err)
+	_, err = other.Decrypt(encrypted)
+	assert.Error(t, err)
+
+	_, err = c.Decrypt([]byte("short"))
+	assert.Error(t, err)
+
+	_, err = NewTokenCipher("")
+	assert.Error(t, err)
+}
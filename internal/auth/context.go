@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+type userContextKey struct{}
+
+// WithUser returns a copy of the context with the signed in user
+func WithUser(ctx context.Context, user *models.UserModel) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// UserFromContext gets the signed in user, set by the auth middleware
+func UserFromContext(ctx context.Context) (*models.UserModel, bool) {
+	user, ok := ctx.Value(userContextKey{}).(*models.UserModel)
+	return user, ok && user != nil
+}
+
+type apiKeyContextKey struct{}
+
+// WithApiKey returns a copy of the context with the API key the request was made with
+func WithApiKey(ctx context.Context, key *models.ApiKeyModel) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, key)
+}
+
+// ApiKeyFromContext gets the API key of the request, set by the auth middleware. Requests with a session have no key.
+func ApiKeyFromContext(ctx context.Context) (*models.ApiKeyModel, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(*models.ApiKeyModel)
+	return key, ok && key != nil
+}
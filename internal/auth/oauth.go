@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"golang.org/x/oauth2"
+	githubOAuth "golang.org/x/oauth2/github"
+)
+
+// GithubOAuth implements GitHub's OAuth web flow: redirect to authorize, exchange the code for a token, then get the user
+type GithubOAuth struct {
+	config *oauth2.Config
+	// apiURL is the GitHub API the user is fetched from
+	apiURL string
+}
+
+func NewGithubOAuth(clientID, clientSecret, redirectURL string) *GithubOAuth {
+	return &GithubOAuth{
+		config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Endpoint:     githubOAuth.Endpoint,
+			// repo is needed to list and clone users' private repos
+			Scopes: []string{"read:user", "repo"},
+		},
+		apiURL: "https://api.github.com/",
+	}
+}
+
+// AuthCodeURL is the GitHub page users are redirected to for signing in. The state is checked on the callback.
+func (o *GithubOAuth) AuthCodeURL(state string) string {
+	return o.config.AuthCodeURL(state)
+}
+
+// Exchange exchanges the code from the callback for an access token
+func (o *GithubOAuth) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	token, err := o.config.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+	return token, nil
+}
+
+// User gets the GitHub user the token belongs to
+func (o *GithubOAuth) User(ctx context.Context, token *oauth2.Token) (*models.GithubUser, error) {
+	client := github.NewClient(o.config.Client(ctx, token))
+	baseURL, err := url.Parse(strings.TrimSuffix(o.apiURL, "/") + "/")
+	if err != nil {
+		return nil, err
+	}
+	client.BaseURL = baseURL
+
+	user, _, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &models.GithubUser{
+		Id:        user.GetID(),
+		AvatarUrl: user.GetAvatarURL(),
+		Login:     user.GetLogin(),
+		Url:       user.GetHTMLURL(),
+		Name:      user.GetName(),
+	}, nil
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func newFakeOAuthServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		if r.PostForm.Get("code") != "valid-code" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+			return
+		}
+		clientID, clientSecret, _ := r.BasicAuth()
+		assert.Equal(t, "client-id", clientID)
+		assert.Equal(t, "client-secret", clientSecret)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "gho_user_token", "token_type": "bearer"})
+	})
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer gho_user_token", r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":         1234,
+			"login":      "jwtly10",
+			"name":       "Josh",
+			"avatar_url": "https://avatars.githubusercontent.com/u/1234",
+			"html_url":   "https://github.com/jwtly10",
+		})
+	})
+	return httptest.NewServer(mux)
+}
+
+func newTestGithubOAuth(serverURL string) *GithubOAuth {
+	o := NewGithubOAuth("client-id", "client-secret", "http://localhost:8080/v1/api/auth/callback")
+	o.config.Endpoint = oauth2.Endpoint{
+		AuthURL:  serverURL + "/login/oauth/authorize",
+		TokenURL: serverURL + "/login/oauth/access_token",
+	}
+	o.apiURL = serverURL
+	return o
+}
+
+func TestGithubOAuthAuthCodeURL(t *testing.T) {
+	o := NewGithubOAuth("client-id", "client-secret", "http://localhost:8080/v1/api/auth/callback")
+
+	u, err := url.Parse(o.AuthCodeURL("state-value"))
+	assert.NoError(t, err)
+	assert.Equal(t, "github.com", u.Host)
+	assert.Equal(t, "client-id", u.Query().Get("client_id"))
+	assert.Equal(t, "state-value", u.Query().Get("state"))
+	assert.Equal(t, "http://localhost:8080/v1/api/auth/callback", u.Query().Get("redirect_uri"))
+}
+
+func TestGithubOAuthExchangeAndGetUser(t *testing.T) {
+	server := newFakeOAuthServer(t)
+	defer server.Close()
+	o := newTestGithubOAuth(server.URL)
+
+	token, err := o.Exchange(context.Background(), "valid-code")
+	assert.NoError(t, err)
+	assert.Equal(t, "gho_user_token", token.AccessToken)
+
+	user, err := o.User(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1234), user.Id)
+	assert.Equal(t, "jwtly10", user.Login)
+	assert.Equal(t, "Josh", user.Name)
+}
+
+func TestGithubOAuthExchangeRejectsInvalidCode(t *testing.T) {
+	server := newFakeOAuthServer(t)
+	defer server.Close()
+	o := newTestGithubOAuth(server.URL)
+
+	_, err := o.Exchange(context.Background(), "invalid-code")
+	assert.Error(t, err)
+}
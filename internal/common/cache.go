@@ -4,9 +4,13 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/jwtly10/googl-bye/internal/models"
 	"github.com/jwtly10/googl-bye/internal/repository"
 )
 
+// RepoCache remembers which repos are saved, so saving them again can be skipped.
+// Public repos are cached for everyone, other repos only for their owner, so cache hits don't leak which
+// private repos other users have saved.
 type RepoCache struct {
 	store sync.Map
 }
@@ -24,14 +28,31 @@ func NewRepoCache(repoRepo repository.RepoRepository, log Logger) (*RepoCache, e
 	}
 
 	for _, repo := range repos {
-		key := fmt.Sprintf("%s/%s", repo.Author, repo.Name)
-		cache.store.Store(key, true)
+		cache.SetRepo(&repo)
 	}
 
 	log.Infof("Cache loaded with %d repos", len(repos))
 	return cache, nil
 }
 
+// SetRepo caches a saved repo for whoever can see it
+func (c *RepoCache) SetRepo(repo *models.RepositoryModel) {
+	if repo.VisibilityOrDefault() == models.VisibilityPublic {
+		c.store.Store(repo.CacheKey(), true)
+	} else if repo.OwnerUserID != nil {
+		c.store.Store(viewerKey(repo.CacheKey(), *repo.OwnerUserID), true)
+	}
+}
+
+// Saved checks if a repo with the cache key is saved, and visible to the viewer
+func (c *RepoCache) Saved(key string, viewerID int) bool {
+	return c.Exists(key) || c.Exists(viewerKey(key, viewerID))
+}
+
+func viewerKey(key string, viewerID int) string {
+	return fmt.Sprintf("%d|%s", viewerID, key)
+}
+
 // Set adds a key-value pair to the cache
 func (c *RepoCache) Set(key, value interface{}) {
 	c.store.Store(key, value)
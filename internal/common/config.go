@@ -1,20 +1,74 @@
 package common
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	DBHost         string
-	DBPort         int
-	DBUser         string
-	DBPassword     string
-	DBName         string
-	GHToken        string
-	ParserInterval int
+	DBHost     string
+	DBPort     int
+	DBUser     string
+	DBPassword string
+	DBName     string
+	GHToken    string
+	// GHTokens is the pool of tokens GitHub requests rotate between, including GHToken
+	GHTokens []string
+	// GitHub App installation credentials, added to the token pool when GHAppID is set
+	GHAppID             int64
+	GHAppInstallationID int64
+	GHAppPrivateKeyPath string
+	// GitHub OAuth app used for signing in. Routes are only protected when GHOAuthClientID is set.
+	GHOAuthClientID     string
+	GHOAuthClientSecret string
+	GHOAuthRedirectURL  string
+	// SessionSecret is the key users' GitHub tokens are encrypted with. Required when signing in is enabled.
+	SessionSecret string
+	// SessionMaxAgeHours is how long users stay signed in
+	SessionMaxAgeHours int
+	// AdminLogins are the GitHub logins of the server admins, who can use the admin routes
+	AdminLogins []string
+	// GHWebhookSecret verifies GitHub webhook deliveries. Webhooks are disabled when it is empty.
+	GHWebhookSecret string
+	GitlabURL       string
+	GitlabToken     string
+	GiteaURL        string
+	GiteaToken      string
+	ParserInterval  int
+	// ScanLocalRoot is the directory local path scans are restricted to. Local scans are disabled when empty.
+	ScanLocalRoot   string
+	ScanMaxUploadMB int
+	// Search guardrails. Queries get the defaults unless they have their own size/stars qualifiers, which must be within the limits.
+	SearchMaxSizeKB      int
+	SearchMaxSizeLimitKB int
+	SearchMinStars       int
+	SearchMinStarsLimit  int
+	// CrawlInterval is how often (seconds) to check for scheduled searches that are due
+	CrawlInterval int
+	// CrawlRateLimitReserve is the number of search requests crawls leave for users of the search UI
+	CrawlRateLimitReserve int
+	// Requests each client can make per minute, to the API and to groups of expensive routes that get their own
+	// limit (searches, scans, raising issues and exports). 0 disables the limit.
+	RateLimitPerMinute       int
+	RateLimitSearchPerMinute int
+	RateLimitScanPerMinute   int
+	RateLimitIssuePerMinute  int
+	RateLimitExportPerMinute int
+	// SMTP server digest emails are sent through. Digests are disabled when SMTPHost is empty.
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	// DigestInterval is how often (seconds) digests of new links are sent to subscribers
+	DigestInterval int
+	// AppURL is where the app is served, for links in emails
+	AppURL string
 }
 
 func LoadConfig() (*Config, error) {
@@ -33,13 +87,204 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	scanMaxUploadMB, err := getEnvInt("SCAN_MAX_UPLOAD_MB", 50)
+	if err != nil {
+		return nil, err
+	}
+
+	crawlInterval, err := getEnvPositiveInt("CRAWL_INTERVAL", 60)
+	if err != nil {
+		return nil, err
+	}
+
+	crawlRateLimitReserve, err := getEnvInt("CRAWL_RATE_LIMIT_RESERVE", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	searchConfig, err := LoadSearchConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitPerMinute, err := getEnvInt("RATE_LIMIT_PER_MINUTE", 120)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitSearchPerMinute, err := getEnvInt("RATE_LIMIT_SEARCH_PER_MINUTE", 10)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitScanPerMinute, err := getEnvInt("RATE_LIMIT_SCAN_PER_MINUTE", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitIssuePerMinute, err := getEnvInt("RATE_LIMIT_ISSUE_PER_MINUTE", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitExportPerMinute, err := getEnvInt("RATE_LIMIT_EXPORT_PER_MINUTE", 10)
+	if err != nil {
+		return nil, err
+	}
+
+	smtpPort, err := getEnvInt("SMTP_PORT", 587)
+	if err != nil {
+		return nil, err
+	}
+
+	digestInterval, err := getEnvPositiveInt("DIGEST_INTERVAL", 24*60*60)
+	if err != nil {
+		return nil, err
+	}
+
+	if os.Getenv("SMTP_HOST") != "" && os.Getenv("SMTP_FROM") == "" {
+		return nil, errors.New("SMTP_FROM is required when SMTP_HOST is set")
+	}
+
+	appURL := os.Getenv("APP_URL")
+	if appURL == "" {
+		appURL = "http://localhost:8080"
+	}
+
+	sessionMaxAgeHours, err := getEnvInt("SESSION_MAX_AGE_HOURS", 24*7)
+	if err != nil {
+		return nil, err
+	}
+
+	if os.Getenv("GH_OAUTH_CLIENT_ID") != "" && os.Getenv("SESSION_SECRET") == "" {
+		return nil, errors.New("SESSION_SECRET is required when GH_OAUTH_CLIENT_ID is set")
+	}
+
+	ghOAuthRedirectURL := os.Getenv("GH_OAUTH_REDIRECT_URL")
+	if ghOAuthRedirectURL == "" {
+		ghOAuthRedirectURL = "http://localhost:8080/v1/api/auth/callback"
+	}
+
+	var ghAppID, ghAppInstallationID int64
+	if appID := os.Getenv("GH_APP_ID"); appID != "" {
+		ghAppID, err = strconv.ParseInt(appID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ghAppInstallationID, err = strconv.ParseInt(os.Getenv("GH_APP_INSTALLATION_ID"), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Config{
+		DBHost:                   os.Getenv("DB_HOST"),
+		DBPort:                   port,
+		DBUser:                   os.Getenv("DB_USER"),
+		DBPassword:               os.Getenv("DB_PASSWORD"),
+		DBName:                   os.Getenv("DB_NAME"),
+		GHToken:                  os.Getenv("GH_TOKEN"),
+		GHTokens:                 getEnvList("GH_TOKEN", "GH_TOKENS"),
+		GHAppID:                  ghAppID,
+		GHAppInstallationID:      ghAppInstallationID,
+		GHAppPrivateKeyPath:      os.Getenv("GH_APP_PRIVATE_KEY_PATH"),
+		GHOAuthClientID:          os.Getenv("GH_OAUTH_CLIENT_ID"),
+		GHOAuthClientSecret:      os.Getenv("GH_OAUTH_CLIENT_SECRET"),
+		GHOAuthRedirectURL:       ghOAuthRedirectURL,
+		SessionSecret:            os.Getenv("SESSION_SECRET"),
+		SessionMaxAgeHours:       sessionMaxAgeHours,
+		AdminLogins:              getEnvList("ADMIN_LOGINS"),
+		GitlabURL:                os.Getenv("GITLAB_URL"),
+		GitlabToken:              os.Getenv("GITLAB_TOKEN"),
+		GiteaURL:                 os.Getenv("GITEA_URL"),
+		GiteaToken:               os.Getenv("GITEA_TOKEN"),
+		ParserInterval:           parserInterval,
+		ScanLocalRoot:            os.Getenv("SCAN_LOCAL_ROOT"),
+		ScanMaxUploadMB:          scanMaxUploadMB,
+		SearchMaxSizeKB:          searchConfig.SearchMaxSizeKB,
+		SearchMaxSizeLimitKB:     searchConfig.SearchMaxSizeLimitKB,
+		SearchMinStars:           searchConfig.SearchMinStars,
+		SearchMinStarsLimit:      searchConfig.SearchMinStarsLimit,
+		CrawlInterval:            crawlInterval,
+		CrawlRateLimitReserve:    crawlRateLimitReserve,
+		RateLimitPerMinute:       rateLimitPerMinute,
+		RateLimitSearchPerMinute: rateLimitSearchPerMinute,
+		RateLimitScanPerMinute:   rateLimitScanPerMinute,
+		RateLimitIssuePerMinute:  rateLimitIssuePerMinute,
+		RateLimitExportPerMinute: rateLimitExportPerMinute,
+		SMTPHost:                 os.Getenv("SMTP_HOST"),
+		SMTPPort:                 smtpPort,
+		SMTPUsername:             os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:             os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:                 os.Getenv("SMTP_FROM"),
+		DigestInterval:           digestInterval,
+		AppURL:                   appURL,
+	}, nil
+}
+
+// LoadSearchConfig loads only the search guardrails from env vars, for the CLI which doesn't need the rest of the config
+func LoadSearchConfig() (*Config, error) {
+	searchMaxSizeKB, err := getEnvInt("SEARCH_MAX_SIZE_KB", 50000)
+	if err != nil {
+		return nil, err
+	}
+
+	searchMaxSizeLimitKB, err := getEnvInt("SEARCH_MAX_SIZE_LIMIT_KB", searchMaxSizeKB)
+	if err != nil {
+		return nil, err
+	}
+
+	searchMinStars, err := getEnvInt("SEARCH_MIN_STARS", 300)
+	if err != nil {
+		return nil, err
+	}
+
+	searchMinStarsLimit, err := getEnvInt("SEARCH_MIN_STARS_LIMIT", 0)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
-		DBHost:         os.Getenv("DB_HOST"),
-		DBPort:         port,
-		DBUser:         os.Getenv("DB_USER"),
-		DBPassword:     os.Getenv("DB_PASSWORD"),
-		DBName:         os.Getenv("DB_NAME"),
-		GHToken:        os.Getenv("GH_TOKEN"),
-		ParserInterval: parserInterval,
+		SearchMaxSizeKB:      searchMaxSizeKB,
+		SearchMaxSizeLimitKB: searchMaxSizeLimitKB,
+		SearchMinStars:       searchMinStars,
+		SearchMinStarsLimit:  searchMinStarsLimit,
 	}, nil
 }
+
+// getEnvInt reads an optional integer env var, using the default if it is not set
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// getEnvPositiveInt reads a number that must be greater than 0, like the interval of a job
+func getEnvPositiveInt(key string, defaultValue int) (int, error) {
+	value, err := getEnvInt(key, defaultValue)
+	if err != nil {
+		return 0, err
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("%s must be greater than 0, got %d", key, value)
+	}
+	return value, nil
+}
+
+// getEnvList reads comma separated values from env vars, skipping empty and duplicate values
+func getEnvList(keys ...string) []string {
+	var values []string
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		for _, value := range strings.Split(os.Getenv(key), ",") {
+			value = strings.TrimSpace(value)
+			if value != "" && !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
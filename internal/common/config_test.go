@@ -0,0 +1,24 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEnvPositiveInt(t *testing.T) {
+	value, err := getEnvPositiveInt("TEST_INTERVAL", 60)
+	assert.NoError(t, err)
+	assert.Equal(t, 60, value)
+
+	t.Setenv("TEST_INTERVAL", "30")
+	value, err = getEnvPositiveInt("TEST_INTERVAL", 60)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, value)
+
+	for _, invalid := range []string{"0", "-1", "soon"} {
+		t.Setenv("TEST_INTERVAL", invalid)
+		_, err = getEnvPositiveInt("TEST_INTERVAL", 60)
+		assert.Error(t, err, invalid)
+	}
+}
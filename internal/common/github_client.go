@@ -3,48 +3,77 @@ package common
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/google/go-github/v39/github"
-	"golang.org/x/oauth2"
+	"github.com/jwtly10/googl-bye/internal/errors"
 )
 
 type GithubClientI interface {
 	SearchRepositories(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.Repository, *github.Response, error)
+	SearchCode(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.CodeResult, *github.Response, error)
 	SearchForUser(ctx context.Context, username string) ([]*github.User, *github.Response, error)
+	ListUserRepositories(ctx context.Context, user string, opts *github.RepositoryListOptions) ([]*github.Repository, *github.Response, error)
+	ListOrgRepositories(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)
 	CheckRateLimit(ctx context.Context) (*github.RateLimits, error)
+	CreateIssue(ctx context.Context, owner, repo string, issue *github.IssueRequest) (*github.Issue, *github.Response, error)
+	GetRepository(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error)
 }
 
 type GithubClient struct {
 	client *github.Client
+	tokens *TokenPool
 	log    Logger
 }
 
 func NewGitHubClient(token string, log Logger) *GithubClient {
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
-	return &GithubClient{client: client, log: log}
+	pool := NewTokenPool(nil, log)
+	pool.AddToken(token)
+	return NewGitHubClientWithPool(pool, log)
+}
+
+// NewGitHubClientWithPool creates a client that rotates between the tokens in the pool
+func NewGitHubClientWithPool(pool *TokenPool, log Logger) *GithubClient {
+	client := github.NewClient(&http.Client{Transport: pool})
+	return &GithubClient{client: client, tokens: pool, log: log}
 }
 
 func (gc *GithubClient) SearchRepositories(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.Repository, *github.Response, error) {
+	// Wait before calling, as the github client fails without sending requests once it knows the limit is exceeded
+	if err := gc.tokens.Wait(ctx, RateLimitSearch); err != nil {
+		return nil, nil, err
+	}
 	result, response, err := gc.client.Search.Repositories(ctx, query, opts)
 	if err != nil {
-		return nil, nil, fmt.Errorf("error searching repositories: %v", err)
+		return nil, nil, fmt.Errorf("error searching repositories: %w", rateLimitedError(err, RateLimitSearch))
 	}
 
 	return result.Repositories, response, nil
 }
 
+func (gc *GithubClient) SearchCode(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.CodeResult, *github.Response, error) {
+	if err := gc.tokens.Wait(ctx, RateLimitCodeSearch); err != nil {
+		return nil, nil, err
+	}
+	result, response, err := gc.client.Search.Code(ctx, query, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error searching code: %w", rateLimitedError(err, RateLimitCodeSearch))
+	}
+
+	return result.CodeResults, response, nil
+}
+
 func (gc *GithubClient) SearchForUser(ctx context.Context, username string) ([]*github.User, *github.Response, error) {
+	if err := gc.tokens.Wait(ctx, RateLimitSearch); err != nil {
+		return nil, nil, err
+	}
 	result, response, err := gc.client.Search.Users(ctx, username, &github.SearchOptions{
 		ListOptions: github.ListOptions{PerPage: 20},
 	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("error searching users: %v", err)
+		return nil, nil, fmt.Errorf("error searching users: %w", rateLimitedError(err, RateLimitSearch))
 	}
 
 	var wg sync.WaitGroup
@@ -55,6 +84,10 @@ func (gc *GithubClient) SearchForUser(ctx context.Context, username string) ([]*
 		wg.Add(1)
 		go func(i int, user *github.User) {
 			defer wg.Done()
+			if err := gc.tokens.Wait(ctx, RateLimitCore); err != nil {
+				errors[i] = err
+				return
+			}
 			fullUser, _, err := gc.client.Users.Get(ctx, user.GetLogin())
 			if err != nil {
 				gc.log.Warnf("error fetching details for user %s: %v", user.GetLogin(), err)
@@ -87,11 +120,94 @@ func (gc *GithubClient) SearchForUser(ctx context.Context, username string) ([]*
 	return validUsers, response, nil
 }
 
+func (gc *GithubClient) ListUserRepositories(ctx context.Context, user string, opts *github.RepositoryListOptions) ([]*github.Repository, *github.Response, error) {
+	if err := gc.tokens.Wait(ctx, RateLimitCore); err != nil {
+		return nil, nil, err
+	}
+	repos, response, err := gc.client.Repositories.List(ctx, user, opts)
+	if err != nil {
+		return nil, response, fmt.Errorf("error listing repositories for user %s: %w", user, rateLimitedError(err, RateLimitCore))
+	}
+
+	return repos, response, nil
+}
+
+func (gc *GithubClient) ListOrgRepositories(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error) {
+	if err := gc.tokens.Wait(ctx, RateLimitCore); err != nil {
+		return nil, nil, err
+	}
+	repos, response, err := gc.client.Repositories.ListByOrg(ctx, org, opts)
+	if err != nil {
+		return nil, response, fmt.Errorf("error listing repositories for org %s: %w", org, rateLimitedError(err, RateLimitCore))
+	}
+
+	return repos, response, nil
+}
+
+// CheckRateLimit returns the combined rate limits of all tokens in the pool.
+// Limits reset when the first token resets.
 func (gc *GithubClient) CheckRateLimit(ctx context.Context) (*github.RateLimits, error) {
-	result, _, err := gc.client.RateLimits(ctx)
+	total := &github.RateLimits{Core: &github.Rate{}, Search: &github.Rate{}}
+	for i := 0; i < gc.tokens.Len(); i++ {
+		result, _, err := gc.client.RateLimits(withPinnedToken(ctx, i))
+		if err != nil {
+			return nil, fmt.Errorf("error checking rate limit: %v", err)
+		}
+		addRate(total.Core, result.GetCore())
+		addRate(total.Search, result.GetSearch())
+	}
+
+	return total, nil
+}
+
+// TokenUsage lists the usage of each token in the pool
+func (gc *GithubClient) TokenUsage() []TokenUsage {
+	return gc.tokens.Usage()
+}
+
+func (gc *GithubClient) CreateIssue(ctx context.Context, owner, repo string, issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	if err := gc.tokens.Wait(ctx, RateLimitCore); err != nil {
+		return nil, nil, err
+	}
+	result, response, err := gc.client.Issues.Create(ctx, owner, repo, issue)
 	if err != nil {
-		return nil, fmt.Errorf("error checking rate limit: %v", err)
+		return nil, nil, fmt.Errorf("error creating issue: %w", rateLimitedError(err, RateLimitCore))
 	}
 
-	return result, nil
+	return result, response, nil
+}
+
+func (gc *GithubClient) GetRepository(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error) {
+	if err := gc.tokens.Wait(ctx, RateLimitCore); err != nil {
+		return nil, nil, err
+	}
+	result, response, err := gc.client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, response, fmt.Errorf("error getting repository %s/%s: %w", owner, repo, rateLimitedError(err, RateLimitCore))
+	}
+
+	return result, response, nil
+}
+
+// addRate adds a token's rate limit to the total
+func addRate(total *github.Rate, rate *github.Rate) {
+	if rate == nil {
+		return
+	}
+	total.Limit += rate.Limit
+	total.Remaining += rate.Remaining
+	if total.Reset.IsZero() || rate.Reset.Before(total.Reset.Time) {
+		total.Reset = rate.Reset
+	}
+}
+
+// rateLimitedError converts the github client's rate limit errors to a RateLimitedError
+func rateLimitedError(err error, resource string) error {
+	switch e := err.(type) {
+	case *github.RateLimitError:
+		return errors.NewRateLimitedError(e.Message, resource, time.Until(e.Rate.Reset.Time))
+	case *github.AbuseRateLimitError:
+		return errors.NewRateLimitedError(e.Message, resource, e.GetRetryAfter())
+	}
+	return err
 }
@@ -0,0 +1,217 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+)
+
+const (
+	// maxCachedBodySize is the largest response body that is cached
+	maxCachedBodySize = 5 << 20
+
+	// cacheTTL is how long responses are kept after they were last saved, and maxCacheSize the most response
+	// bytes kept, removing the least recently saved first. Both are checked at most once every cachePruneInterval.
+	cacheTTL           = 7 * 24 * time.Hour
+	maxCacheSize       = 256 << 20
+	cachePruneInterval = time.Hour
+
+	// anonymousIdentity is the identity of requests sent without credentials, and serverIdentity of the server's tokens
+	anonymousIdentity = "anonymous"
+	serverIdentity    = "server"
+)
+
+// cacheIdentityKey is the context key of the class of credentials a request is sent with (see withCacheIdentity)
+type cacheIdentityKey struct{}
+
+// withCacheIdentity marks requests as sent with credentials of a class, such as the server's tokens.
+// Cached responses are shared between requests of the same class, so requests with credentials but no class aren't cached.
+func withCacheIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, cacheIdentityKey{}, identity)
+}
+
+// CachingTransport caches responses to GET requests, revalidating them with If-None-Match/If-Modified-Since.
+// GitHub doesn't count 304 Not Modified responses against the rate limit.
+// Responses marked Cache-Control private or no-store aren't cached.
+type CachingTransport struct {
+	base  http.RoundTripper
+	store repository.HttpCacheRepository
+	log   Logger
+	now   func() time.Time
+
+	pruneMu    sync.Mutex
+	lastPruned time.Time
+}
+
+func NewCachingTransport(base http.RoundTripper, store repository.HttpCacheRepository, log Logger) *CachingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &CachingTransport{
+		base:  base,
+		store: store,
+		log:   log,
+		now:   time.Now,
+	}
+}
+
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	key, ok := cacheKey(req)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	cached, err := t.store.GetCachedResponse(key)
+	if err != nil {
+		// The cache is only an optimisation, so requests are still made without it
+		t.log.Warnf("Error getting cached response for %s: %v", req.URL, err)
+		cached = nil
+	}
+
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		t.log.Debugf("Using cached response for %s", req.URL)
+		resp.Body.Close()
+		return cachedResponse(req, cached, resp), nil
+	}
+
+	if resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "") && !isPrivate(resp) {
+		t.save(key, req, resp)
+		go t.prune()
+	}
+
+	return resp, nil
+}
+
+// prune deletes responses older than cacheTTL, and the oldest responses over maxCacheSize,
+// unless it already did in the last cachePruneInterval
+func (t *CachingTransport) prune() {
+	t.pruneMu.Lock()
+	defer t.pruneMu.Unlock()
+
+	now := t.now()
+	if now.Sub(t.lastPruned) < cachePruneInterval {
+		return
+	}
+	t.lastPruned = now
+
+	deleted, err := t.store.DeleteCachedResponses(now.Add(-cacheTTL), maxCacheSize)
+	if err != nil {
+		t.log.Warnf("Error deleting old cached responses: %v", err)
+	} else if deleted > 0 {
+		t.log.Infof("Deleted %d old cached responses", deleted)
+	}
+}
+
+// isPrivate checks if a response must not be stored by a shared cache
+func isPrivate(resp *http.Response) bool {
+	for _, value := range resp.Header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			if directive == "no-store" || directive == "private" || strings.HasPrefix(directive, "private=") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// save caches a response. The body is read to be saved, so it is replaced for the caller.
+func (t *CachingTransport) save(key string, req *http.Request, resp *http.Response) {
+	if resp.ContentLength > maxCachedBodySize {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedBodySize+1))
+	if err != nil {
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), resp.Body))
+		return
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(body) > maxCachedBodySize {
+		return
+	}
+
+	entry := &models.HttpCacheModel{
+		Key:          key,
+		Url:          req.URL.String(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		Body:         body,
+	}
+	if err := t.store.SaveCachedResponse(entry); err != nil {
+		t.log.Warnf("Error caching response for %s: %v", req.URL, err)
+	}
+}
+
+// cachedResponse rebuilds a cached response. Headers from the 304 response, such as the rate limit, are kept as they are up to date.
+func cachedResponse(req *http.Request, cached *models.HttpCacheModel, notModified *http.Response) *http.Response {
+	header := cached.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	for name, values := range notModified.Header {
+		header[name] = values
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", cached.StatusCode, http.StatusText(cached.StatusCode)),
+		StatusCode:    cached.StatusCode,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+		ContentLength: int64(len(cached.Body)),
+		Request:       req,
+	}
+}
+
+// cacheKey identifies a request. GitHub responses vary by Accept and the credentials used, so the identity class
+// of the credentials is part of the key. Requests with credentials but no identity class can't be cached.
+func cacheKey(req *http.Request) (string, bool) {
+	identity, _ := req.Context().Value(cacheIdentityKey{}).(string)
+	if identity == "" {
+		if req.Header.Get("Authorization") != "" {
+			return "", false
+		}
+		identity = anonymousIdentity
+	}
+
+	hash := sha256.New()
+	io.WriteString(hash, req.URL.String())
+	io.WriteString(hash, "\n"+req.Header.Get("Accept"))
+	io.WriteString(hash, "\n"+identity)
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
@@ -0,0 +1,191 @@
+package common
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+// memoryHttpCache keeps cached responses in memory, so the transport can be tested without a db
+type memoryHttpCache struct {
+	repository.HttpCacheRepository
+	mu      sync.Mutex
+	entries map[string]*models.HttpCacheModel
+	pruned  []time.Time
+}
+
+func (m *memoryHttpCache) GetCachedResponse(key string) (*models.HttpCacheModel, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.entries[key], nil
+}
+
+func (m *memoryHttpCache) SaveCachedResponse(entry *models.HttpCacheModel) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry.UpdatedAt = time.Now()
+	m.entries[entry.Key] = entry
+	return nil
+}
+
+func (m *memoryHttpCache) DeleteCachedResponses(before time.Time, maxSize int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruned = append(m.pruned, before)
+
+	var deleted int64
+	for key, entry := range m.entries {
+		if entry.UpdatedAt.Before(before) {
+			delete(m.entries, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *memoryHttpCache) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+func TestCachingTransportRevalidatesWithETag(t *testing.T) {
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", "30")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.Header().Set("X-RateLimit-Remaining", "29")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"login": "jwtly10"}`))
+	}))
+	defer server.Close()
+
+	store := &memoryHttpCache{entries: make(map[string]*models.HttpCacheModel)}
+	client := &http.Client{Transport: NewCachingTransport(http.DefaultTransport, store, NewLogger(false, zapcore.DebugLevel))}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL + "/users/jwtly10")
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `{"login": "jwtly10"}`, string(body))
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	}
+
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, notModified)
+	assert.Equal(t, 1, store.len())
+}
+
+func TestCachingTransportKeysByIdentity(t *testing.T) {
+	var conditional int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			conditional++
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	store := &memoryHttpCache{entries: make(map[string]*models.HttpCacheModel)}
+	client := &http.Client{Transport: NewCachingTransport(http.DefaultTransport, store, NewLogger(false, zapcore.DebugLevel))}
+
+	get := func(ctx context.Context, token string) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/user", nil)
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// Tokens without an identity class are never cached
+	get(context.Background(), "Bearer user")
+	get(context.Background(), "Bearer user")
+	assert.Equal(t, 0, conditional)
+	assert.Equal(t, 0, store.len())
+
+	// Tokens of the same class share responses, anonymous requests don't
+	server1 := withCacheIdentity(context.Background(), serverIdentity)
+	get(server1, "Bearer one")
+	get(server1, "Bearer two")
+	get(context.Background(), "")
+	assert.Equal(t, 1, conditional)
+	assert.Equal(t, 2, store.len())
+}
+
+func TestCachingTransportSkipsPrivateResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Cache-Control", r.URL.Query().Get("cache"))
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	store := &memoryHttpCache{entries: make(map[string]*models.HttpCacheModel)}
+	client := &http.Client{Transport: NewCachingTransport(http.DefaultTransport, store, NewLogger(false, zapcore.DebugLevel))}
+
+	for _, cache := range []string{"private, max-age=60", "no-store", "public, max-age=60"} {
+		resp, err := client.Get(server.URL + "/repos?cache=" + url.QueryEscape(cache))
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, 1, store.len())
+}
+
+func TestCachingTransportPrunesOldResponses(t *testing.T) {
+	store := &memoryHttpCache{entries: map[string]*models.HttpCacheModel{
+		"old": {Key: "old", Model: models.Model{UpdatedAt: time.Now().Add(-cacheTTL - time.Hour)}},
+		"new": {Key: "new", Model: models.Model{UpdatedAt: time.Now()}},
+	}}
+	transport := NewCachingTransport(http.DefaultTransport, store, NewLogger(false, zapcore.DebugLevel))
+
+	transport.prune()
+	transport.prune()
+
+	// Pruning only happens once an interval
+	assert.Len(t, store.pruned, 1)
+	assert.Equal(t, 1, store.len())
+	assert.NotNil(t, store.entries["new"])
+
+	transport.now = func() time.Time { return time.Now().Add(cachePruneInterval) }
+	transport.prune()
+	assert.Len(t, store.pruned, 2)
+}
+
+func TestCachingTransportIgnoresOtherMethods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	store := &memoryHttpCache{entries: make(map[string]*models.HttpCacheModel)}
+	client := &http.Client{Transport: NewCachingTransport(http.DefaultTransport, store, NewLogger(false, zapcore.DebugLevel))}
+
+	resp, err := client.Post(server.URL+"/repos/owner/repo/issues", "application/json", nil)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 0, store.len())
+}
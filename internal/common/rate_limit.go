@@ -0,0 +1,237 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/errors"
+)
+
+// GitHub rate limit resources, as reported by the X-RateLimit-Resource header
+const (
+	RateLimitCore       = "core"
+	RateLimitSearch     = "search"
+	RateLimitCodeSearch = "code_search"
+)
+
+const (
+	// MaxRateLimitWait is the longest a request will wait for a rate limit to reset.
+	// Requests that would wait longer fail with a RateLimitedError instead.
+	MaxRateLimitWait = time.Minute
+	// maxSecondaryRetries is how many times a request is retried after hitting a secondary rate limit
+	maxSecondaryRetries = 3
+	// secondaryBackoff is the first wait after a secondary rate limit without a Retry-After, doubling on each retry
+	secondaryBackoff = 5 * time.Second
+)
+
+// RateLimit is the last known rate limit of a resource
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// RateLimitTransport tracks GitHub's X-RateLimit-* headers per resource.
+// Requests wait for exhausted rate limits to reset, and secondary rate limits are retried with backoff.
+type RateLimitTransport struct {
+	base    http.RoundTripper
+	log     Logger
+	maxWait time.Duration
+
+	mu     sync.Mutex
+	limits map[string]RateLimit
+
+	// rotate checks if a request can use another token once this one is exhausted.
+	// When it can, the rate limit response is returned instead of waiting for the reset.
+	rotate func(ctx context.Context, resource string) bool
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func NewRateLimitTransport(base http.RoundTripper, log Logger) *RateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &RateLimitTransport{
+		base:    base,
+		log:     log,
+		maxWait: MaxRateLimitWait,
+		limits:  make(map[string]RateLimit),
+		now:     time.Now,
+		sleep:   sleepContext,
+	}
+}
+
+// RateLimit returns the last known rate limit of a resource
+func (t *RateLimitTransport) RateLimit(resource string) (RateLimit, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	limit, ok := t.limits[resource]
+	return limit, ok
+}
+
+// Wait blocks until the resource has requests remaining.
+// Returns a RateLimitedError if the rate limit resets later than the max wait.
+func (t *RateLimitTransport) Wait(ctx context.Context, resource string) error {
+	limit, ok := t.RateLimit(resource)
+	if !ok || limit.Remaining > 0 {
+		return nil
+	}
+
+	wait := limit.Reset.Sub(t.now())
+	if wait <= 0 {
+		return nil
+	}
+	if wait > t.maxWait {
+		return errors.NewRateLimitedError(fmt.Sprintf("github %s rate limit exceeded, resets at %v", resource, limit.Reset.Format(time.RFC3339)), resource, wait)
+	}
+
+	t.log.Infof("Github %s rate limit exceeded, waiting %v for it to reset", resource, wait)
+	return t.sleep(ctx, wait)
+}
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource := resourceForPath(req.URL.Path)
+
+	for attempt := 0; ; attempt++ {
+		if err := t.Wait(req.Context(), resource); err != nil {
+			return nil, err
+		}
+
+		// Retries need a fresh copy of the body
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		t.update(resource, resp)
+
+		if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+			return resp, nil
+		}
+
+		canRetry := req.Body == nil || req.GetBody != nil
+
+		// Primary rate limit, wait for the reset and try again
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			if !canRetry || attempt >= maxSecondaryRetries || (t.rotate != nil && t.rotate(req.Context(), responseResource(resp, resource))) {
+				return resp, nil
+			}
+			resp.Body.Close()
+			continue
+		}
+
+		secondary, err := isSecondaryRateLimit(resp)
+		if err != nil {
+			return nil, err
+		}
+		if !secondary {
+			return resp, nil
+		}
+
+		backoff := secondaryBackoff << attempt
+		if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			backoff = time.Duration(retryAfter) * time.Second
+		}
+		if !canRetry || attempt >= maxSecondaryRetries || backoff > t.maxWait {
+			resp.Body.Close()
+			return nil, errors.NewRateLimitedError("github secondary rate limit exceeded", resource, backoff)
+		}
+
+		t.log.Warnf("Github secondary rate limit exceeded, retrying in %v", backoff)
+		resp.Body.Close()
+		if err := t.sleep(req.Context(), backoff); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// update saves the rate limit headers of a response
+func (t *RateLimitTransport) update(resource string, resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	reset, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	resource = responseResource(resp, resource)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits[resource] = RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(reset, 0),
+	}
+}
+
+// isPrimaryRateLimit checks if a response failed because the rate limit was exhausted
+func isPrimaryRateLimit(resp *http.Response) bool {
+	return (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) &&
+		resp.Header.Get("X-RateLimit-Remaining") == "0"
+}
+
+// responseResource is the rate limit resource of a response, or the guessed resource if it doesn't say
+func responseResource(resp *http.Response, guessed string) string {
+	if r := resp.Header.Get("X-RateLimit-Resource"); r != "" {
+		return r
+	}
+	return guessed
+}
+
+// isSecondaryRateLimit checks a 403/429 response for a secondary rate limit.
+// The body is read to check the message, so it is replaced for the caller.
+func isSecondaryRateLimit(resp *http.Response) (bool, error) {
+	if resp.Header.Get("Retry-After") != "" {
+		return true, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return false, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	msg := strings.ToLower(string(body))
+	return strings.Contains(msg, "secondary rate limit") || strings.Contains(msg, "abuse"), nil
+}
+
+// resourceForPath guesses the rate limit resource of a request, until the response says otherwise
+func resourceForPath(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/search/code"):
+		return RateLimitCodeSearch
+	case strings.HasPrefix(path, "/search/"):
+		return RateLimitSearch
+	default:
+		return RateLimitCore
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,166 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func newTestTransport(now time.Time) (*RateLimitTransport, *[]time.Duration) {
+	var slept []time.Duration
+	transport := NewRateLimitTransport(http.DefaultTransport, NewLogger(false, zapcore.DebugLevel))
+	transport.now = func() time.Time { return now }
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	return transport, &slept
+}
+
+func TestRateLimitTransportTracksResources(t *testing.T) {
+	now := time.Unix(1720605600, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resource := "core"
+		if r.URL.Path == "/search/repositories" {
+			resource = "search"
+		}
+		w.Header().Set("X-RateLimit-Resource", resource)
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "12")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Minute).Unix(), 10))
+	}))
+	defer server.Close()
+
+	transport, _ := newTestTransport(now)
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL + "/search/repositories")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	search, ok := transport.RateLimit(RateLimitSearch)
+	assert.True(t, ok)
+	assert.Equal(t, RateLimit{Limit: 30, Remaining: 12, Reset: now.Add(time.Minute)}, search)
+
+	_, ok = transport.RateLimit(RateLimitCore)
+	assert.False(t, ok)
+}
+
+func TestRateLimitTransportWaitsForReset(t *testing.T) {
+	now := time.Unix(1720605600, 0)
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Resource", "search")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(30*time.Second).Unix(), 10))
+	}))
+	defer server.Close()
+
+	transport, slept := newTestTransport(now)
+	client := &http.Client{Transport: transport}
+
+	// The first request exhausts the limit, the next waits for the reset before it is sent
+	resp, err := client.Get(server.URL + "/search/repositories")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = client.Get(server.URL + "/search/repositories")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, []time.Duration{30 * time.Second}, *slept)
+}
+
+func TestRateLimitTransportFailsWhenResetIsTooLate(t *testing.T) {
+	now := time.Unix(1720605600, 0)
+	transport, slept := newTestTransport(now)
+	transport.limits[RateLimitSearch] = RateLimit{Limit: 30, Remaining: 0, Reset: now.Add(10 * time.Minute)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("expected no requests while rate limited")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: transport}
+	_, err := client.Get(server.URL + "/search/repositories")
+
+	rle, ok := errors.AsRateLimitedError(err)
+	assert.True(t, ok)
+	assert.Equal(t, RateLimitSearch, rle.Resource)
+	assert.Equal(t, 10*time.Minute, rle.RetryAfter)
+	assert.Empty(t, *slept)
+}
+
+func TestRateLimitTransportRetriesSecondaryLimits(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if requests == 2 {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "You have exceeded a secondary rate limit."}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport, slept := newTestTransport(time.Now())
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL + "/search/code")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []time.Duration{3 * time.Second, 2 * secondaryBackoff}, *slept)
+}
+
+func TestRateLimitTransportGivesUpOnSecondaryLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	transport, slept := newTestTransport(time.Now())
+	client := &http.Client{Transport: transport}
+
+	_, err := client.Get(server.URL + "/search/code")
+	rle, ok := errors.AsRateLimitedError(err)
+	assert.True(t, ok)
+	assert.Equal(t, RateLimitCodeSearch, rle.Resource)
+	assert.Equal(t, 10*time.Minute, rle.RetryAfter)
+	assert.Empty(t, *slept)
+}
+
+func TestRateLimitTransportPassesThroughOtherErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message": "Resource not accessible by integration"}`))
+	}))
+	defer server.Close()
+
+	transport, _ := newTestTransport(time.Now())
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL + "/repos/owner/repo/issues")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	body := make([]byte, 64)
+	n, _ := resp.Body.Read(body)
+	assert.Contains(t, string(body[:n]), "Resource not accessible")
+}
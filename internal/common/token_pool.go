@@ -0,0 +1,272 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenUsage is the usage of a token in the pool. The token itself is never included.
+type TokenUsage struct {
+	Name       string               `json:"name"`
+	Requests   int64                `json:"requests"`
+	RateLimits map[string]RateLimit `json:"rateLimits"`
+}
+
+// TokenPool authenticates GitHub requests with a pool of tokens.
+// Each request uses the token with the most remaining quota for its rate limit resource.
+type TokenPool struct {
+	tokens []*poolToken
+	base   http.RoundTripper
+	log    Logger
+	// identity is the cache identity of the pool's tokens (see withCacheIdentity), empty if responses can't be cached
+	identity string
+}
+
+type poolToken struct {
+	name     string
+	source   oauth2.TokenSource
+	limits   *RateLimitTransport
+	requests atomic.Int64
+}
+
+type pinnedTokenKey struct{}
+
+// NewTokenPool creates an empty pool. Requests are sent with the base transport, or http.DefaultTransport when nil.
+func NewTokenPool(base http.RoundTripper, log Logger) *TokenPool {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &TokenPool{base: base, log: log}
+}
+
+// NewTokenPoolFromConfig creates a pool of the configured personal access tokens and GitHub App installation.
+// The tokens are all the server's, so cached responses are shared between them.
+func NewTokenPoolFromConfig(config *Config, base http.RoundTripper, log Logger) (*TokenPool, error) {
+	pool := NewTokenPool(base, log)
+	pool.identity = serverIdentity
+
+	for _, token := range config.GHTokens {
+		pool.AddToken(token)
+	}
+
+	if config.GHAppID != 0 {
+		pem, err := os.ReadFile(config.GHAppPrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading github app private key: %w", err)
+		}
+		if err := pool.AddApp(config.GHAppID, config.GHAppInstallationID, pem); err != nil {
+			return nil, err
+		}
+	}
+
+	if pool.Len() == 0 {
+		log.Warn("No github tokens configured, requests will be unauthenticated")
+		pool.AddToken("")
+	}
+
+	log.Infof("Github token pool loaded with %d tokens", pool.Len())
+	return pool, nil
+}
+
+// AddToken adds a personal access token to the pool. An empty token makes unauthenticated requests.
+func (p *TokenPool) AddToken(token string) {
+	name := "anonymous"
+	if token != "" {
+		name = maskToken(token)
+	}
+	p.add(name, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+}
+
+// AddApp adds a GitHub App installation to the pool, using its PEM encoded private key.
+// Installation tokens are created when needed, and refreshed before they expire.
+func (p *TokenPool) AddApp(appID, installationID int64, privateKeyPEM []byte) error {
+	source, err := newAppTokenSource(appID, installationID, privateKeyPEM)
+	if err != nil {
+		return err
+	}
+	p.add(fmt.Sprintf("app:%d/installation:%d", appID, installationID), oauth2.ReuseTokenSource(nil, source))
+	return nil
+}
+
+func (p *TokenPool) add(name string, source oauth2.TokenSource) {
+	t := &poolToken{
+		name:   name,
+		source: source,
+		limits: NewRateLimitTransport(p.base, p.log),
+	}
+	t.limits.rotate = func(ctx context.Context, resource string) bool {
+		return p.canRotate(ctx, t, resource)
+	}
+	p.tokens = append(p.tokens, t)
+}
+
+// Len is the number of tokens in the pool
+func (p *TokenPool) Len() int {
+	return len(p.tokens)
+}
+
+// Wait blocks until a token has requests remaining for the resource.
+// Returns a RateLimitedError if no token resets within the max wait.
+func (p *TokenPool) Wait(ctx context.Context, resource string) error {
+	return p.tokenFor(ctx, resource).limits.Wait(ctx, resource)
+}
+
+// Usage lists the requests made and last known rate limits of each token
+func (p *TokenPool) Usage() []TokenUsage {
+	usage := make([]TokenUsage, 0, len(p.tokens))
+	for _, t := range p.tokens {
+		t.limits.mu.Lock()
+		limits := make(map[string]RateLimit, len(t.limits.limits))
+		for resource, limit := range t.limits.limits {
+			limits[resource] = limit
+		}
+		t.limits.mu.Unlock()
+
+		usage = append(usage, TokenUsage{
+			Name:       t.name,
+			Requests:   t.requests.Load(),
+			RateLimits: limits,
+		})
+	}
+	return usage
+}
+
+// RoundTrip sends the request with the token with the most remaining quota.
+// When the token runs out, the request is retried with the next token that has quota left.
+func (p *TokenPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	resource := resourceForPath(req.URL.Path)
+	canRetry := req.Body == nil || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		t := p.tokenFor(req.Context(), resource)
+		resp, err := p.send(t, req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		if canRetry && attempt < len(p.tokens) && isPrimaryRateLimit(resp) && p.canRotate(req.Context(), t, responseResource(resp, resource)) {
+			p.log.Infof("Github token '%s' %s rate limit exceeded, retrying with the next token", t.name, responseResource(resp, resource))
+			resp.Body.Close()
+			continue
+		}
+
+		p.reportPoolQuota(req.Context(), resp)
+		return resp, nil
+	}
+}
+
+// send makes a request with a token of the pool
+func (p *TokenPool) send(t *poolToken, req *http.Request, attempt int) (*http.Response, error) {
+	token, err := t.source.Token()
+	if err != nil {
+		return nil, fmt.Errorf("error getting token '%s': %w", t.name, err)
+	}
+
+	// RoundTrippers must not modify the request, and retries need a fresh copy of the body
+	ctx := req.Context()
+	if p.identity != "" {
+		ctx = withCacheIdentity(ctx, p.identity)
+	}
+	req = req.Clone(ctx)
+	if attempt > 0 && req.Body != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	if token.AccessToken != "" {
+		token.SetAuthHeader(req)
+	}
+
+	t.requests.Add(1)
+	return t.limits.RoundTrip(req)
+}
+
+// canRotate checks if a request that exhausted a token can be retried with another token.
+// Requests pinned to a token can't be.
+func (p *TokenPool) canRotate(ctx context.Context, from *poolToken, resource string) bool {
+	if _, pinned := ctx.Value(pinnedTokenKey{}).(int); pinned {
+		return false
+	}
+	next := p.tokenFor(ctx, resource)
+	remaining, _ := next.available(resource)
+	return next != from && remaining > 0
+}
+
+// reportPoolQuota replaces an exhausted token's remaining quota with the quota of the next token to be used.
+// Otherwise the github client stops making requests for the resource, even when other tokens have quota left.
+// Error responses are left alone, so the github client still sees rate limit errors when every token is exhausted.
+func (p *TokenPool) reportPoolQuota(ctx context.Context, resp *http.Response) {
+	if resp.StatusCode >= http.StatusBadRequest || resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+	if _, pinned := ctx.Value(pinnedTokenKey{}).(int); pinned {
+		return
+	}
+
+	resource := responseResource(resp, resourceForPath(resp.Request.URL.Path))
+
+	remaining, _ := p.tokenFor(ctx, resource).available(resource)
+	if remaining == 0 {
+		return
+	}
+	if remaining == math.MaxInt {
+		// The next token hasn't been used yet, so assume it has the same limit
+		remaining, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	}
+	resp.Header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+}
+
+// withPinnedToken makes requests with the context use the token at index i of the pool
+func withPinnedToken(ctx context.Context, i int) context.Context {
+	return context.WithValue(ctx, pinnedTokenKey{}, i)
+}
+
+// tokenFor picks the token with the most remaining quota for the resource.
+// Tokens that haven't been used yet are picked first, and when all tokens are exhausted the first to reset is picked.
+func (p *TokenPool) tokenFor(ctx context.Context, resource string) *poolToken {
+	if i, ok := ctx.Value(pinnedTokenKey{}).(int); ok && i < len(p.tokens) {
+		return p.tokens[i]
+	}
+
+	var best *poolToken
+	var bestRemaining int
+	var bestReset time.Time
+	for _, t := range p.tokens {
+		remaining, reset := t.available(resource)
+		if best == nil || remaining > bestRemaining || (remaining == bestRemaining && remaining == 0 && reset.Before(bestReset)) {
+			best, bestRemaining, bestReset = t, remaining, reset
+		}
+	}
+	return best
+}
+
+// available is the requests a token has remaining for a resource, and when they reset
+func (t *poolToken) available(resource string) (int, time.Time) {
+	limit, ok := t.limits.RateLimit(resource)
+	if !ok {
+		return math.MaxInt, time.Time{}
+	}
+	if !limit.Reset.After(t.limits.now()) {
+		return limit.Limit, time.Time{}
+	}
+	return limit.Remaining, limit.Reset
+}
+
+// maskToken keeps enough of a token to tell which one it is
+func maskToken(token string) string {
+	if len(token) < 16 {
+		return strings.Repeat("*", len(token))
+	}
+	return token[:4] + "..." + token[len(token)-4:]
+}
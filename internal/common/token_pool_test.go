@@ -0,0 +1,194 @@
+package common
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestTokenPoolRotatesToMostRemainingQuota(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+	remaining := map[string]int{"Bearer token-one": 1, "Bearer token-two": 20}
+	var used []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		used = append(used, auth)
+		remaining[auth]--
+		w.Header().Set("X-RateLimit-Resource", "search")
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining[auth]))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	}))
+	defer server.Close()
+
+	pool := NewTokenPool(nil, NewLogger(false, zapcore.DebugLevel))
+	pool.AddToken("token-one")
+	pool.AddToken("token-two")
+	client := &http.Client{Transport: pool}
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL + "/search/repositories")
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// Unused tokens are tried first, then the token with the most remaining quota is used
+	assert.Equal(t, []string{"Bearer token-one", "Bearer token-two", "Bearer token-two"}, used)
+
+	usage := pool.Usage()
+	assert.Len(t, usage, 2)
+	assert.Equal(t, "*********", usage[0].Name)
+	assert.Equal(t, int64(1), usage[0].Requests)
+	assert.Equal(t, 0, usage[0].RateLimits[RateLimitSearch].Remaining)
+	assert.Equal(t, int64(2), usage[1].Requests)
+	assert.Equal(t, 18, usage[1].RateLimits[RateLimitSearch].Remaining)
+}
+
+func TestTokenPoolReportsQuotaOfNextToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Resource", "search")
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	}))
+	defer server.Close()
+
+	pool := NewTokenPool(nil, NewLogger(false, zapcore.DebugLevel))
+	pool.AddToken("ghp_first_pool_token")
+	pool.AddToken("ghp_second_pool_token")
+	client := &http.Client{Transport: pool}
+
+	// The second token hasn't been used, so the github client shouldn't think the search limit is exhausted
+	resp, err := client.Get(server.URL + "/search/repositories")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "30", resp.Header.Get("X-RateLimit-Remaining"))
+
+	// Once every token is exhausted, the real quota is reported
+	resp, err = client.Get(server.URL + "/search/repositories")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "0", resp.Header.Get("X-RateLimit-Remaining"))
+
+	assert.Equal(t, "ghp_...oken", pool.Usage()[0].Name)
+}
+
+func TestTokenPoolRetriesExhaustedRequestsWithNextToken(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+	var used []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		used = append(used, auth)
+		w.Header().Set("X-RateLimit-Resource", "search")
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if auth == "Bearer token-one" {
+			// GitHub's quota can run out before our count of it does, e.g. when the token is used elsewhere
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "29")
+	}))
+	defer server.Close()
+
+	pool := NewTokenPool(nil, NewLogger(false, zapcore.DebugLevel))
+	pool.AddToken("token-one")
+	pool.AddToken("token-two")
+	client := &http.Client{Transport: pool}
+
+	resp, err := client.Get(server.URL + "/search/repositories")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	// The exhausted token isn't retried, the request is sent with the next token instead
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"Bearer token-one", "Bearer token-two"}, used)
+}
+
+func TestTokenPoolLeavesRateLimitErrorsAlone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Resource", "search")
+		w.Header().Set("X-RateLimit-Limit", "30")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	pool := NewTokenPool(nil, NewLogger(false, zapcore.DebugLevel))
+	pool.AddToken("ghp_first_pool_token")
+	pool.AddToken("ghp_second_pool_token")
+
+	// Requests with a body that can't be resent aren't retried, so the error response is returned.
+	// The github client needs its real headers to return a rate limit error.
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/search/repositories", io.NopCloser(strings.NewReader("{}")))
+	assert.NoError(t, err)
+	resp, err := pool.RoundTrip(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("X-RateLimit-Remaining"))
+}
+
+func TestAppTokenSourceCreatesInstallationTokens(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	expires := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/app/installations/99/access_tokens", r.URL.Path)
+
+		// The JWT must be signed by the app's key, and issued by the app
+		jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		assert.Len(t, parts, 3)
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature))
+
+		claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var claims map[string]interface{}
+		assert.NoError(t, json.Unmarshal(claimsJSON, &claims))
+		assert.Equal(t, "12", claims["iss"])
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(installationToken{Token: "ghs_installation", ExpiresAt: expires})
+	}))
+	defer server.Close()
+
+	source, err := newAppTokenSource(12, 99, keyPEM)
+	assert.NoError(t, err)
+	source.baseURL = server.URL
+
+	token, err := source.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "ghs_installation", token.AccessToken)
+	assert.True(t, expires.Equal(token.Expiry))
+}
+
+func TestParseRSAPrivateKeyRejectsInvalidKeys(t *testing.T) {
+	_, err := parseRSAPrivateKey([]byte("not a key"))
+	assert.Error(t, err)
+}
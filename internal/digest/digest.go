@@ -0,0 +1,164 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/mail"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+)
+
+// maxDigestLinks is how many links are listed in a digest. The rest are only counted.
+const maxDigestLinks = 100
+
+// LinkSource streams the links of repos, RepoLinkRepository in the app
+type LinkSource interface {
+	StreamRepositoryLinks(filter repository.RepoLinkFilter, fn func(repo *models.RepoWithLinks, link *models.Link) error) error
+}
+
+// Digester emails confirmed subscribers the links found in an author's repos since their last digest.
+// Repos are parsed again after webhook pushes and rescans, so links already sent are remembered and not sent again.
+type Digester struct {
+	links         LinkSource
+	subscriptions repository.DigestSubscriptionRepository
+	sender        mail.Sender
+	appURL        string
+	log           common.Logger
+}
+
+func NewDigester(links LinkSource, subscriptions repository.DigestSubscriptionRepository, sender mail.Sender, appURL string, log common.Logger) *Digester {
+	return &Digester{
+		links:         links,
+		subscriptions: subscriptions,
+		sender:        sender,
+		appURL:        strings.TrimSuffix(appURL, "/"),
+		log:           log,
+	}
+}
+
+// digestLink is a link listed in a digest
+type digestLink struct {
+	repo string
+	link models.Link
+}
+
+// SendDigests sends a digest to every subscription with new links. Subscriptions that fail are retried on the next run.
+func (d *Digester) SendDigests(ctx context.Context) {
+	subs, err := d.subscriptions.GetSubscriptions()
+	if err != nil {
+		d.log.Errorf("Error getting digest subscriptions: %v", err)
+		return
+	}
+
+	sent := 0
+	for _, sub := range subs {
+		if ctx.Err() != nil {
+			return
+		}
+		ok, err := d.sendDigest(sub)
+		if err != nil {
+			d.log.Errorf("Error sending digest of '%s' to subscription '%d': %v", sub.Author, sub.ID, err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	d.log.Infof("Sent %d digests to %d subscriptions", sent, len(subs))
+}
+
+// sendDigest emails the links found since the subscription's last digest, returning false if there are none
+func (d *Digester) sendDigest(sub models.DigestSubscriptionModel) (bool, error) {
+	var links []digestLink
+	var sent []models.DigestSentLink
+	total := 0
+	newest := sub.LastDigestAt
+
+	filter := repository.RepoLinkFilter{Author: sub.Author, ViewerID: sub.UserID, Since: sub.LastDigestAt, NotSentTo: sub.ID}
+	err := d.links.StreamRepositoryLinks(filter, func(repo *models.RepoWithLinks, link *models.Link) error {
+		if link == nil {
+			return nil
+		}
+		total++
+		sent = append(sent, models.DigestSentLink{RepoID: repo.ID, Url: link.Url, File: link.File, LineNumber: link.LineNumber})
+		if link.CreatedAt.After(newest) {
+			newest = link.CreatedAt
+		}
+		if len(links) < maxDigestLinks {
+			links = append(links, digestLink{repo: repo.Author + "/" + repo.Name, link: *link})
+		}
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("error getting links: %w", err)
+	}
+	if total == 0 {
+		return false, nil
+	}
+
+	msg := mail.Message{
+		To:      []string{sub.Email},
+		Subject: fmt.Sprintf("%d new goo.gl %s in %s's repos", total, plural(total, "link"), sub.Author),
+		Body:    d.body(sub, links, total),
+	}
+	if err := d.sender.Send(msg); err != nil {
+		return false, err
+	}
+
+	if err := d.subscriptions.MarkLinksSent(sub.ID, sent); err != nil {
+		return true, err
+	}
+	if err := d.subscriptions.UpdateLastDigest(sub.ID, newest); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func (d *Digester) body(sub models.DigestSubscriptionModel, links []digestLink, total int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d new goo.gl %s in %s's repos since %s.\n", total, plural(total, "link"), sub.Author, sub.LastDigestAt.UTC().Format("2 Jan 2006 15:04 MST"))
+
+	repo := ""
+	for _, l := range links {
+		if l.repo != repo {
+			repo = l.repo
+			fmt.Fprintf(&b, "\n%s\n", repo)
+		}
+		fmt.Fprintf(&b, "  %s:%d  %s", l.link.File, l.link.LineNumber, l.link.Url)
+		if l.link.ExpandedURL != "" {
+			fmt.Fprintf(&b, " -> %s", l.link.ExpandedURL)
+		}
+		b.WriteString("\n")
+		if l.link.GithubUrl != "" {
+			fmt.Fprintf(&b, "    %s\n", l.link.GithubUrl)
+		}
+	}
+	if total > len(links) {
+		fmt.Fprintf(&b, "\n...and %d more.\n", total-len(links))
+	}
+
+	fmt.Fprintf(&b, "\nSearch for %s to see all of their links: %s/search-user\n", sub.Author, d.appURL)
+	fmt.Fprintf(&b, "Unsubscribe: %s\n", UnsubscribeURL(d.appURL, sub.UnsubscribeToken))
+	return b.String()
+}
+
+// ConfirmURL is the page an email address confirms a subscription on
+func ConfirmURL(appURL, token string) string {
+	return fmt.Sprintf("%s/v1/api/digests/confirm?token=%s", strings.TrimSuffix(appURL, "/"), url.QueryEscape(token))
+}
+
+// UnsubscribeURL is the page a recipient unsubscribes on, linked at the end of every digest
+func UnsubscribeURL(appURL, token string) string {
+	return fmt.Sprintf("%s/v1/api/digests/unsubscribe?token=%s", strings.TrimSuffix(appURL, "/"), url.QueryEscape(token))
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
@@ -0,0 +1,195 @@
+package digest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/mail"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/test"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+type fakeLink struct {
+	repo models.RepoWithLinks
+	link models.Link
+	// visibleTo is the only viewer that can see the link, when set
+	visibleTo int
+}
+
+type fakeLinks struct {
+	links   []fakeLink
+	filters []repository.RepoLinkFilter
+	// sent is shared with fakeSubscriptions, to exclude links already sent
+	sent map[int][]models.DigestSentLink
+}
+
+func (f *fakeLinks) StreamRepositoryLinks(filter repository.RepoLinkFilter, fn func(repo *models.RepoWithLinks, link *models.Link) error) error {
+	f.filters = append(f.filters, filter)
+	for _, l := range f.links {
+		if l.repo.Author != filter.Author || !l.link.CreatedAt.After(filter.Since) {
+			continue
+		}
+		if l.visibleTo != 0 && l.visibleTo != filter.ViewerID {
+			continue
+		}
+		if wasSent(f.sent[filter.NotSentTo], l) {
+			continue
+		}
+		repo, link := l.repo, l.link
+		if err := fn(&repo, &link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func wasSent(sent []models.DigestSentLink, l fakeLink) bool {
+	for _, s := range sent {
+		if s == (models.DigestSentLink{RepoID: l.repo.ID, Url: l.link.Url, File: l.link.File, LineNumber: l.link.LineNumber}) {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeSubscriptions struct {
+	subs []models.DigestSubscriptionModel
+	sent map[int][]models.DigestSentLink
+}
+
+func (f *fakeSubscriptions) CreateSubscription(sub *models.DigestSubscriptionModel) (bool, error) {
+	return false, nil
+}
+func (f *fakeSubscriptions) ConfirmSubscription(token string) (bool, error) { return false, nil }
+func (f *fakeSubscriptions) GetSubscriptionsForUser(userID int) ([]models.DigestSubscriptionModel, error) {
+	return nil, nil
+}
+func (f *fakeSubscriptions) DeleteSubscription(id, userID int) (bool, error)      { return false, nil }
+func (f *fakeSubscriptions) DeleteSubscriptionByToken(token string) (bool, error) { return false, nil }
+
+func (f *fakeSubscriptions) GetSubscriptions() ([]models.DigestSubscriptionModel, error) {
+	return f.subs, nil
+}
+
+func (f *fakeSubscriptions) MarkLinksSent(id int, links []models.DigestSentLink) error {
+	f.sent[id] = append(f.sent[id], links...)
+	return nil
+}
+
+func (f *fakeSubscriptions) UpdateLastDigest(id int, lastDigestAt time.Time) error {
+	for i := range f.subs {
+		if f.subs[i].ID == id {
+			f.subs[i].LastDigestAt = lastDigestAt
+		}
+	}
+	return nil
+}
+
+type failingSender struct{}
+
+func (failingSender) Send(msg mail.Message) error { return fmt.Errorf("smtp unavailable") }
+
+var lastDigest = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestFakes creates the links and subscriptions for tests, sharing the links sent to subscriptions
+func newTestFakes() (*fakeLinks, *fakeSubscriptions) {
+	links, subs := newTestLinks(), newTestSubscriptions()
+	links.sent = subs.sent
+	return links, subs
+}
+
+func newTestLinks() *fakeLinks {
+	repo := models.RepoWithLinks{ID: 5, Name: "dotfiles", Author: "jwtly10"}
+	return &fakeLinks{links: []fakeLink{
+		{repo: repo, link: models.Link{Url: "https://goo.gl/old", File: "README.md", LineNumber: 1, CreatedAt: lastDigest.Add(-time.Hour)}},
+		{repo: repo, link: models.Link{Url: "https://goo.gl/abc", ExpandedURL: "https://example.com", File: "README.md", LineNumber: 3, GithubUrl: "https://github.com/jwtly10/dotfiles/blob/main/README.md#L3", CreatedAt: lastDigest.Add(time.Hour)}},
+		{repo: repo, link: models.Link{Url: "https://goo.gl/xyz", File: "main.go", LineNumber: 10, CreatedAt: lastDigest.Add(2 * time.Hour)}},
+	}}
+}
+
+func newTestSubscriptions() *fakeSubscriptions {
+	return &fakeSubscriptions{sent: map[int][]models.DigestSentLink{}, subs: []models.DigestSubscriptionModel{
+		{Model: models.Model{ID: 1}, Email: "me@example.com", Author: "jwtly10", UserID: 1, UnsubscribeToken: "token", LastDigestAt: lastDigest},
+		{Model: models.Model{ID: 2}, Email: "me@example.com", Author: "nobody", UserID: 1, UnsubscribeToken: "token-2", LastDigestAt: lastDigest},
+	}}
+}
+
+func TestSendDigests(t *testing.T) {
+	server := test.NewFakeSMTPServer(t)
+	links, subs := newTestFakes()
+
+	sender := mail.NewSMTPSender(server.Host(), server.Port(), "", "", "googl-bye@example.com")
+	d := NewDigester(links, subs, sender, "https://googl-bye.example.com/", common.NewLogger(false, zapcore.DebugLevel))
+	d.SendDigests(context.Background())
+
+	// Only the subscription with new links gets an email
+	emails := server.Emails()
+	assert.Len(t, emails, 1)
+	assert.Equal(t, []string{"me@example.com"}, emails[0].To)
+	assert.Contains(t, emails[0].Data, "Subject: 2 new goo.gl links in jwtly10's repos\r\n")
+	assert.Contains(t, emails[0].Data, "jwtly10/dotfiles\r\n  README.md:3  https://goo.gl/abc -> https://example.com\r\n")
+	assert.Contains(t, emails[0].Data, "https://github.com/jwtly10/dotfiles/blob/main/README.md#L3")
+	assert.Contains(t, emails[0].Data, "main.go:10  https://goo.gl/xyz")
+	assert.NotContains(t, emails[0].Data, "goo.gl/old")
+	assert.Contains(t, emails[0].Data, "Unsubscribe: https://googl-bye.example.com/v1/api/digests/unsubscribe?token=token")
+
+	// Links are only those the subscriber can see
+	assert.Equal(t, 1, links.filters[0].ViewerID)
+
+	// The next digest starts after the newest link sent
+	assert.Equal(t, lastDigest.Add(2*time.Hour), subs.subs[0].LastDigestAt)
+	assert.Equal(t, lastDigest, subs.subs[1].LastDigestAt)
+
+	// Nothing new is found on the next run
+	d.SendDigests(context.Background())
+	assert.Len(t, server.Emails(), 1)
+
+	// Links found again when the repo is parsed again aren't sent again, but new links are
+	for i := range links.links[1:] {
+		links.links[i+1].link.CreatedAt = lastDigest.Add(3 * time.Hour)
+	}
+	repo := links.links[0].repo
+	links.links = append(links.links, fakeLink{repo: repo, link: models.Link{Url: "https://goo.gl/new", File: "main.go", LineNumber: 20, CreatedAt: lastDigest.Add(3 * time.Hour)}})
+	d.SendDigests(context.Background())
+
+	emails = server.Emails()
+	assert.Len(t, emails, 2)
+	assert.Contains(t, emails[1].Data, "Subject: 1 new goo.gl link in jwtly10's repos\r\n")
+	assert.Contains(t, emails[1].Data, "main.go:20  https://goo.gl/new")
+	assert.NotContains(t, emails[1].Data, "goo.gl/abc")
+}
+
+func TestSendDigestsLimitsLinks(t *testing.T) {
+	server := test.NewFakeSMTPServer(t)
+	links, subs := &fakeLinks{}, newTestSubscriptions()
+	repo := models.RepoWithLinks{ID: 6, Name: "big", Author: "jwtly10"}
+	for i := 0; i < maxDigestLinks+5; i++ {
+		links.links = append(links.links, fakeLink{repo: repo, link: models.Link{Url: "https://goo.gl/abc", File: "main.go", LineNumber: i, CreatedAt: lastDigest.Add(time.Minute)}})
+	}
+
+	sender := mail.NewSMTPSender(server.Host(), server.Port(), "", "", "googl-bye@example.com")
+	NewDigester(links, subs, sender, "http://localhost:8080", common.NewLogger(false, zapcore.DebugLevel)).SendDigests(context.Background())
+
+	emails := server.Emails()
+	assert.Len(t, emails, 1)
+	assert.Contains(t, emails[0].Data, fmt.Sprintf("Subject: %d new goo.gl links", maxDigestLinks+5))
+	assert.Equal(t, maxDigestLinks, strings.Count(emails[0].Data, "https://goo.gl/abc"))
+	assert.Contains(t, emails[0].Data, "...and 5 more.")
+}
+
+func TestSendDigestsRetriesFailedEmails(t *testing.T) {
+	links, subs := newTestFakes()
+
+	NewDigester(links, subs, failingSender{}, "http://localhost:8080", common.NewLogger(false, zapcore.DebugLevel)).SendDigests(context.Background())
+
+	// The links are sent again on the next run
+	assert.Equal(t, lastDigest, subs.subs[0].LastDigestAt)
+	assert.Empty(t, subs.sent)
+}
@@ -1,5 +1,10 @@
 package errors
 
+import (
+	"errors"
+	"time"
+)
+
 // NotFoundError represents a resource not found error
 type NotFoundError struct {
 	Message string
@@ -18,6 +23,24 @@ func (e *BadRequestError) Error() string {
 	return e.Message
 }
 
+// UnauthorizedError represents a request without valid credentials
+type UnauthorizedError struct {
+	Message string
+}
+
+func (e *UnauthorizedError) Error() string {
+	return e.Message
+}
+
+// ForbiddenError represents a request with valid credentials that aren't allowed to do something
+type ForbiddenError struct {
+	Message string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Message
+}
+
 // InternalError represents an internal server error
 type InternalError struct {
 	Message string
@@ -27,6 +50,28 @@ func (e *InternalError) Error() string {
 	return e.Message
 }
 
+// RateLimitedError represents a request that was rate limited by an upstream API
+type RateLimitedError struct {
+	Message string
+	// Resource is the rate limit resource that was exhausted, e.g. 'search' or 'core'
+	Resource string
+	// RetryAfter is how long to wait before the request can be retried
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return e.Message
+}
+
+// AsRateLimitedError finds a RateLimitedError in the chain of wrapped errors
+func AsRateLimitedError(err error) (*RateLimitedError, bool) {
+	var rle *RateLimitedError
+	if errors.As(err, &rle) {
+		return rle, true
+	}
+	return nil, false
+}
+
 func NewNotFoundError(message string) error {
 	return &NotFoundError{Message: message}
 }
@@ -35,6 +80,18 @@ func NewBadRequestError(message string) error {
 	return &BadRequestError{Message: message}
 }
 
+func NewUnauthorizedError(message string) error {
+	return &UnauthorizedError{Message: message}
+}
+
+func NewForbiddenError(message string) error {
+	return &ForbiddenError{Message: message}
+}
+
 func NewInternalError(message string) error {
 	return &InternalError{Message: message}
 }
+
+func NewRateLimitedError(message, resource string, retryAfter time.Duration) error {
+	return &RateLimitedError{Message: message, Resource: resource, RetryAfter: retryAfter}
+}
@@ -0,0 +1,38 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jwtly10/googl-bye/internal/forge"
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+// IssueTitle is the title of the issues raised against repos with links
+const IssueTitle = "Replace goo.gl short links"
+
+// NewRepoIssue renders the links of a repo as an issue to raise against it, asking for each link to be replaced
+func NewRepoIssue(repo *models.RepoWithLinks) forge.Issue {
+	var b strings.Builder
+	fmt.Fprintf(&b, "goo.gl short links stop redirecting once the goo.gl shortener is shut down. %d were found in this repository by [%s](%s).\n\n", len(repo.Links), toolName, toolURI)
+	fmt.Fprint(&b, "| Link | Replace with | Location |\n| --- | --- | --- |\n")
+
+	for _, link := range repo.Links {
+		replacement := "Could not be expanded, find where it pointed"
+		if isExpanded(link.ExpandedURL) {
+			replacement = escapeMarkdown(link.ExpandedURL)
+		}
+
+		location := escapeMarkdown(fmt.Sprintf("%s:%d", link.File, link.LineNumber))
+		if link.GithubUrl != "" {
+			location = fmt.Sprintf("[%s](%s)", location, link.GithubUrl)
+		}
+
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", escapeMarkdown(link.Url), replacement, location)
+	}
+
+	return forge.Issue{
+		Title: IssueTitle,
+		Body:  b.String(),
+	}
+}
@@ -0,0 +1,24 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRepoIssue(t *testing.T) {
+	issue := NewRepoIssue(&models.RepoWithLinks{
+		Name:   "repo",
+		Author: "owner",
+		Links: []models.Link{
+			{Url: "https://goo.gl/abc", ExpandedURL: "https://example.com/a_b", File: "main.go", LineNumber: 4, GithubUrl: "https://github.com/owner/repo/blob/main/main.go#L4"},
+			{Url: "goo.gl/forms/xyz", ExpandedURL: "ERROR: unexpected status code 404", File: "docs/README.md", LineNumber: 2},
+		},
+	})
+
+	assert.Equal(t, IssueTitle, issue.Title)
+	assert.Contains(t, issue.Body, "2 were found in this repository")
+	assert.Contains(t, issue.Body, "| https://goo.gl/abc | https://example.com/a\\_b | [main.go:4](https://github.com/owner/repo/blob/main/main.go#L4) |\n")
+	assert.Contains(t, issue.Body, "| goo.gl/forms/xyz | Could not be expanded, find where it pointed | docs/README.md:2 |\n")
+}
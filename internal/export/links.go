@@ -0,0 +1,284 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+// This file renders repos and their links as CSV, NDJSON and Markdown reports.
+// The writers take one row at a time, so a report can be streamed straight from the database.
+
+const (
+	FormatCSV      = "csv"
+	FormatNDJSON   = "ndjson"
+	FormatMarkdown = "markdown"
+)
+
+// LinkWriter writes a report one repo/link row at a time. Rows must be ordered by author and repo.
+type LinkWriter interface {
+	// WriteRow writes a single row. link is nil for repos without links.
+	WriteRow(repo *models.RepoWithLinks, link *models.Link) error
+	// Close writes anything remaining (e.g. a summary) and flushes the output
+	Close() error
+	// ContentType is the media type of the report
+	ContentType() string
+	// FileExtension is the extension to use when the report is downloaded
+	FileExtension() string
+}
+
+// NewLinkWriter returns the writer for the format, or an error if the format is not supported
+func NewLinkWriter(format string, w io.Writer) (LinkWriter, error) {
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return newCsvLinkWriter(w), nil
+	case FormatNDJSON:
+		return newNdjsonLinkWriter(w), nil
+	case FormatMarkdown, "md":
+		return newMarkdownLinkWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format '%s', expected one of: %s, %s, %s", format, FormatCSV, FormatNDJSON, FormatMarkdown)
+	}
+}
+
+// LinkRow is a flattened repo/link row, used by the CSV and NDJSON exports
+type LinkRow struct {
+	RepoID         int    `json:"repoId"`
+	Author         string `json:"author"`
+	Name           string `json:"name"`
+	Forge          string `json:"forge"`
+	State          string `json:"state"`
+	Language       string `json:"language"`
+	Stars          int    `json:"stars"`
+	RepoUrl        string `json:"repoUrl"`
+	ErrorMsg       string `json:"errorMsg,omitempty"`
+	Url            string `json:"url,omitempty"`
+	ExpandedUrl    string `json:"expandedUrl,omitempty"`
+	File           string `json:"file,omitempty"`
+	LineNumber     int    `json:"lineNumber,omitempty"`
+	ColumnNumber   int    `json:"columnNumber,omitempty"`
+	Classification string `json:"classification,omitempty"`
+	AffectsRuntime bool   `json:"affectsRuntime"`
+	LinkUrl        string `json:"linkUrl,omitempty"`
+}
+
+func newLinkRow(repo *models.RepoWithLinks, link *models.Link) LinkRow {
+	row := LinkRow{
+		RepoID:   repo.ID,
+		Author:   repo.Author,
+		Name:     repo.Name,
+		Forge:    repo.Forge,
+		State:    repo.State,
+		Language: repo.Language,
+		Stars:    repo.Stars,
+		RepoUrl:  repo.GhUrl,
+		ErrorMsg: repo.ErrorMsg,
+	}
+	if link != nil {
+		row.Url = link.Url
+		row.ExpandedUrl = link.ExpandedURL
+		row.File = link.File
+		row.LineNumber = link.LineNumber
+		row.ColumnNumber = link.ColumnNumber
+		row.Classification = link.Classification
+		row.AffectsRuntime = link.AffectsRuntime
+		row.LinkUrl = link.GithubUrl
+	}
+	return row
+}
+
+var csvHeader = []string{
+	"repo_id", "author", "name", "forge", "state", "language", "stars", "repo_url", "error_msg",
+	"url", "expanded_url", "file", "line_number", "column_number", "classification", "affects_runtime", "link_url",
+}
+
+type csvLinkWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func newCsvLinkWriter(w io.Writer) *csvLinkWriter {
+	return &csvLinkWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvLinkWriter) WriteRow(repo *models.RepoWithLinks, link *models.Link) error {
+	if !c.headerWritten {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+		c.headerWritten = true
+	}
+
+	row := newLinkRow(repo, link)
+	record := []string{
+		strconv.Itoa(row.RepoID), row.Author, row.Name, row.Forge, row.State, row.Language, strconv.Itoa(row.Stars), row.RepoUrl, row.ErrorMsg,
+		row.Url, row.ExpandedUrl, row.File, optionalInt(row.LineNumber), optionalInt(row.ColumnNumber), row.Classification, strconv.FormatBool(row.AffectsRuntime), row.LinkUrl,
+	}
+	for i, cell := range record {
+		record[i] = escapeCsvCell(cell)
+	}
+	return c.w.Write(record)
+}
+
+// escapeCsvCell stops spreadsheets running cells as formulas, as file names and links come from repos anyone can push to
+func escapeCsvCell(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (c *csvLinkWriter) Close() error {
+	// An empty export still gets a header, so it can be opened as a spreadsheet
+	if !c.headerWritten {
+		if err := c.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvLinkWriter) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (c *csvLinkWriter) FileExtension() string {
+	return "csv"
+}
+
+func optionalInt(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+type ndjsonLinkWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNdjsonLinkWriter(w io.Writer) *ndjsonLinkWriter {
+	bw := bufio.NewWriter(w)
+	return &ndjsonLinkWriter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (n *ndjsonLinkWriter) WriteRow(repo *models.RepoWithLinks, link *models.Link) error {
+	// Encode terminates each value with a newline
+	return n.enc.Encode(newLinkRow(repo, link))
+}
+
+func (n *ndjsonLinkWriter) Close() error {
+	return n.w.Flush()
+}
+
+func (n *ndjsonLinkWriter) ContentType() string {
+	return "application/x-ndjson"
+}
+
+func (n *ndjsonLinkWriter) FileExtension() string {
+	return "ndjson"
+}
+
+// markdownLinkWriter writes a heading per author, a table per repo and a summary of the totals at the end
+type markdownLinkWriter struct {
+	w          *bufio.Writer
+	author     string
+	repoID     int
+	repoCount  int
+	linkCount  int
+	errorCount int
+	authors    int
+}
+
+func newMarkdownLinkWriter(w io.Writer) *markdownLinkWriter {
+	return &markdownLinkWriter{w: bufio.NewWriter(w), repoID: -1}
+}
+
+func (m *markdownLinkWriter) WriteRow(repo *models.RepoWithLinks, link *models.Link) error {
+	if m.repoCount == 0 {
+		fmt.Fprintf(m.w, "# goo.gl links report\n\nGenerated %s\n", time.Now().UTC().Format(time.RFC1123))
+	}
+
+	if repo.Author != m.author || m.authors == 0 {
+		m.author = repo.Author
+		m.authors++
+		fmt.Fprintf(m.w, "\n## %s\n", escapeMarkdown(repo.Author))
+	}
+
+	if repo.ID != m.repoID {
+		m.repoID = repo.ID
+		m.repoCount++
+		m.writeRepoHeading(repo)
+		if link != nil {
+			fmt.Fprint(m.w, "| Link | Expanded | Location | Type |\n| --- | --- | --- | --- |\n")
+		}
+	}
+
+	if link != nil {
+		m.linkCount++
+		location := fmt.Sprintf("%s:%d", link.File, link.LineNumber)
+		if link.GithubUrl != "" {
+			location = fmt.Sprintf("[%s](%s)", escapeMarkdown(location), markdownDestination(link.GithubUrl))
+		} else {
+			location = escapeMarkdown(location)
+		}
+		fmt.Fprintf(m.w, "| %s | %s | %s | %s |\n", escapeMarkdown(link.Url), escapeMarkdown(link.ExpandedURL), location, link.Classification)
+	}
+
+	return nil
+}
+
+func (m *markdownLinkWriter) writeRepoHeading(repo *models.RepoWithLinks) {
+	name := escapeMarkdown(fmt.Sprintf("%s/%s", repo.Author, repo.Name))
+	if repo.GhUrl != "" {
+		name = fmt.Sprintf("[%s](%s)", name, markdownDestination(repo.GhUrl))
+	}
+	fmt.Fprintf(m.w, "\n### %s\n\n", name)
+
+	if repo.State == "ERROR" {
+		m.errorCount++
+		fmt.Fprintf(m.w, "> Parsing failed: %s\n", escapeMarkdown(repo.ErrorMsg))
+	}
+}
+
+func (m *markdownLinkWriter) Close() error {
+	if m.repoCount == 0 {
+		fmt.Fprint(m.w, "# goo.gl links report\n\nNo repositories matched.\n")
+		return m.w.Flush()
+	}
+
+	fmt.Fprint(m.w, "\n## Summary\n\n| | Count |\n| --- | --- |\n")
+	fmt.Fprintf(m.w, "| Authors | %d |\n| Repositories | %d |\n| Links | %d |\n| Failed to parse | %d |\n", m.authors, m.repoCount, m.linkCount, m.errorCount)
+	return m.w.Flush()
+}
+
+func (m *markdownLinkWriter) ContentType() string {
+	return "text/markdown; charset=utf-8"
+}
+
+func (m *markdownLinkWriter) FileExtension() string {
+	return "md"
+}
+
+var markdownEscaper = strings.NewReplacer("|", "\\|", "\n", " ", "\r", "", "[", "\\[", "]", "\\]", "*", "\\*", "_", "\\_", "`", "\\`")
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// Link destinations are wrapped in <...>, where only the brackets, line breaks and the table's pipes need encoding.
+// Spaces are encoded too, so the url stays usable when copied out of the source
+var destinationEscaper = strings.NewReplacer("<", "%3C", ">", "%3E", " ", "%20", "\n", "%0A", "\r", "%0D", "|", "%7C")
+
+func markdownDestination(url string) string {
+	return "<" + destinationEscaper.Replace(url) + ">"
+}
@@ -0,0 +1,139 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	exportRepo = &models.RepoWithLinks{
+		ID:       1,
+		Name:     "repo",
+		Author:   "owner",
+		Forge:    models.ForgeGitHub,
+		State:    "COMPLETED",
+		Language: "Go",
+		Stars:    500,
+		GhUrl:    "https://github.com/owner/repo",
+	}
+	exportErrorRepo = &models.RepoWithLinks{
+		ID:       2,
+		Name:     "broken",
+		Author:   "other",
+		Forge:    models.ForgeGitHub,
+		State:    "ERROR",
+		ErrorMsg: "clone failed",
+	}
+	exportLinks = []*models.Link{
+		{
+			Url:            "https://goo.gl/abc",
+			ExpandedURL:    "https://example.com/a,b",
+			File:           "main.go",
+			LineNumber:     3,
+			ColumnNumber:   10,
+			Classification: models.LinkInString,
+			AffectsRuntime: true,
+			GithubUrl:      "https://github.com/owner/repo/blob/main/main.go#L3",
+		},
+		{
+			Url:            "goo.gl/xyz",
+			ExpandedURL:    "https://example.com/b",
+			File:           "README.md",
+			LineNumber:     7,
+			Classification: models.LinkInMarkdown,
+		},
+	}
+)
+
+func writeExportRows(t *testing.T, format string) string {
+	var buf bytes.Buffer
+	lw, err := NewLinkWriter(format, &buf)
+	assert.NoError(t, err)
+
+	assert.NoError(t, lw.WriteRow(exportErrorRepo, nil))
+	for _, l := range exportLinks {
+		assert.NoError(t, lw.WriteRow(exportRepo, l))
+	}
+	assert.NoError(t, lw.Close())
+	return buf.String()
+}
+
+func TestNewLinkWriterUnsupportedFormat(t *testing.T) {
+	_, err := NewLinkWriter("xml", &bytes.Buffer{})
+	assert.EqualError(t, err, "unsupported export format 'xml', expected one of: csv, ndjson, markdown")
+}
+
+func TestCsvLinkWriter(t *testing.T) {
+	out := writeExportRows(t, FormatCSV)
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 4)
+	assert.Equal(t, csvHeader, records[0])
+	assert.Equal(t, []string{"2", "other", "broken", "github", "ERROR", "", "0", "", "clone failed", "", "", "", "", "", "", "false", ""}, records[1])
+	assert.Equal(t, "https://example.com/a,b", records[2][10])
+	assert.Equal(t, "10", records[2][13])
+	assert.Equal(t, "true", records[2][15])
+}
+
+func TestCsvLinkWriterEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	lw, _ := NewLinkWriter(FormatCSV, &buf)
+	assert.NoError(t, lw.WriteRow(exportRepo, &models.Link{Url: "goo.gl/abc", File: "=HYPERLINK(\"https://example.com\")", ExpandedURL: "@SUM(1)", Classification: "-1+1"}))
+	assert.NoError(t, lw.Close())
+
+	records, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "goo.gl/abc", records[1][9])
+	assert.Equal(t, "'@SUM(1)", records[1][10])
+	assert.Equal(t, "'=HYPERLINK(\"https://example.com\")", records[1][11])
+	assert.Equal(t, "'-1+1", records[1][14])
+}
+
+func TestCsvLinkWriterWritesHeaderWhenEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	lw, _ := NewLinkWriter(FormatCSV, &buf)
+	assert.NoError(t, lw.Close())
+	assert.Equal(t, strings.Join(csvHeader, ",")+"\n", buf.String())
+}
+
+func TestNdjsonLinkWriter(t *testing.T) {
+	out := writeExportRows(t, FormatNDJSON)
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	assert.Len(t, lines, 3)
+
+	var row LinkRow
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &row))
+	assert.Equal(t, "owner", row.Author)
+	assert.Equal(t, "https://goo.gl/abc", row.Url)
+	assert.Equal(t, 3, row.LineNumber)
+	assert.True(t, row.AffectsRuntime)
+}
+
+func TestMarkdownLinkWriter(t *testing.T) {
+	out := writeExportRows(t, FormatMarkdown)
+
+	assert.Contains(t, out, "## other\n")
+	assert.Contains(t, out, "### other/broken\n")
+	assert.Contains(t, out, "> Parsing failed: clone failed")
+	assert.Contains(t, out, "## owner\n")
+	assert.Contains(t, out, "### [owner/repo](<https://github.com/owner/repo>)")
+	assert.Contains(t, out, "| https://goo.gl/abc | https://example.com/a,b | [main.go:3](<https://github.com/owner/repo/blob/main/main.go#L3>) | STRING |")
+	assert.Contains(t, out, "| goo.gl/xyz | https://example.com/b | README.md:7 | MARKDOWN |")
+	assert.Contains(t, out, "| Authors | 2 |\n| Repositories | 2 |\n| Links | 2 |\n| Failed to parse | 1 |")
+
+	// Only one table header is written per repo
+	assert.Equal(t, 1, strings.Count(out, "| Link | Expanded |"))
+}
+
+func TestMarkdownDestination(t *testing.T) {
+	assert.Equal(t, "<https://github.com/o/r/blob/main/a%20(b).md#L1>", markdownDestination("https://github.com/o/r/blob/main/a (b).md#L1"))
+	assert.Equal(t, "<https://example.com/%3Cx%3E%7Cy%0A>", markdownDestination("https://example.com/<x>|y\n"))
+}
@@ -0,0 +1,303 @@
+package export
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+// This file renders links as SARIF 2.1.0, so they can be uploaded to GitHub code scanning or opened in an IDE
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// SarifContentType is the media type registered for SARIF files
+	SarifContentType = "application/sarif+json"
+
+	toolName = "googl-bye"
+	toolURI  = "https://github.com/jwtly10/googl-bye"
+
+	// srcRoot is the uriBaseId that file locations are relative to. It isn't defined in originalUriBaseIds,
+	// as the root of the repo isn't known, so consumers resolve it to wherever the files are checked out.
+	srcRoot = "SRCROOT"
+
+	// columnKind is the unit of columns in regions, matching how the parser counts columns
+	columnKind = "unicodeCodePoints"
+)
+
+// Rule ids, one per shortener
+const (
+	RuleGooGlLink      = "GOOGL001"
+	RuleGooGlFormsLink = "GOOGL002"
+)
+
+var sarifRules = []SarifRule{
+	{
+		ID:   RuleGooGlLink,
+		Name: "GooGlShortLink",
+		ShortDescription: SarifMessage{
+			Text: "goo.gl short link",
+		},
+		FullDescription: SarifMessage{
+			Text: "goo.gl short links stop redirecting once the goo.gl shortener is shut down. Replace the link with the url it redirects to.",
+		},
+		HelpURI: toolURI,
+		DefaultConfiguration: SarifRuleConfiguration{
+			Level: "warning",
+		},
+	},
+	{
+		ID:   RuleGooGlFormsLink,
+		Name: "GooGlFormsLink",
+		ShortDescription: SarifMessage{
+			Text: "goo.gl/forms short link",
+		},
+		FullDescription: SarifMessage{
+			Text: "goo.gl/forms links stop redirecting once the goo.gl shortener is shut down. Replace the link with the full Google Forms url it redirects to.",
+		},
+		HelpURI: toolURI,
+		DefaultConfiguration: SarifRuleConfiguration{
+			Level: "warning",
+		},
+	},
+}
+
+type SarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool                     SarifTool                 `json:"tool"`
+	ColumnKind               string                    `json:"columnKind"`
+	VersionControlProvenance []SarifVersionControlInfo `json:"versionControlProvenance,omitempty"`
+	Results                  []SarifResult             `json:"results"`
+	Properties               map[string]interface{}    `json:"properties,omitempty"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SarifRule `json:"rules"`
+}
+
+type SarifRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	ShortDescription     SarifMessage           `json:"shortDescription"`
+	FullDescription      SarifMessage           `json:"fullDescription"`
+	HelpURI              string                 `json:"helpUri"`
+	DefaultConfiguration SarifRuleConfiguration `json:"defaultConfiguration"`
+}
+
+type SarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifVersionControlInfo struct {
+	RepositoryURI string `json:"repositoryUri"`
+}
+
+type SarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    SarifMessage           `json:"message"`
+	Locations  []SarifLocation        `json:"locations"`
+	Fixes      []SarifFix             `json:"fixes,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation SarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifact `json:"artifactLocation"`
+	Region           SarifRegion   `json:"region"`
+	ContextRegion    *SarifRegion  `json:"contextRegion,omitempty"`
+}
+
+type SarifArtifact struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type SarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn,omitempty"`
+	EndLine     int           `json:"endLine,omitempty"`
+	EndColumn   int           `json:"endColumn,omitempty"`
+	Snippet     *SarifMessage `json:"snippet,omitempty"`
+}
+
+type SarifFix struct {
+	Description     SarifMessage          `json:"description"`
+	ArtifactChanges []SarifArtifactChange `json:"artifactChanges"`
+}
+
+type SarifArtifactChange struct {
+	ArtifactLocation SarifArtifact      `json:"artifactLocation"`
+	Replacements     []SarifReplacement `json:"replacements"`
+}
+
+type SarifReplacement struct {
+	DeletedRegion   SarifRegion  `json:"deletedRegion"`
+	InsertedContent SarifMessage `json:"insertedContent"`
+}
+
+// NewRepoSarif renders the links of a parsed repository as a SARIF log
+func NewRepoSarif(repo *models.RepoWithLinks) *SarifLog {
+	run := newSarifRun(repo.Links)
+	if repo.GhUrl != "" {
+		run.VersionControlProvenance = []SarifVersionControlInfo{{RepositoryURI: repo.GhUrl}}
+	}
+	run.Properties = map[string]interface{}{
+		"repository": fmt.Sprintf("%s/%s", repo.Author, repo.Name),
+		"forge":      repo.Forge,
+	}
+	return newSarifLog(run)
+}
+
+// NewScanSarif renders the links of a scan report as a SARIF log
+func NewScanSarif(report *models.ScanReport) *SarifLog {
+	run := newSarifRun(report.Links)
+	run.Properties = map[string]interface{}{
+		"scanned": report.Name,
+	}
+	return newSarifLog(run)
+}
+
+func newSarifLog(run SarifRun) *SarifLog {
+	return &SarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []SarifRun{run},
+	}
+}
+
+func newSarifRun(links []models.Link) SarifRun {
+	results := make([]SarifResult, 0, len(links))
+	for _, l := range links {
+		results = append(results, newSarifResult(l))
+	}
+
+	return SarifRun{
+		Tool: SarifTool{
+			Driver: SarifDriver{
+				Name:           toolName,
+				InformationURI: toolURI,
+				Rules:          sarifRules,
+			},
+		},
+		ColumnKind: columnKind,
+		Results:    results,
+	}
+}
+
+// artifactURI turns a file path into the relative URI reference SARIF expects, so paths with spaces
+// or characters like '#' still point at the file
+func artifactURI(file string) string {
+	return (&url.URL{Path: strings.TrimPrefix(file, "/")}).EscapedPath()
+}
+
+func newSarifResult(l models.Link) SarifResult {
+	ruleIndex := shortenerRuleIndex(l.Url)
+	artifact := SarifArtifact{
+		URI:       artifactURI(l.File),
+		URIBaseID: srcRoot,
+	}
+
+	region := SarifRegion{
+		StartLine: max(l.LineNumber, 1),
+	}
+	// Links saved before columns were tracked only have a line number
+	if l.ColumnNumber > 0 {
+		region.StartColumn = l.ColumnNumber
+		region.EndColumn = l.ColumnNumber + utf8.RuneCountInString(l.Url)
+	}
+
+	location := SarifLocation{
+		PhysicalLocation: SarifPhysicalLocation{
+			ArtifactLocation: artifact,
+			Region:           region,
+		},
+	}
+	if l.Snippet != "" && l.SnippetStartLine > 0 {
+		location.PhysicalLocation.ContextRegion = &SarifRegion{
+			StartLine: l.SnippetStartLine,
+			EndLine:   l.SnippetStartLine + strings.Count(l.Snippet, "\n"),
+			Snippet:   &SarifMessage{Text: l.Snippet},
+		}
+	}
+
+	result := SarifResult{
+		RuleID:    sarifRules[ruleIndex].ID,
+		RuleIndex: ruleIndex,
+		Level:     resultLevel(l),
+		Locations: []SarifLocation{location},
+		Properties: map[string]interface{}{
+			"classification": l.Classification,
+			"affectsRuntime": l.AffectsRuntime,
+		},
+	}
+
+	if isExpanded(l.ExpandedURL) {
+		result.Message = SarifMessage{
+			Text: fmt.Sprintf("'%s' is a goo.gl short link that will stop working. Replace it with the url it redirects to: %s", l.Url, l.ExpandedURL),
+		}
+		if region.StartColumn > 0 {
+			result.Fixes = []SarifFix{{
+				Description: SarifMessage{Text: fmt.Sprintf("Replace '%s' with '%s'", l.Url, l.ExpandedURL)},
+				ArtifactChanges: []SarifArtifactChange{{
+					ArtifactLocation: artifact,
+					Replacements: []SarifReplacement{{
+						DeletedRegion:   region,
+						InsertedContent: SarifMessage{Text: l.ExpandedURL},
+					}},
+				}},
+			}}
+		}
+	} else {
+		result.Message = SarifMessage{
+			Text: fmt.Sprintf("'%s' is a goo.gl short link that will stop working. It could not be expanded (%s), so find where it pointed and replace it or remove it.", l.Url, strings.TrimPrefix(l.ExpandedURL, "ERROR: ")),
+		}
+	}
+
+	return result
+}
+
+// shortenerRuleIndex returns the index into sarifRules for the shortener of the link
+func shortenerRuleIndex(link string) int {
+	if strings.Contains(strings.ToLower(link), "goo.gl/forms/") {
+		return 1
+	}
+	return 0
+}
+
+// resultLevel downgrades links that don't affect runtime behaviour (comments, docs, test fixtures) to notes
+func resultLevel(l models.Link) string {
+	if l.Classification == "" || l.Classification == models.LinkUnclassified || models.LinkAffectsRuntime(l.Classification) {
+		return "warning"
+	}
+	return "note"
+}
+
+// isExpanded checks the parser managed to expand the link. Failures are saved as 'ERROR: <reason>'
+func isExpanded(expandedURL string) bool {
+	return expandedURL != "" && !strings.HasPrefix(expandedURL, "ERROR:")
+}
@@ -0,0 +1,98 @@
+package export
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRepoSarif(t *testing.T) {
+	repo := &models.RepoWithLinks{
+		Name:   "repo",
+		Author: "owner",
+		Forge:  models.ForgeGitHub,
+		GhUrl:  "https://github.com/owner/repo",
+		Links: []models.Link{
+			{
+				Url:              "https://goo.gl/abc",
+				ExpandedURL:      "https://example.com/page",
+				File:             "src/main.go",
+				LineNumber:       12,
+				ColumnNumber:     8,
+				Classification:   models.LinkInString,
+				AffectsRuntime:   true,
+				Snippet:          "func main() {\n\turl := \"https://goo.gl/abc\"\n}",
+				SnippetStartLine: 11,
+			},
+			{
+				Url:            "goo.gl/forms/xyz",
+				ExpandedURL:    "ERROR: unexpected status code 404 for https://goo.gl/forms/xyz",
+				File:           "README.md",
+				LineNumber:     3,
+				Classification: models.LinkInMarkdown,
+			},
+		},
+	}
+
+	log := NewRepoSarif(repo)
+
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "googl-bye", run.Tool.Driver.Name)
+	assert.Len(t, run.Tool.Driver.Rules, 2)
+	assert.Equal(t, "https://github.com/owner/repo", run.VersionControlProvenance[0].RepositoryURI)
+	assert.Len(t, run.Results, 2)
+
+	// Expanded link in code has a fix replacing the exact region of the link
+	code := run.Results[0]
+	assert.Equal(t, RuleGooGlLink, code.RuleID)
+	assert.Equal(t, 0, code.RuleIndex)
+	assert.Equal(t, "warning", code.Level)
+	assert.Contains(t, code.Message.Text, "https://example.com/page")
+	region := code.Locations[0].PhysicalLocation.Region
+	assert.Equal(t, SarifRegion{StartLine: 12, StartColumn: 8, EndColumn: 26}, region)
+	assert.Equal(t, "src/main.go", code.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, 11, code.Locations[0].PhysicalLocation.ContextRegion.StartLine)
+	assert.Equal(t, 13, code.Locations[0].PhysicalLocation.ContextRegion.EndLine)
+	assert.Len(t, code.Fixes, 1)
+	replacement := code.Fixes[0].ArtifactChanges[0].Replacements[0]
+	assert.Equal(t, region, replacement.DeletedRegion)
+	assert.Equal(t, "https://example.com/page", replacement.InsertedContent.Text)
+
+	// Forms link in docs, that failed to expand, is a note without a fix
+	docs := run.Results[1]
+	assert.Equal(t, RuleGooGlFormsLink, docs.RuleID)
+	assert.Equal(t, 1, docs.RuleIndex)
+	assert.Equal(t, "note", docs.Level)
+	assert.Contains(t, docs.Message.Text, "could not be expanded (unexpected status code 404")
+	assert.Empty(t, docs.Fixes)
+	assert.Equal(t, SarifRegion{StartLine: 3}, docs.Locations[0].PhysicalLocation.Region)
+	assert.Nil(t, docs.Locations[0].PhysicalLocation.ContextRegion)
+}
+
+func TestNewScanSarifEncodesEmptyResults(t *testing.T) {
+	log := NewScanSarif(&models.ScanReport{Name: "dir", Links: []models.Link{}})
+
+	b, err := json.Marshal(log)
+	assert.NoError(t, err)
+
+	// SARIF consumers expect results to be an array, even when there are no findings
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(b, &decoded))
+	run := decoded["runs"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{}, run["results"])
+	assert.Equal(t, "https://json.schemastore.org/sarif-2.1.0.json", decoded["$schema"])
+
+	// Columns count characters, and SRCROOT is left for consumers to resolve
+	assert.Equal(t, "unicodeCodePoints", run["columnKind"])
+	assert.NotContains(t, run, "originalUriBaseIds")
+}
+
+func TestArtifactURIEscapesPaths(t *testing.T) {
+	assert.Equal(t, "src/main.go", artifactURI("/src/main.go"))
+	assert.Equal(t, "docs/my%20notes/100%25%20done.md", artifactURI("docs/my notes/100% done.md"))
+	assert.Equal(t, "src/%23hash%3Fquery.md", artifactURI("src/#hash?query.md"))
+}
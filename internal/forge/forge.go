@@ -0,0 +1,93 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+// Forge is a code hosting service (GitHub, GitLab, Gitea etc.) that repositories can be
+// searched for, cloned from, linked to and have issues raised against.
+type Forge interface {
+	// Name is the forge identifier stored against repositories (see models.ForgeGitHub etc.)
+	Name() string
+	// SearchRepositories runs a search query against the forge, returning one page of results and the next page (0 if there are no more).
+	SearchRepositories(ctx context.Context, query string, page, perPage int) ([]models.RepositoryModel, int, error)
+	// GetRepository looks up a repository on the forge, returning nil if it doesn't exist or can't be seen with the forge's token.
+	// Private is set from the forge, so it can be trusted.
+	GetRepository(ctx context.Context, owner, name string) (*models.RepositoryModel, error)
+	// CloneURL is the https url used to clone the repository
+	CloneURL(owner, name string) string
+	// BlobURL is a link to a line of a file in the repository, as viewed on the forge
+	BlobURL(owner, name, branch, filePath string, lineNumber int) string
+	// CreateIssue raises an issue against the repository, returning a link to it
+	CreateIssue(ctx context.Context, owner, name string, issue Issue) (string, error)
+}
+
+// Issue is the content of an issue to raise against a repository
+type Issue struct {
+	Title  string
+	Body   string
+	Labels []string
+}
+
+// Registry holds the forges configured for this instance, keyed by name
+type Registry struct {
+	forges map[string]Forge
+}
+
+func NewRegistry(forges ...Forge) *Registry {
+	r := &Registry{forges: make(map[string]Forge)}
+	for _, f := range forges {
+		r.Register(f)
+	}
+	return r
+}
+
+// Register adds a forge to the registry, replacing any existing forge with the same name
+func (r *Registry) Register(f Forge) {
+	r.forges[f.Name()] = f
+}
+
+// Get returns the forge with the given name. An empty name is treated as GitHub.
+func (r *Registry) Get(name string) (Forge, error) {
+	if name == "" {
+		name = models.ForgeGitHub
+	}
+	f, ok := r.forges[name]
+	if !ok {
+		return nil, fmt.Errorf("forge '%s' is not configured", name)
+	}
+	return f, nil
+}
+
+// ForRepo returns the forge the repository is hosted on
+func (r *Registry) ForRepo(repo *models.RepositoryModel) (Forge, error) {
+	return r.Get(repo.ForgeName())
+}
+
+// Names returns the names of all configured forges
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.forges))
+	for name := range r.forges {
+		names = append(names, name)
+	}
+	return names
+}
+
+// isMarkdown checks if the file will be rendered by the forge, meaning we need to request the plain view to link to a line
+func isMarkdown(filePath string) bool {
+	return strings.HasSuffix(strings.ToLower(filePath), ".md")
+}
+
+// escapePath url encodes each segment of a file path, keeping the separators
+func escapePath(filePath string) string {
+	segments := strings.Split(filePath, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,153 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+// GiteaForge talks to the Gitea REST API (v1). Gitea is always self-hosted, so there is no default url.
+type GiteaForge struct {
+	baseURL string
+	api     apiClient
+}
+
+func NewGiteaForge(baseURL, token string) *GiteaForge {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	return &GiteaForge{
+		baseURL: baseURL,
+		api: newApiClient(baseURL+"/api/v1", func(req *http.Request) {
+			if token != "" {
+				req.Header.Set("Authorization", "token "+token)
+			}
+		}),
+	}
+}
+
+func (f *GiteaForge) Name() string {
+	return models.ForgeGitea
+}
+
+type giteaRepo struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	HtmlURL    string    `json:"html_url"`
+	CloneURL   string    `json:"clone_url"`
+	Language   string    `json:"language"`
+	StarsCount int       `json:"stars_count"`
+	ForksCount int       `json:"forks_count"`
+	Size       int       `json:"size"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Private    bool      `json:"private"`
+	Owner      struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+func (f *GiteaForge) SearchRepositories(ctx context.Context, query string, page, perPage int) ([]models.RepositoryModel, int, error) {
+	page = max(page, 1)
+
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("sort", "stars")
+	params.Set("order", "desc")
+	params.Set("page", strconv.Itoa(page))
+	params.Set("limit", strconv.Itoa(perPage))
+
+	var result struct {
+		Ok   bool        `json:"ok"`
+		Data []giteaRepo `json:"data"`
+	}
+	resp, err := f.api.do(ctx, http.MethodGet, "/repos/search?"+params.Encode(), nil, &result)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error searching gitea repos: %v", err)
+	}
+
+	repos := make([]models.RepositoryModel, 0, len(result.Data))
+	for _, r := range result.Data {
+		repos = append(repos, f.toRepo(r))
+	}
+
+	// Gitea reports the total result count rather than the next page
+	nextPage := 0
+	total, err := strconv.Atoi(resp.Header.Get("X-Total-Count"))
+	if err == nil && page*perPage < total {
+		nextPage = page + 1
+	}
+	return repos, nextPage, nil
+}
+
+func (f *GiteaForge) GetRepository(ctx context.Context, owner, name string) (*models.RepositoryModel, error) {
+	var r giteaRepo
+	path := fmt.Sprintf("/repos/%s/%s", url.PathEscape(owner), url.PathEscape(name))
+	resp, err := f.api.do(ctx, http.MethodGet, path, nil, &r)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting gitea repo: %v", err)
+	}
+
+	repo := f.toRepo(r)
+	return &repo, nil
+}
+
+// toRepo converts a gitea repo to our model
+func (f *GiteaForge) toRepo(r giteaRepo) models.RepositoryModel {
+	return models.RepositoryModel{
+		Name:     r.Name,
+		Author:   r.Owner.Login,
+		Forge:    models.ForgeGitea,
+		GhUrl:    r.HtmlURL,
+		CloneUrl: r.CloneURL,
+		ApiUrl:   fmt.Sprintf("%s/repos/%s/%s", f.api.baseURL, r.Owner.Login, r.Name),
+		Language: r.Language,
+		Stars:    r.StarsCount,
+		Forks:    r.ForksCount,
+		Size:     r.Size,
+		LastPush: r.UpdatedAt,
+		Private:  r.Private,
+	}
+}
+
+func (f *GiteaForge) CloneURL(owner, name string) string {
+	return fmt.Sprintf("%s/%s/%s.git", f.baseURL, owner, name)
+}
+
+func (f *GiteaForge) BlobURL(owner, name, branch, filePath string, lineNumber int) string {
+	blobUrl := fmt.Sprintf("%s/%s/%s/src/branch/%s/%s", f.baseURL, owner, name, branch, escapePath(filePath))
+
+	if isMarkdown(filePath) {
+		blobUrl += "?display=source"
+	}
+
+	if lineNumber > 0 {
+		blobUrl += fmt.Sprintf("#L%d", lineNumber)
+	}
+
+	return blobUrl
+}
+
+func (f *GiteaForge) CreateIssue(ctx context.Context, owner, name string, issue Issue) (string, error) {
+	// Gitea only accepts label ids when creating issues, so labels are not supported here
+	body := map[string]interface{}{
+		"title": issue.Title,
+		"body":  issue.Body,
+	}
+
+	var created struct {
+		HtmlURL string `json:"html_url"`
+	}
+	path := fmt.Sprintf("/repos/%s/%s/issues", url.PathEscape(owner), url.PathEscape(name))
+	if _, err := f.api.do(ctx, http.MethodPost, path, body, &created); err != nil {
+		return "", fmt.Errorf("error creating gitea issue: %v", err)
+	}
+	return created.HtmlURL, nil
+}
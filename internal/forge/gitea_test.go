@@ -0,0 +1,119 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGiteaForgeSearchRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/repos/search", r.URL.Path)
+		assert.Equal(t, "goo.gl", r.URL.Query().Get("q"))
+		assert.Equal(t, "1", r.URL.Query().Get("page"))
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+
+		w.Header().Set("X-Total-Count", "3")
+		w.Write([]byte(`{"ok": true, "data": [{
+			"id": 7,
+			"name": "repo",
+			"html_url": "https://gitea.example.com/owner/repo",
+			"clone_url": "https://gitea.example.com/owner/repo.git",
+			"language": "Go",
+			"stars_count": 5,
+			"forks_count": 1,
+			"size": 120,
+			"updated_at": "2024-07-01T10:00:00Z",
+			"owner": {"login": "owner"}
+		}, {
+			"id": 8,
+			"name": "other",
+			"owner": {"login": "owner"}
+		}]}`))
+	}))
+	defer server.Close()
+
+	f := NewGiteaForge(server.URL, "secret")
+	repos, nextPage, err := f.SearchRepositories(context.Background(), "goo.gl", 0, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nextPage)
+	assert.Len(t, repos, 2)
+	assert.Equal(t, "repo", repos[0].Name)
+	assert.Equal(t, "owner", repos[0].Author)
+	assert.Equal(t, models.ForgeGitea, repos[0].Forge)
+	assert.Equal(t, "Go", repos[0].Language)
+	assert.Equal(t, "https://gitea.example.com/owner/repo.git", repos[0].CloneUrl)
+	assert.Equal(t, server.URL+"/api/v1/repos/owner/repo", repos[0].ApiUrl)
+}
+
+func TestGiteaForgeLastPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Total-Count", "3")
+		w.Write([]byte(`{"ok": true, "data": [{"id": 9, "name": "last", "owner": {"login": "owner"}}]}`))
+	}))
+	defer server.Close()
+
+	f := NewGiteaForge(server.URL, "")
+	_, nextPage, err := f.SearchRepositories(context.Background(), "goo.gl", 2, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, nextPage)
+}
+
+func TestGiteaForgeGetRepository(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/repos/owner/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "/api/v1/repos/owner/repo", r.URL.Path)
+		w.Write([]byte(`{"id": 7, "name": "repo", "private": true, "owner": {"login": "owner"}}`))
+	}))
+	defer server.Close()
+
+	f := NewGiteaForge(server.URL, "secret")
+	repo, err := f.GetRepository(context.Background(), "owner", "repo")
+	assert.NoError(t, err)
+	assert.Equal(t, "repo", repo.Name)
+	assert.True(t, repo.Private)
+
+	repo, err = f.GetRepository(context.Background(), "owner", "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, repo)
+}
+
+func TestGiteaForgeCreateIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/repos/owner/repo/issues", r.URL.Path)
+
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "title", body["title"])
+		assert.Equal(t, "body", body["body"])
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"html_url": "https://gitea.example.com/owner/repo/issues/3"}`))
+	}))
+	defer server.Close()
+
+	f := NewGiteaForge(server.URL, "")
+	url, err := f.CreateIssue(context.Background(), "owner", "repo", Issue{Title: "title", Body: "body"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://gitea.example.com/owner/repo/issues/3", url)
+}
+
+func TestGiteaForgeURLs(t *testing.T) {
+	f := NewGiteaForge("https://gitea.example.com", "")
+
+	assert.Equal(t, "https://gitea.example.com/owner/repo.git", f.CloneURL("owner", "repo"))
+	assert.Equal(t, "https://gitea.example.com/owner/repo/src/branch/main/main.go#L3", f.BlobURL("owner", "repo", "main", "main.go", 3))
+	assert.Equal(t, "https://gitea.example.com/owner/repo/src/branch/main/docs/README.md?display=source#L1", f.BlobURL("owner", "repo", "main", "docs/README.md", 1))
+}
@@ -0,0 +1,119 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+const githubWebURL = "https://github.com"
+
+type GithubForge struct {
+	client common.GithubClientI
+	webURL string
+}
+
+func NewGithubForge(client common.GithubClientI) *GithubForge {
+	return &GithubForge{
+		client: client,
+		webURL: githubWebURL,
+	}
+}
+
+func (f *GithubForge) Name() string {
+	return models.ForgeGitHub
+}
+
+func (f *GithubForge) SearchRepositories(ctx context.Context, query string, page, perPage int) ([]models.RepositoryModel, int, error) {
+	ghRepos, resp, err := f.client.SearchRepositories(ctx, query, &github.SearchOptions{
+		ListOptions: github.ListOptions{Page: page, PerPage: perPage},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	repos := make([]models.RepositoryModel, 0, len(ghRepos))
+	for _, r := range ghRepos {
+		repos = append(repos, f.toRepo(r))
+	}
+
+	nextPage := 0
+	if resp != nil {
+		nextPage = resp.NextPage
+	}
+	return repos, nextPage, nil
+}
+
+func (f *GithubForge) GetRepository(ctx context.Context, owner, name string) (*models.RepositoryModel, error) {
+	r, resp, err := f.client.GetRepository(ctx, owner, name)
+	if err != nil {
+		// Private repos the token can't see are reported as not found
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	repo := f.toRepo(r)
+	return &repo, nil
+}
+
+// toRepo converts a github repository to our model
+func (f *GithubForge) toRepo(r *github.Repository) models.RepositoryModel {
+	owner := r.GetOwner().GetLogin()
+	return models.RepositoryModel{
+		Name:     r.GetName(),
+		Author:   owner,
+		Forge:    models.ForgeGitHub,
+		GhUrl:    fmt.Sprintf("%s/%s/%s", f.webURL, owner, r.GetName()),
+		CloneUrl: f.CloneURL(owner, r.GetName()),
+		ApiUrl:   r.GetURL(),
+		Language: r.GetLanguage(),
+		Stars:    r.GetStargazersCount(),
+		Forks:    r.GetForksCount(),
+		Size:     r.GetSize(),
+		LastPush: r.GetPushedAt().Time,
+		Private:  r.GetPrivate(),
+	}
+}
+
+func (f *GithubForge) CloneURL(owner, name string) string {
+	return fmt.Sprintf("%s/%s/%s.git", f.webURL, owner, name)
+}
+
+func (f *GithubForge) BlobURL(owner, name, branch, filePath string, lineNumber int) string {
+	blobUrl := fmt.Sprintf("%s/%s/%s/blob/%s/%s", f.webURL, owner, name, branch, escapePath(filePath))
+
+	// Markdown files are rendered by default, ?plain=1 is needed to link to a line
+	if isMarkdown(filePath) {
+		blobUrl += "?plain=1"
+	}
+
+	if lineNumber > 0 {
+		blobUrl += fmt.Sprintf("#L%d", lineNumber)
+	}
+
+	return blobUrl
+}
+
+func (f *GithubForge) CreateIssue(ctx context.Context, owner, name string, issue Issue) (string, error) {
+	req := &github.IssueRequest{
+		Title: github.String(issue.Title),
+		Body:  github.String(issue.Body),
+	}
+	if len(issue.Labels) > 0 {
+		labels := append([]string{}, issue.Labels...)
+		req.Labels = &labels
+	}
+
+	created, _, err := f.client.CreateIssue(ctx, owner, name, req)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(created.GetHTMLURL()), nil
+}
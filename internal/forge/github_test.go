@@ -0,0 +1,75 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/jwtly10/googl-bye/internal/mock"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubForgeBlobURL(t *testing.T) {
+	f := NewGithubForge(nil)
+
+	assert.Equal(t, "https://github.com/jwtly10/googl-bye-test/blob/main/README.md?plain=1#L5", f.BlobURL("jwtly10", "googl-bye-test", "main", "README.md", 5))
+	assert.Equal(t, "https://github.com/jwtly10/googl-bye-test/blob/main/cmd/my%20app/main.go#L4", f.BlobURL("jwtly10", "googl-bye-test", "main", "cmd/my app/main.go", 4))
+	assert.Equal(t, "https://github.com/jwtly10/googl-bye-test.git", f.CloneURL("jwtly10", "googl-bye-test"))
+}
+
+func TestGithubForgeCreateIssue(t *testing.T) {
+	var gotOwner, gotRepo string
+	var gotIssue *github.IssueRequest
+	f := NewGithubForge(&mock.MockGithubClient{
+		MockCreateIssue: func(ctx context.Context, owner, repo string, issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+			gotOwner, gotRepo, gotIssue = owner, repo, issue
+			return &github.Issue{HTMLURL: github.String("https://github.com/owner/repo/issues/1")}, nil, nil
+		},
+	})
+
+	url, err := f.CreateIssue(context.Background(), "owner", "repo", Issue{Title: "title", Body: "body", Labels: []string{"links"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://github.com/owner/repo/issues/1", url)
+	assert.Equal(t, "owner", gotOwner)
+	assert.Equal(t, "repo", gotRepo)
+	assert.Equal(t, "title", gotIssue.GetTitle())
+	assert.Equal(t, []string{"links"}, *gotIssue.Labels)
+}
+
+func TestGithubForgeGetRepository(t *testing.T) {
+	f := NewGithubForge(&mock.MockGithubClient{
+		MockGetRepository: func(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error) {
+			if repo == "missing" {
+				return nil, &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}, errors.New("not found")
+			}
+			return &github.Repository{Name: github.String(repo), Owner: &github.User{Login: github.String(owner)}, Private: github.Bool(true)}, nil, nil
+		},
+	})
+
+	repo, err := f.GetRepository(context.Background(), "owner", "repo")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://github.com/owner/repo.git", repo.CloneUrl)
+	assert.True(t, repo.Private)
+
+	repo, err = f.GetRepository(context.Background(), "owner", "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, repo)
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(NewGithubForge(nil), NewGitlabForge("", ""))
+
+	f, err := r.ForRepo(&models.RepositoryModel{})
+	assert.NoError(t, err)
+	assert.Equal(t, models.ForgeGitHub, f.Name())
+
+	f, err = r.Get(models.ForgeGitLab)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ForgeGitLab, f.Name())
+
+	_, err = r.Get(models.ForgeGitea)
+	assert.Error(t, err)
+}
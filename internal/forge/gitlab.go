@@ -0,0 +1,144 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+const gitlabDefaultURL = "https://gitlab.com"
+
+// GitlabForge talks to the GitLab REST API (v4), either gitlab.com or a self-hosted instance
+type GitlabForge struct {
+	baseURL string
+	api     apiClient
+}
+
+func NewGitlabForge(baseURL, token string) *GitlabForge {
+	if baseURL == "" {
+		baseURL = gitlabDefaultURL
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	return &GitlabForge{
+		baseURL: baseURL,
+		api: newApiClient(baseURL+"/api/v4", func(req *http.Request) {
+			if token != "" {
+				req.Header.Set("PRIVATE-TOKEN", token)
+			}
+		}),
+	}
+}
+
+func (f *GitlabForge) Name() string {
+	return models.ForgeGitLab
+}
+
+type gitlabProject struct {
+	ID             int       `json:"id"`
+	Path           string    `json:"path"`
+	WebURL         string    `json:"web_url"`
+	HttpURLToRepo  string    `json:"http_url_to_repo"`
+	StarCount      int       `json:"star_count"`
+	ForksCount     int       `json:"forks_count"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	Visibility     string    `json:"visibility"`
+	Namespace      struct {
+		FullPath string `json:"full_path"`
+	} `json:"namespace"`
+}
+
+func (f *GitlabForge) SearchRepositories(ctx context.Context, query string, page, perPage int) ([]models.RepositoryModel, int, error) {
+	params := url.Values{}
+	params.Set("search", query)
+	params.Set("order_by", "star_count")
+	params.Set("page", strconv.Itoa(max(page, 1)))
+	params.Set("per_page", strconv.Itoa(perPage))
+
+	var projects []gitlabProject
+	resp, err := f.api.do(ctx, http.MethodGet, "/projects?"+params.Encode(), nil, &projects)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error searching gitlab projects: %v", err)
+	}
+
+	repos := make([]models.RepositoryModel, 0, len(projects))
+	for _, p := range projects {
+		repos = append(repos, f.toRepo(p))
+	}
+
+	nextPage, _ := strconv.Atoi(resp.Header.Get("X-Next-Page"))
+	return repos, nextPage, nil
+}
+
+func (f *GitlabForge) GetRepository(ctx context.Context, owner, name string) (*models.RepositoryModel, error) {
+	var project gitlabProject
+	resp, err := f.api.do(ctx, http.MethodGet, "/projects/"+url.PathEscape(owner+"/"+name), nil, &project)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting gitlab project: %v", err)
+	}
+
+	repo := f.toRepo(project)
+	return &repo, nil
+}
+
+// toRepo converts a gitlab project to our model. Internal projects are only visible to signed in users, so are treated as private.
+func (f *GitlabForge) toRepo(p gitlabProject) models.RepositoryModel {
+	return models.RepositoryModel{
+		Name:     p.Path,
+		Author:   p.Namespace.FullPath,
+		Forge:    models.ForgeGitLab,
+		GhUrl:    p.WebURL,
+		CloneUrl: p.HttpURLToRepo,
+		ApiUrl:   fmt.Sprintf("%s/projects/%d", f.api.baseURL, p.ID),
+		Stars:    p.StarCount,
+		Forks:    p.ForksCount,
+		LastPush: p.LastActivityAt,
+		Private:  p.Visibility != "" && p.Visibility != "public",
+	}
+}
+
+func (f *GitlabForge) CloneURL(owner, name string) string {
+	return fmt.Sprintf("%s/%s/%s.git", f.baseURL, owner, name)
+}
+
+func (f *GitlabForge) BlobURL(owner, name, branch, filePath string, lineNumber int) string {
+	blobUrl := fmt.Sprintf("%s/%s/%s/-/blob/%s/%s", f.baseURL, owner, name, branch, escapePath(filePath))
+
+	if isMarkdown(filePath) {
+		blobUrl += "?plain=1"
+	}
+
+	if lineNumber > 0 {
+		blobUrl += fmt.Sprintf("#L%d", lineNumber)
+	}
+
+	return blobUrl
+}
+
+func (f *GitlabForge) CreateIssue(ctx context.Context, owner, name string, issue Issue) (string, error) {
+	body := map[string]interface{}{
+		"title":       issue.Title,
+		"description": issue.Body,
+	}
+	if len(issue.Labels) > 0 {
+		body["labels"] = strings.Join(issue.Labels, ",")
+	}
+
+	var created struct {
+		WebURL string `json:"web_url"`
+	}
+	projectPath := url.PathEscape(owner + "/" + name)
+	if _, err := f.api.do(ctx, http.MethodPost, "/projects/"+projectPath+"/issues", body, &created); err != nil {
+		return "", fmt.Errorf("error creating gitlab issue: %v", err)
+	}
+	return created.WebURL, nil
+}
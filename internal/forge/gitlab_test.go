@@ -0,0 +1,119 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitlabForgeSearchRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v4/projects", r.URL.Path)
+		assert.Equal(t, "goo.gl", r.URL.Query().Get("search"))
+		assert.Equal(t, "2", r.URL.Query().Get("page"))
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+
+		w.Header().Set("X-Next-Page", "3")
+		w.Write([]byte(`[{
+			"id": 42,
+			"path": "project",
+			"web_url": "https://gitlab.example.com/group/sub/project",
+			"http_url_to_repo": "https://gitlab.example.com/group/sub/project.git",
+			"star_count": 12,
+			"forks_count": 3,
+			"last_activity_at": "2024-07-01T10:00:00Z",
+			"namespace": {"full_path": "group/sub"}
+		}]`))
+	}))
+	defer server.Close()
+
+	f := NewGitlabForge(server.URL, "secret")
+	repos, nextPage, err := f.SearchRepositories(context.Background(), "goo.gl", 2, 50)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, nextPage)
+	assert.Len(t, repos, 1)
+	assert.Equal(t, "project", repos[0].Name)
+	assert.Equal(t, "group/sub", repos[0].Author)
+	assert.Equal(t, models.ForgeGitLab, repos[0].Forge)
+	assert.Equal(t, "https://gitlab.example.com/group/sub/project.git", repos[0].CloneUrl)
+	assert.Equal(t, server.URL+"/api/v4/projects/42", repos[0].ApiUrl)
+	assert.Equal(t, 12, repos[0].Stars)
+}
+
+func TestGitlabForgeGetRepository(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fpublic":
+			w.Write([]byte(`{"id": 1, "path": "public", "visibility": "public", "namespace": {"full_path": "group"}}`))
+		case "/api/v4/projects/group%2Finternal":
+			w.Write([]byte(`{"id": 2, "path": "internal", "visibility": "internal", "namespace": {"full_path": "group"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	f := NewGitlabForge(server.URL, "secret")
+	repo, err := f.GetRepository(context.Background(), "group", "public")
+	assert.NoError(t, err)
+	assert.Equal(t, "group", repo.Author)
+	assert.False(t, repo.Private)
+
+	repo, err = f.GetRepository(context.Background(), "group", "internal")
+	assert.NoError(t, err)
+	assert.True(t, repo.Private)
+
+	repo, err = f.GetRepository(context.Background(), "group", "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, repo)
+}
+
+func TestGitlabForgeCreateIssue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v4/projects/group%2Fproject/issues", r.URL.EscapedPath())
+
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "title", body["title"])
+		assert.Equal(t, "body", body["description"])
+		assert.Equal(t, "a,b", body["labels"])
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"web_url": "https://gitlab.example.com/group/project/-/issues/1"}`))
+	}))
+	defer server.Close()
+
+	f := NewGitlabForge(server.URL, "")
+	url, err := f.CreateIssue(context.Background(), "group", "project", Issue{Title: "title", Body: "body", Labels: []string{"a", "b"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://gitlab.example.com/group/project/-/issues/1", url)
+}
+
+func TestGitlabForgeURLs(t *testing.T) {
+	f := NewGitlabForge("https://gitlab.example.com/", "")
+
+	assert.Equal(t, "https://gitlab.example.com/group/project.git", f.CloneURL("group", "project"))
+	assert.Equal(t, "https://gitlab.example.com/group/project/-/blob/main/src/app.js#L10", f.BlobURL("group", "project", "main", "src/app.js", 10))
+	assert.Equal(t, "https://gitlab.example.com/group/project/-/blob/main/README.md?plain=1#L1", f.BlobURL("group", "project", "main", "README.md", 1))
+}
+
+func TestGitlabForgeErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"401 Unauthorized"}`))
+	}))
+	defer server.Close()
+
+	f := NewGitlabForge(server.URL, "bad")
+	_, _, err := f.SearchRepositories(context.Background(), "goo.gl", 1, 50)
+
+	assert.ErrorContains(t, err, "401")
+}
@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMTPSender(t *testing.T) {
+	server := test.NewFakeSMTPServer(t)
+
+	sender := NewSMTPSender(server.Host(), server.Port(), "", "", "googl-bye@example.com")
+	sender.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	err := sender.Send(Message{
+		To:      []string{"maintainer@example.com"},
+		Subject: "New goo.gl links",
+		Body:    "Found 1 link\nhttps://goo.gl/abc\n",
+	})
+	assert.NoError(t, err)
+
+	emails := server.Emails()
+	assert.Len(t, emails, 1)
+	assert.Equal(t, "googl-bye@example.com", emails[0].From)
+	assert.Equal(t, []string{"maintainer@example.com"}, emails[0].To)
+	assert.Contains(t, emails[0].Data, "Subject: New goo.gl links\r\n")
+	assert.Contains(t, emails[0].Data, "Date: Tue, 02 Jan 2024 03:04:05 +0000\r\n")
+	assert.True(t, strings.HasSuffix(emails[0].Data, "\r\n\r\nFound 1 link\r\nhttps://goo.gl/abc\r\n"), emails[0].Data)
+}
+
+func TestSMTPSenderWithoutRecipients(t *testing.T) {
+	server := test.NewFakeSMTPServer(t)
+
+	err := NewSMTPSender(server.Host(), server.Port(), "", "", "googl-bye@example.com").Send(Message{Subject: "No one"})
+	assert.Error(t, err)
+	assert.Empty(t, server.Emails())
+}
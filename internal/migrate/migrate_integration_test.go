@@ -0,0 +1,201 @@
+package migrate_test
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jwtly10/googl-bye/db"
+	"github.com/jwtly10/googl-bye/internal/migrate"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/test"
+)
+
+func TestMigrator_Integration(t *testing.T) {
+	// The test database is already migrated to the latest version
+	container, database, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+
+	ctx := context.Background()
+	migrator, err := migrate.NewMigrator(database, db.Migrations())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Up does nothing when migrations are applied", func(t *testing.T) {
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if len(applied) != 0 {
+			t.Errorf("expected no migrations to be applied but got %v", applied)
+		}
+	})
+
+	t.Run("Down and up again", func(t *testing.T) {
+		rolledBack, err := migrator.Down(ctx, 1)
+		if err != nil {
+			t.Fatalf("expected no error when rolling back but got %v", err)
+		}
+		if len(rolledBack) != 1 {
+			t.Fatalf("expected 1 migration to be rolled back but got %d", len(rolledBack))
+		}
+
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			t.Fatalf("expected no error when getting status but got %v", err)
+		}
+		last := statuses[len(statuses)-1]
+		if last.Version != rolledBack[0].Version || last.AppliedAt != nil {
+			t.Errorf("expected migration %d to be pending but got %+v", rolledBack[0].Version, last)
+		}
+
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			t.Fatalf("expected no error when migrating up but got %v", err)
+		}
+		if len(applied) != 1 || applied[0].Version != rolledBack[0].Version {
+			t.Errorf("expected migration %d to be applied again but got %v", rolledBack[0].Version, applied)
+		}
+	})
+
+	t.Run("Failed migrations are rolled back", func(t *testing.T) {
+		broken, err := migrate.NewMigrator(database, fstest.MapFS{
+			"9000_broken.up.sql": {Data: []byte("CREATE TABLE broken_tb (id INT); SELECT * FROM missing_tb;")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := broken.Up(ctx); err == nil {
+			t.Fatal("expected an error when applying a broken migration")
+		}
+
+		var exists bool
+		if err := database.QueryRow(`SELECT to_regclass('public.broken_tb') IS NOT NULL`).Scan(&exists); err != nil {
+			t.Fatal(err)
+		}
+		if exists {
+			t.Error("expected the broken migration's table to be rolled back")
+		}
+
+		// Migrations applied to the database but not known to the migrator are reported as missing
+		statuses, err := broken.Status(ctx)
+		if err != nil {
+			t.Fatalf("expected no error when getting status but got %v", err)
+		}
+		for _, s := range statuses {
+			if s.Version == 9000 && s.AppliedAt != nil {
+				t.Errorf("expected the broken migration not to be applied but got %+v", s)
+			}
+			if s.Version != 9000 && !s.Missing {
+				t.Errorf("expected migration %d to be missing from the broken migrator but got %+v", s.Version, s)
+			}
+		}
+	})
+
+	t.Run("Concurrent migrations wait for each other", func(t *testing.T) {
+		concurrent, err := migrate.NewMigrator(database, fstest.MapFS{
+			"9001_concurrent.up.sql": {Data: []byte("CREATE TABLE concurrent_tb (id INT);")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		results := make([]int, 5)
+		errs := make([]error, 5)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				applied, err := concurrent.Up(ctx)
+				results[i], errs[i] = len(applied), err
+			}(i)
+		}
+		wg.Wait()
+
+		total := 0
+		for i := range results {
+			if errs[i] != nil {
+				t.Errorf("expected no error but got %v", errs[i])
+			}
+			total += results[i]
+		}
+		if total != 1 {
+			t.Errorf("expected the migration to be applied once but it was applied %d times", total)
+		}
+	})
+}
+
+func TestMigrateFromInitSQL_Integration(t *testing.T) {
+	container, database, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+		SkipMigrations:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+
+	// A database created by db/init.sql, before migrations, with a parsed repo
+	initSQL, err := os.ReadFile("testdata/init.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec(string(initSQL)); err != nil {
+		t.Fatalf("expected no error when running init.sql but got %v", err)
+	}
+	_, err = database.Exec(`
+		INSERT INTO repository_tb (name, author, state, api_url, gh_url, clone_url)
+		VALUES ('old', 'jwtly10', 'COMPLETED', 'https://api.github.com/repos/jwtly10/old', 'https://github.com/jwtly10/old', 'https://github.com/jwtly10/old.git');
+		INSERT INTO parser_links_tb (repo_id, url, file, line_number, path)
+		SELECT id, 'https://goo.gl/abc', 'README.md', 1, 'README.md' FROM repository_tb WHERE name = 'old';`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrator, err := migrate.NewMigrator(database, db.Migrations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("expected no error when migrating but got %v", err)
+	}
+
+	repoRepo := repository.NewRepoRepository(database)
+	repoLinkRepo := repository.NewRepoLinkRepository(database)
+
+	t.Run("Existing repos and links are kept", func(t *testing.T) {
+		repos, err := repoLinkRepo.GetRepositoryWithLinksForUser("jwtly10", repository.NoViewer)
+		if err != nil {
+			t.Fatalf("expected no error when getting repos but got %v", err)
+		}
+		if len(repos) != 1 || repos[0].Forge != "github" || repos[0].Visibility != "public" || len(repos[0].Links) != 1 {
+			t.Errorf("expected the old repo with its link but got %+v", repos)
+		}
+	})
+
+	t.Run("Repos are unique per forge", func(t *testing.T) {
+		repo := &models.RepositoryModel{Name: "old", Author: "jwtly10", Forge: "gitlab", CloneUrl: "https://gitlab.com/jwtly10/old.git"}
+		if err := repoRepo.CreateRepo(repo); err != nil {
+			t.Fatalf("expected no error when creating the same repo on another forge but got %v", err)
+		}
+		if repo.ID == 0 {
+			t.Error("expected the gitlab repo to be created")
+		}
+
+		// Saving a tracked repo again relies on the (forge, name, author) constraint
+		again := &models.RepositoryModel{Name: "old", Author: "jwtly10", Forge: "github", CloneUrl: "https://github.com/jwtly10/old.git"}
+		if err := repoRepo.CreateRepos([]*models.RepositoryModel{again}); err != nil {
+			t.Errorf("expected no error when saving a tracked repo again but got %v", err)
+		}
+	})
+}
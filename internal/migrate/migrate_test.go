@@ -0,0 +1,74 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/jwtly10/googl-bye/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0010_add_index.up.sql":    {Data: []byte("CREATE INDEX a ON b (c);")},
+		"0002_add_column.up.sql":   {Data: []byte("ALTER TABLE b ADD COLUMN c TEXT;")},
+		"0002_add_column.down.sql": {Data: []byte("ALTER TABLE b DROP COLUMN c;")},
+		"0001_init.up.sql":         {Data: []byte("CREATE TABLE b (id INT);")},
+		"0001_init.down.sql":       {Data: []byte("DROP TABLE b;")},
+	}
+
+	migrations, err := Load(fsys)
+	assert.NoError(t, err)
+	assert.Len(t, migrations, 3)
+
+	// Migrations are ordered by version, not file name
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "add_column", migrations[1].Name)
+	assert.Equal(t, "ALTER TABLE b DROP COLUMN c;", migrations[1].Down)
+	assert.Equal(t, int64(10), migrations[2].Version)
+	assert.Empty(t, migrations[2].Down)
+}
+
+func TestLoadInvalidMigrations(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "invalid file name",
+			fsys: fstest.MapFS{"init.sql": {Data: []byte("CREATE TABLE b (id INT);")}},
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{
+				"0001_init.up.sql":  {Data: []byte("CREATE TABLE b (id INT);")},
+				"0001_other.up.sql": {Data: []byte("CREATE TABLE c (id INT);")},
+			},
+		},
+		{
+			name: "down without up",
+			fsys: fstest.MapFS{"0001_init.down.sql": {Data: []byte("DROP TABLE b;")}},
+		},
+		{
+			name: "version zero",
+			fsys: fstest.MapFS{"0000_init.up.sql": {Data: []byte("CREATE TABLE b (id INT);")}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Load(db.Migrations())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	for _, m := range migrations {
+		assert.NotEmpty(t, m.Down, "migration %d_%s should have a down file", m.Version, m.Name)
+	}
+}
@@ -7,14 +7,23 @@ import (
 
 type MockGithubClient struct {
 	MockSearchRepositories func(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.Repository, *github.Response, error)
+	MockSearchCode         func(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.CodeResult, *github.Response, error)
 	MockCheckRateLimit     func(ctx context.Context) (*github.RateLimits, error)
 	MockSearchForUser      func(ctx context.Context, username string) ([]*github.User, *github.Response, error)
+	MockListUserRepos      func(ctx context.Context, user string, opts *github.RepositoryListOptions) ([]*github.Repository, *github.Response, error)
+	MockListOrgRepos       func(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)
+	MockCreateIssue        func(ctx context.Context, owner, repo string, issue *github.IssueRequest) (*github.Issue, *github.Response, error)
+	MockGetRepository      func(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error)
 }
 
 func (m *MockGithubClient) SearchRepositories(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.Repository, *github.Response, error) {
 	return m.MockSearchRepositories(ctx, query, opts)
 }
 
+func (m *MockGithubClient) SearchCode(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.CodeResult, *github.Response, error) {
+	return m.MockSearchCode(ctx, query, opts)
+}
+
 func (m *MockGithubClient) CheckRateLimit(ctx context.Context) (*github.RateLimits, error) {
 	return m.MockCheckRateLimit(ctx)
 }
@@ -22,3 +31,19 @@ func (m *MockGithubClient) CheckRateLimit(ctx context.Context) (*github.RateLimi
 func (m *MockGithubClient) SearchForUser(ctx context.Context, username string) ([]*github.User, *github.Response, error) {
 	return m.MockSearchForUser(ctx, username)
 }
+
+func (m *MockGithubClient) ListUserRepositories(ctx context.Context, user string, opts *github.RepositoryListOptions) ([]*github.Repository, *github.Response, error) {
+	return m.MockListUserRepos(ctx, user, opts)
+}
+
+func (m *MockGithubClient) ListOrgRepositories(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error) {
+	return m.MockListOrgRepos(ctx, org, opts)
+}
+
+func (m *MockGithubClient) CreateIssue(ctx context.Context, owner, repo string, issue *github.IssueRequest) (*github.Issue, *github.Response, error) {
+	return m.MockCreateIssue(ctx, owner, repo, issue)
+}
+
+func (m *MockGithubClient) GetRepository(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error) {
+	return m.MockGetRepository(ctx, owner, repo)
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+const (
+	// ScopeRead allows reading repos, links and stats
+	ScopeRead = "read"
+	// ScopeSearch allows searching forges and scanning repos
+	ScopeSearch = "search"
+	// ScopeSave allows saving repos to be parsed
+	ScopeSave = "save"
+	// ScopeManage allows managing the user's teams, API keys and outbound webhooks, and raising issues against their repos
+	ScopeManage = "manage"
+)
+
+// ApiKeyScopes are the valid scopes of an API key
+var ApiKeyScopes = []string{ScopeRead, ScopeSearch, ScopeSave, ScopeManage}
+
+// ApiKeyModel is a key for calling the API without a browser session. Only a hash of the key is stored.
+type ApiKeyModel struct {
+	Model
+	UserID int    `db:"user_id" json:"userId"`
+	Name   string `db:"name" json:"name"`
+	// Prefix is the start of the key, so users can tell their keys apart
+	Prefix     string     `db:"prefix" json:"prefix"`
+	KeyHash    string     `db:"key_hash" json:"-"`
+	Scopes     []string   `db:"scopes" json:"scopes"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"lastUsedAt"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revokedAt"`
+}
+
+// HasScope checks if the key has a scope
+func (k *ApiKeyModel) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatedApiKey is a new API key, the only time the key itself is returned
+type CreatedApiKey struct {
+	ApiKeyModel
+	Key string `json:"key"`
+}
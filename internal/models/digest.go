@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// DigestSubscriptionModel is an email address that is sent a periodic digest of new links found in an author's repos
+type DigestSubscriptionModel struct {
+	Model
+	Email  string `db:"email" json:"email"`
+	Author string `db:"author" json:"author"`
+	// UserID is the user who subscribed. Digests only include repos they can see.
+	UserID int `db:"user_id" json:"userId"`
+	// UnsubscribeToken is included in every digest, so the recipient can unsubscribe without signing in
+	UnsubscribeToken string `db:"unsubscribe_token" json:"-"`
+	// ConfirmToken is emailed to the address when subscribing. Digests are only sent once it is confirmed.
+	ConfirmToken string `db:"confirm_token" json:"-"`
+	// ConfirmedAt is when the address confirmed the subscription, nil until then
+	ConfirmedAt *time.Time `db:"confirmed_at" json:"confirmedAt"`
+	// LastDigestAt is when the newest link in the last digest was found. Only links found after it are sent.
+	LastDigestAt time.Time `db:"last_digest_at" json:"lastDigestAt"`
+}
+
+// DigestSentLink is a link sent to a subscription. Links are found again when their repo is parsed again,
+// so they are matched by where they are rather than by id.
+type DigestSentLink struct {
+	RepoID     int
+	Url        string
+	File       string
+	LineNumber int
+}
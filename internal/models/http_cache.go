@@ -0,0 +1,25 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// HttpCacheModel is a cached response to a GET request, revalidated using its ETag or Last-Modified headers
+type HttpCacheModel struct {
+	Model
+	// Key identifies the request the response is for
+	Key          string      `db:"key" json:"key"`
+	Url          string      `db:"url" json:"url"`
+	ETag         string      `db:"etag" json:"etag"`
+	LastModified string      `db:"last_modified" json:"lastModified"`
+	StatusCode   int         `db:"status_code" json:"statusCode"`
+	Header       http.Header `db:"header" json:"header"`
+	Body         []byte      `db:"body" json:"-"`
+}
+
+// BeforeUpdated overrides model lifecycle hook, updating the updated_at time.
+func (m *HttpCacheModel) BeforeUpdated() error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
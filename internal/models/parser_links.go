@@ -4,16 +4,33 @@ import (
 	"time"
 )
 
+// Link classifications describe where in a file a link was found.
+const (
+	LinkInComment     = "COMMENT"
+	LinkInString      = "STRING"
+	LinkInMarkdown    = "MARKDOWN"
+	LinkInConfig      = "CONFIG"
+	LinkInTestFixture = "TEST_FIXTURE"
+	LinkInCode        = "CODE"
+	LinkUnclassified  = "UNKNOWN"
+)
+
 // ParserLinksModel represents the repository data stored in the database.
 type ParserLinksModel struct {
 	Model
-	RepoId      int    `db:"repo_id" json:"repoId"`
-	Url         string `db:"url" json:"url"`
-	ExpandedUrl string `db:"expanded_url" json:"expandedUrl"`
-	File        string `db:"file" json:"file"`
-	LineNumber  int    `db:"line_number" json:"lineNumber"`
-	GithubUrl   string `db:"github_url" json:"github_url"`
-	Path        string `db:"path" json:"path"`
+	RepoId           int    `db:"repo_id" json:"repoId"`
+	Url              string `db:"url" json:"url"`
+	ExpandedUrl      string `db:"expanded_url" json:"expandedUrl"`
+	File             string `db:"file" json:"file"`
+	LineNumber       int    `db:"line_number" json:"lineNumber"`
+	ColumnNumber     int    `db:"column_number" json:"columnNumber"` // characters (unicode code points) from 1, not bytes
+	Classification   string `db:"classification" json:"classification"`
+	Snippet          string `db:"snippet" json:"snippet"`
+	SnippetStartLine int    `db:"snippet_start_line" json:"snippetStartLine"`
+	MatchStart       int    `db:"match_start" json:"matchStart"`
+	MatchEnd         int    `db:"match_end" json:"matchEnd"`
+	GithubUrl        string `db:"github_url" json:"github_url"`
+	Path             string `db:"path" json:"path"`
 }
 
 // BeforeUpdated overrides model lifecycle hook, updating the updated_at time.
@@ -21,3 +38,14 @@ func (m *ParserLinksModel) BeforeUpdated() error {
 	m.UpdatedAt = time.Now()
 	return nil
 }
+
+// LinkAffectsRuntime reports whether a link with the given classification is likely
+// to be used by the program at runtime (as opposed to comments, docs or test data).
+func LinkAffectsRuntime(classification string) bool {
+	switch classification {
+	case LinkInString, LinkInConfig, LinkInCode:
+		return true
+	default:
+		return false
+	}
+}
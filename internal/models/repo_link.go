@@ -5,32 +5,48 @@ import "time"
 // RepoWithLinks  is a DTO for the frontend.
 // Combines models.RepositoryModel and models.ParseLinksModel, to prevent additional frontend parsing logic that will need to be maintained
 type RepoWithLinks struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Author    string    `json:"author"`
-	State     string    `json:"state"`
-	ApiUrl    string    `json:"apiUrl"`
-	GhUrl     string    `json:"ghUrl"`
-	Language  string    `json:"language"`
-	Stars     int       `json:"stars"`
-	Forks     int       `json:"forks"`
-	Size      int       `json:"size"`
-	LastPush  time.Time `json:"lastPush"`
-	CloneURL  string    `json:"cloneUrl"`
-	ErrorMsg  string    `json:"errorMsg"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
-	Links     []Link    `json:"links"`
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Author     string    `json:"author"`
+	Forge      string    `json:"forge"`
+	State      string    `json:"state"`
+	ApiUrl     string    `json:"apiUrl"`
+	GhUrl      string    `json:"ghUrl"`
+	Language   string    `json:"language"`
+	Stars      int       `json:"stars"`
+	Forks      int       `json:"forks"`
+	Size       int       `json:"size"`
+	LastPush   time.Time `json:"lastPush"`
+	CloneURL   string    `json:"cloneUrl"`
+	ErrorMsg   string    `json:"errorMsg"`
+	Private    bool      `json:"private"`
+	TeamID     *int      `json:"teamId"`
+	Visibility string    `json:"visibility"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	Links      []Link    `json:"links"`
 }
 
 type Link struct {
-	ID          int       `json:"id"`
-	Url         string    `json:"url"`
-	ExpandedURL string    `json:"expandedUrl"`
-	File        string    `json:"file"`
-	LineNumber  int       `json:"lineNumber"`
-	GithubUrl   string    `json:"githubUrl"`
-	Path        string    `json:"path"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID               int       `json:"id"`
+	Url              string    `json:"url"`
+	ExpandedURL      string    `json:"expandedUrl"`
+	File             string    `json:"file"`
+	LineNumber       int       `json:"lineNumber"`
+	ColumnNumber     int       `json:"columnNumber"`
+	Classification   string    `json:"classification"`
+	AffectsRuntime   bool      `json:"affectsRuntime"`
+	Snippet          string    `json:"snippet"`
+	SnippetStartLine int       `json:"snippetStartLine"`
+	MatchStart       int       `json:"matchStart"`
+	MatchEnd         int       `json:"matchEnd"`
+	GithubUrl        string    `json:"githubUrl"`
+	Path             string    `json:"path"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// CreatedIssue is an issue raised against a repo on its forge
+type CreatedIssue struct {
+	Url string `json:"url"`
 }
@@ -1,14 +1,40 @@
 package models
 
 import (
+	"fmt"
 	"time"
 )
 
+// Forges that repositories can be hosted on.
+const (
+	ForgeGitHub = "github"
+	ForgeGitLab = "gitlab"
+	ForgeGitea  = "gitea"
+)
+
+// Parsing priorities. Pending repos with a higher priority are parsed first.
+const (
+	PriorityNormal = 0
+	// PriorityHigh is used for repos that code search found goo.gl links in
+	PriorityHigh = 10
+)
+
+// Visibility of a repository's results.
+const (
+	// VisibilityPublic repos are visible to everyone
+	VisibilityPublic = "public"
+	// VisibilityTeam repos are visible to members of the repo's team, and its owner
+	VisibilityTeam = "team"
+	// VisibilityPrivate repos are only visible to their owner
+	VisibilityPrivate = "private"
+)
+
 // RepositoryModel represents the repository data stored in the database.
 type RepositoryModel struct {
 	Model
 	Name     string    `db:"name" json:"name"`
 	Author   string    `db:"author" json:"author"`
+	Forge    string    `db:"forge" json:"forge"`
 	State    string    `db:"state" json:"state"`
 	Language string    `db:"language" json:"language"`
 	Stars    int       `db:"stars" json:"stars"`
@@ -19,6 +45,63 @@ type RepositoryModel struct {
 	GhUrl    string    `db:"gh_url" json:"ghUrl"`
 	CloneUrl string    `db:"clone_url" json:"cloneUrl"`
 	ErrorMsg string    `db:"error_msg" json:"essorMsg"`
+	Priority int       `db:"priority" json:"priority"`
+	// CandidateFiles are files that code search found goo.gl links in
+	CandidateFiles []string `db:"candidate_files" json:"candidateFiles"`
+	// ChangedFiles are files pushed to since the repo was last parsed. Only they are parsed when set, otherwise the whole repo is.
+	ChangedFiles []string `db:"changed_files" json:"changedFiles"`
+	// Private repos are private on their forge. They are cloned with their owner's token, and are never publicly visible.
+	Private     bool `db:"private" json:"private"`
+	OwnerUserID *int `db:"owner_user_id" json:"ownerUserId"`
+	TeamID      *int `db:"team_id" json:"teamId"`
+	// Visibility is who can see the repo's results
+	Visibility string `db:"visibility" json:"visibility"`
+}
+
+// ForgeName returns the forge the repository is hosted on.
+// Repositories saved without a forge are assumed to be on GitHub.
+func (m *RepositoryModel) ForgeName() string {
+	if m.Forge == "" {
+		return ForgeGitHub
+	}
+	return m.Forge
+}
+
+// SetOwner sets who owns a repo saved on their behalf, e.g. by their saved search.
+// Repos that are public on their forge stay public. Private repos are visible to the team if there is one, otherwise only to the owner.
+// Returns false for private repos without an owner, as they could never be cloned or seen.
+func (m *RepositoryModel) SetOwner(ownerUserID, teamID *int) bool {
+	m.OwnerUserID = ownerUserID
+	m.TeamID = nil
+	m.Visibility = VisibilityPublic
+	if m.Private {
+		m.Visibility = VisibilityPrivate
+		if teamID != nil {
+			m.TeamID = teamID
+			m.Visibility = VisibilityTeam
+		}
+	}
+	return !m.Private || ownerUserID != nil
+}
+
+// VisibilityOrDefault returns the repo's visibility. Repos saved without one are public, unless they are private on their forge.
+func (m *RepositoryModel) VisibilityOrDefault() string {
+	if m.Visibility != "" {
+		return m.Visibility
+	}
+	if m.Private {
+		return VisibilityPrivate
+	}
+	return VisibilityPublic
+}
+
+// CacheKey uniquely identifies the repository across forges.
+// GitHub repos keep the plain author/name key.
+func (m *RepositoryModel) CacheKey() string {
+	if m.ForgeName() == ForgeGitHub {
+		return fmt.Sprintf("%s/%s", m.Author, m.Name)
+	}
+	return fmt.Sprintf("%s:%s/%s", m.Forge, m.Author, m.Name)
 }
 
 // BeforeUpdated overrides model lifecycle hook, updating the updated_at time.
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// ScanReport is a DTO for the results of scanning files that are not tracked in repository_tb
+// (a local directory or an uploaded archive)
+type ScanReport struct {
+	Name      string    `json:"name"`
+	ScannedAt time.Time `json:"scannedAt"`
+	LinkCount int       `json:"linkCount"`
+	Links     []Link    `json:"links"`
+}
+
+// NewLink converts a parsed link into the DTO shared with the frontend
+func NewLink(l ParserLinksModel) Link {
+	return Link{
+		ID:               l.ID,
+		Url:              l.Url,
+		ExpandedURL:      l.ExpandedUrl,
+		File:             l.File,
+		LineNumber:       l.LineNumber,
+		ColumnNumber:     l.ColumnNumber,
+		Classification:   l.Classification,
+		AffectsRuntime:   LinkAffectsRuntime(l.Classification),
+		Snippet:          l.Snippet,
+		SnippetStartLine: l.SnippetStartLine,
+		MatchStart:       l.MatchStart,
+		MatchEnd:         l.MatchEnd,
+		GithubUrl:        l.GithubUrl,
+		Path:             l.Path,
+		CreatedAt:        l.CreatedAt,
+		UpdatedAt:        l.UpdatedAt,
+	}
+}
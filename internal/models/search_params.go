@@ -11,10 +11,46 @@ type SearchParamsModel struct {
 	Model
 	Name           string               `db:"name" json:"name" validate:"omitempty,min=1"`
 	Query          string               `db:"query" json:"query" validate:"omitempty,min=1"`
+	Forge          string               `db:"forge" json:"forge"`
 	Opts           github.SearchOptions `db:"opts" json:"opts"`
 	StartPage      int                  `db:"start_page" json:"startPage" validate:"min=0"`
 	CurrentPage    int                  `db:"current_page" json:"currentPage" validate:"min=0"`
 	PagesToProcess int                  `db:"pages_to_process" json:"pagesToProcess" validate:"min=0"`
+	// AutoSave saves the repos found by a named search, so they are queued for parsing
+	AutoSave   bool `db:"auto_save" json:"autoSave"`
+	ReposFound int  `db:"repos_found" json:"reposFound"`
+	// Exhausted is set when the forge has no more pages of results
+	Exhausted bool `db:"exhausted" json:"exhausted"`
+	// Schedule is a cron expression for running the search in the background. Empty when not scheduled.
+	Schedule  string     `db:"schedule" json:"schedule"`
+	LastRunAt *time.Time `db:"last_run_at" json:"lastRunAt"`
+	NextRunAt *time.Time `db:"next_run_at" json:"nextRunAt"`
+	// OwnerUserID is the user who saved the search. Repos the search saves belong to them.
+	OwnerUserID *int `db:"owner_user_id" json:"ownerUserId"`
+	TeamID      *int `db:"team_id" json:"teamId"`
+	// Visibility is who can see the search, like the visibility of repos
+	Visibility string `db:"visibility" json:"visibility"`
+}
+
+// FirstPage is the first page of results to process. Pages start from 1, but 0 has always been accepted as the first page.
+func (m *SearchParamsModel) FirstPage() int {
+	return max(m.StartPage, 1)
+}
+
+// EndPage is the page after the last page the search should process
+func (m *SearchParamsModel) EndPage() int {
+	return m.FirstPage() + m.PagesToProcess
+}
+
+// IsComplete checks if a saved search has processed all of its pages. CurrentPage is the next page to process.
+func (m *SearchParamsModel) IsComplete() bool {
+	return m.Exhausted || m.CurrentPage >= m.EndPage()
+}
+
+// SavedSearchResult is a DTO of a saved search and the repos found by its latest run
+type SavedSearchResult struct {
+	Search *SearchParamsModel `json:"search"`
+	Repos  []RepositoryModel  `json:"repos"`
 }
 
 // BeforeUpdated overrides model lifecycle hook, updating the updated_at time.
@@ -22,3 +58,17 @@ func (m *SearchParamsModel) BeforeUpdated() error {
 	m.UpdatedAt = time.Now()
 	return nil
 }
+
+// SearchResult is a DTO of the repos found by a search, and the query that was actually searched
+type SearchResult struct {
+	Query string            `json:"query"`
+	Repos []RepositoryModel `json:"repos"`
+}
+
+// CodeSearchResult is a DTO of the repos found by a code search.
+// Skipped counts repos that were found but are already saved.
+type CodeSearchResult struct {
+	Query   string            `json:"query"`
+	Repos   []RepositoryModel `json:"repos"`
+	Skipped int               `json:"skipped"`
+}
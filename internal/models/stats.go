@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Link expansion statuses reported by the stats endpoint
+const (
+	LinkExpanded   = "EXPANDED"
+	LinkFailed     = "FAILED"
+	LinkUnexpanded = "UNEXPANDED"
+)
+
+// StatsModel is a DTO of the aggregate statistics shown on the dashboard overview
+type StatsModel struct {
+	GeneratedAt        time.Time      `json:"generatedAt"`
+	TotalRepos         int            `json:"totalRepos"`
+	TotalLinks         int            `json:"totalLinks"`
+	ReposByState       map[string]int `json:"reposByState"`
+	LinksByExpansion   map[string]int `json:"linksByExpansion"`
+	TopAuthors         []StatCount    `json:"topAuthors"`
+	TopLanguages       []StatCount    `json:"topLanguages"`
+	TopShortUrls       []StatCount    `json:"topShortUrls"`
+	ReposScannedPerDay []StatCount    `json:"reposScannedPerDay"`
+}
+
+// StatCount is a labelled count, in the shape the dashboard charts expect
+type StatCount struct {
+	Label string `json:"label"`
+	Value int    `json:"value"`
+}
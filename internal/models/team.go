@@ -0,0 +1,29 @@
+package models
+
+// Roles of team members. Owners can add and remove members.
+const (
+	TeamRoleOwner  = "OWNER"
+	TeamRoleMember = "MEMBER"
+)
+
+// TeamModel is a group of users who share the repos saved for the team
+type TeamModel struct {
+	Model
+	Name string `db:"name" json:"name"`
+}
+
+// TeamMemberModel is a user's membership of a team
+type TeamMemberModel struct {
+	Model
+	TeamID int    `db:"team_id" json:"teamId"`
+	UserID int    `db:"user_id" json:"userId"`
+	Role   string `db:"role" json:"role"`
+	// Login is the member's GitHub login, joined from user_tb
+	Login string `json:"login"`
+}
+
+// TeamWithMembers is a DTO of a team and its members
+type TeamWithMembers struct {
+	TeamModel
+	Members []TeamMemberModel `json:"members"`
+}
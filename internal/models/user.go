@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// UserModel is a user who has signed in with GitHub
+type UserModel struct {
+	Model
+	GithubID  int64  `db:"github_id" json:"githubId"`
+	Login     string `db:"login" json:"login"`
+	Name      string `db:"name" json:"name"`
+	AvatarUrl string `db:"avatar_url" json:"avatarUrl"`
+	// AccessToken is the user's encrypted GitHub OAuth token
+	AccessToken []byte `db:"access_token" json:"-"`
+}
+
+// SessionModel is a signed in session. Only a hash of the session token is stored.
+type SessionModel struct {
+	Model
+	TokenHash string    `db:"token_hash" json:"-"`
+	UserID    int       `db:"user_id" json:"userId"`
+	ExpiresAt time.Time `db:"expires_at" json:"expiresAt"`
+}
@@ -0,0 +1,78 @@
+package models
+
+// WebhookDeliveryModel is a webhook delivery that has been handled, so redeliveries can be ignored
+type WebhookDeliveryModel struct {
+	Model
+	DeliveryID string `db:"delivery_id" json:"deliveryId"`
+	Event      string `db:"event" json:"event"`
+}
+
+// Outcomes of handling a webhook delivery
+const (
+	WebhookQueued    = "queued"
+	WebhookIgnored   = "ignored"
+	WebhookDuplicate = "duplicate"
+)
+
+// WebhookResult is the response to a webhook delivery
+type WebhookResult struct {
+	DeliveryID string `json:"deliveryId"`
+	Event      string `json:"event"`
+	Status     string `json:"status"`
+	// Reason explains why a delivery was ignored
+	Reason string `json:"reason,omitempty"`
+	Repo   string `json:"repo,omitempty"`
+	// ChangedFiles is how many files will be parsed, 0 when the whole repo will be
+	ChangedFiles int `json:"changedFiles"`
+}
+
+// Events outbound webhooks can subscribe to
+const (
+	// EventRepoCompleted is sent when a repo has been parsed
+	EventRepoCompleted = "repo.completed"
+	// EventRepoLinksFound is sent when a repo has been parsed and goo.gl links were found
+	EventRepoLinksFound = "repo.links_found"
+	// EventRepoFailed is sent when parsing a repo failed or timed out
+	EventRepoFailed = "repo.failed"
+)
+
+// WebhookEvents are the valid events of an outbound webhook
+var WebhookEvents = []string{EventRepoCompleted, EventRepoLinksFound, EventRepoFailed}
+
+// Payload formats of outbound webhooks
+const (
+	WebhookFormatJSON  = "json"
+	WebhookFormatSlack = "slack"
+)
+
+// OutboundWebhookModel is a URL that is notified when repos finish parsing
+type OutboundWebhookModel struct {
+	Model
+	UserID int    `db:"user_id" json:"userId"`
+	URL    string `db:"url" json:"url"`
+	// Secret signs deliveries. It is only returned when the webhook is created.
+	Secret string   `db:"secret" json:"-"`
+	Events []string `db:"events" json:"events"`
+	Format string   `db:"format" json:"format"`
+	Active bool     `db:"active" json:"active"`
+}
+
+// CreatedOutboundWebhook is a new outbound webhook, the only time its secret is returned
+type CreatedOutboundWebhook struct {
+	OutboundWebhookModel
+	Secret string `json:"secret"`
+}
+
+// OutboundDeliveryModel is an attempt to deliver an event to an outbound webhook
+type OutboundDeliveryModel struct {
+	Model
+	WebhookID int `db:"webhook_id" json:"webhookId"`
+	// DeliveryID is shared by the attempts of the same delivery
+	DeliveryID string `db:"delivery_id" json:"deliveryId"`
+	Event      string `db:"event" json:"event"`
+	Attempt    int    `db:"attempt" json:"attempt"`
+	StatusCode int    `db:"status_code" json:"statusCode"`
+	ErrorMsg   string `db:"error_msg" json:"errorMsg"`
+	Success    bool   `db:"success" json:"success"`
+	DurationMs int    `db:"duration_ms" json:"durationMs"`
+}
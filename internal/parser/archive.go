@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// This file handles extracting uploaded archives so they can be parsed like a cloned repo
+
+// maxExtractedSizeMB is the max total size of files extracted from a single archive
+const maxExtractedSizeMB = 500
+
+// maxExtractedFiles is the max number of files extracted from a single archive, as empty files use no size budget.
+// It is a var so tests don't have to write as many files.
+var maxExtractedFiles = 100000
+
+// IsSupportedArchive checks if the file name has an archive extension we can extract
+func IsSupportedArchive(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".zip") ||
+		strings.HasSuffix(name, ".tar.gz") ||
+		strings.HasSuffix(name, ".tgz") ||
+		strings.HasSuffix(name, ".tar")
+}
+
+// ExtractArchive extracts a .zip, .tar.gz/.tgz or .tar archive into dest.
+// The archive type is determined from name, as uploaded files are usually saved to a temp path.
+// Entries that would be written outside of dest, symlinks, and files over the max parse size are skipped.
+func ExtractArchive(archivePath, name, dest string) error {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return extractZip(archivePath, dest)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		f, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("error reading gzip archive: %w", err)
+		}
+		defer gz.Close()
+		return extractTar(gz, dest)
+	case strings.HasSuffix(lower, ".tar"):
+		f, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return extractTar(f, dest)
+	default:
+		return fmt.Errorf("unsupported archive type: %s", name)
+	}
+}
+
+func extractZip(archivePath, dest string) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("error reading zip archive: %w", err)
+	}
+	defer r.Close()
+
+	budget := int64(maxExtractedSizeMB * 1024 * 1024)
+	files := 0
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() || !f.Mode().IsRegular() {
+			continue
+		}
+		if f.UncompressedSize64 > uint64(maxFileSizeMB*1024*1024) {
+			continue
+		}
+		if files++; files > maxExtractedFiles {
+			return fmt.Errorf("archive has more than %d files", maxExtractedFiles)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("error reading %s from zip archive: %w", f.Name, err)
+		}
+		written, err := writeArchiveEntry(dest, f.Name, rc, budget)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		budget -= written
+	}
+
+	return nil
+}
+
+func extractTar(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+	budget := int64(maxExtractedSizeMB * 1024 * 1024)
+	files := 0
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading tar archive: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Size > int64(maxFileSizeMB*1024*1024) {
+			continue
+		}
+		if files++; files > maxExtractedFiles {
+			return fmt.Errorf("archive has more than %d files", maxExtractedFiles)
+		}
+
+		written, err := writeArchiveEntry(dest, header.Name, tr, budget)
+		if err != nil {
+			return err
+		}
+		budget -= written
+	}
+}
+
+// writeArchiveEntry writes a single file from an archive, making sure it stays within dest
+func writeArchiveEntry(dest, name string, r io.Reader, budget int64) (int64, error) {
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	if target != dest && !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+		// Zip slip, the entry is trying to escape the destination
+		return 0, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	// Read one byte over the budget so we can tell if the archive is too large
+	written, err := io.Copy(out, io.LimitReader(r, budget+1))
+	if err != nil {
+		return written, fmt.Errorf("error extracting %s: %w", name, err)
+	}
+	if written > budget {
+		return written, fmt.Errorf("archive is larger than the %dMB limit", maxExtractedSizeMB)
+	}
+
+	return written, nil
+}
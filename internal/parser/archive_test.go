@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractZipArchive(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "upload")
+	f, err := os.Create(archivePath)
+	assert.NoError(t, err)
+
+	zw := zip.NewWriter(f)
+	for name, contents := range map[string]string{
+		"src/main.go":   "// https://goo.gl/abc",
+		"../escape.txt": "should not be written",
+	} {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		w.Write([]byte(contents))
+	}
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, f.Close())
+
+	dest := t.TempDir()
+	err = ExtractArchive(archivePath, "project.zip", dest)
+	assert.NoError(t, err)
+
+	contents, err := os.ReadFile(filepath.Join(dest, "src", "main.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "// https://goo.gl/abc", string(contents))
+
+	_, err = os.Stat(filepath.Join(filepath.Dir(dest), "escape.txt"))
+	assert.True(t, os.IsNotExist(err), "entries outside of the destination should be skipped")
+}
+
+func TestExtractTarGzArchive(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "upload")
+	f, err := os.Create(archivePath)
+	assert.NoError(t, err)
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	contents := []byte("docs: https://goo.gl/abc")
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "config.yml", Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg}))
+	tw.Write(contents)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "link", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink}))
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, f.Close())
+
+	dest := t.TempDir()
+	err = ExtractArchive(archivePath, "project.tar.gz", dest)
+	assert.NoError(t, err)
+
+	extracted, err := os.ReadFile(filepath.Join(dest, "config.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, contents, extracted)
+
+	_, err = os.Lstat(filepath.Join(dest, "link"))
+	assert.True(t, os.IsNotExist(err), "symlinks should be skipped")
+}
+
+func TestExtractArchiveLimitsFiles(t *testing.T) {
+	previous := maxExtractedFiles
+	maxExtractedFiles = 3
+	t.Cleanup(func() { maxExtractedFiles = previous })
+
+	archivePath := filepath.Join(t.TempDir(), "upload")
+	f, err := os.Create(archivePath)
+	assert.NoError(t, err)
+
+	// Empty files don't use any of the size budget
+	tw := tar.NewWriter(f)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg}))
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, f.Close())
+
+	err = ExtractArchive(archivePath, "project.tar", t.TempDir())
+	assert.EqualError(t, err, "archive has more than 3 files")
+}
+
+func TestExtractUnsupportedArchive(t *testing.T) {
+	err := ExtractArchive("upload", "project.rar", t.TempDir())
+	assert.Error(t, err)
+	assert.False(t, IsSupportedArchive("project.rar"))
+	assert.True(t, IsSupportedArchive("Project.TGZ"))
+}
@@ -0,0 +1,411 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+// This file handles classifying where in a file a link was found (comment, string, docs etc.)
+// It is not a real lexer, just enough of one to follow comments and strings across lines.
+
+type languageFamily int
+
+const (
+	familyUnknown languageFamily = iota
+	familyCode
+	familyConfig
+	familyMarkup
+	familyProse
+)
+
+type language struct {
+	family        languageFamily
+	lineComments  []string
+	blockComments [][2]string
+	// Quotes that open a string literal. Longest quotes should be listed first.
+	quotes []string
+	// Quotes that may span multiple lines (e.g. Go raw strings, Python docstrings)
+	multiLineQuotes []string
+	// Quotes that do not support backslash escapes
+	rawQuotes []string
+}
+
+var (
+	cLike = language{
+		family:        familyCode,
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`, `'`},
+	}
+	goLang = language{
+		family:          familyCode,
+		lineComments:    []string{"//"},
+		blockComments:   [][2]string{{"/*", "*/"}},
+		quotes:          []string{`"`, `'`, "`"},
+		multiLineQuotes: []string{"`"},
+		rawQuotes:       []string{"`"},
+	}
+	jsLike = language{
+		family:          familyCode,
+		lineComments:    []string{"//"},
+		blockComments:   [][2]string{{"/*", "*/"}},
+		quotes:          []string{`"`, `'`, "`"},
+		multiLineQuotes: []string{"`"},
+	}
+	pythonLang = language{
+		family:          familyCode,
+		lineComments:    []string{"#"},
+		quotes:          []string{`"""`, `'''`, `"`, `'`},
+		multiLineQuotes: []string{`"""`, `'''`},
+	}
+	hashComment = language{
+		family:       familyCode,
+		lineComments: []string{"#"},
+		quotes:       []string{`"`, `'`},
+	}
+	sqlLang = language{
+		family:        familyCode,
+		lineComments:  []string{"--"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`'`, `"`},
+	}
+	luaLang = language{
+		family:        familyCode,
+		blockComments: [][2]string{{"--[[", "]]"}},
+		lineComments:  []string{"--"},
+		quotes:        []string{`"`, `'`},
+	}
+	cssLang = language{
+		family:        familyCode,
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`, `'`},
+	}
+	markupLang = language{
+		family:        familyMarkup,
+		blockComments: [][2]string{{"<!--", "-->"}},
+		// Quotes are only followed inside tags, and apostrophes are common in unquoted attributes
+		quotes: []string{`"`},
+	}
+	hashConfig = language{
+		family:       familyConfig,
+		lineComments: []string{"#"},
+	}
+	iniConfig = language{
+		family:       familyConfig,
+		lineComments: []string{"#", ";"},
+	}
+	jsonConfig = language{
+		family: familyConfig,
+	}
+	xmlConfig = language{
+		family:        familyConfig,
+		blockComments: [][2]string{{"<!--", "-->"}},
+	}
+	prose = language{
+		family:        familyProse,
+		blockComments: [][2]string{{"<!--", "-->"}},
+	}
+	unknownLang = language{
+		family: familyUnknown,
+	}
+)
+
+// .m is left out, as it is used by both Objective-C and MATLAB, which have different comments
+var languagesByExt = map[string]language{
+	".go":         goLang,
+	".c":          cLike,
+	".h":          cLike,
+	".cc":         cLike,
+	".cpp":        cLike,
+	".hpp":        cLike,
+	".cs":         cLike,
+	".java":       cLike,
+	".kt":         cLike,
+	".kts":        cLike,
+	".scala":      cLike,
+	".swift":      cLike,
+	".rs":         cLike,
+	".dart":       cLike,
+	".php":        cLike,
+	".mm":         cLike,
+	".groovy":     cLike,
+	".gradle":     cLike,
+	".js":         jsLike,
+	".jsx":        jsLike,
+	".mjs":        jsLike,
+	".cjs":        jsLike,
+	".ts":         jsLike,
+	".tsx":        jsLike,
+	".py":         pythonLang,
+	".rb":         hashComment,
+	".sh":         hashComment,
+	".bash":       hashComment,
+	".zsh":        hashComment,
+	".pl":         hashComment,
+	".r":          hashComment,
+	".ex":         hashComment,
+	".exs":        hashComment,
+	".sql":        sqlLang,
+	".lua":        luaLang,
+	".css":        cssLang,
+	".scss":       cLike,
+	".less":       cLike,
+	".html":       markupLang,
+	".htm":        markupLang,
+	".vue":        markupLang,
+	".svelte":     markupLang,
+	".yaml":       hashConfig,
+	".yml":        hashConfig,
+	".toml":       hashConfig,
+	".properties": hashConfig,
+	".env":        hashConfig,
+	".ini":        iniConfig,
+	".cfg":        iniConfig,
+	".conf":       iniConfig,
+	".json":       jsonConfig,
+	".xml":        xmlConfig,
+	".plist":      xmlConfig,
+	".md":         prose,
+	".markdown":   prose,
+	".rst":        prose,
+	".txt":        prose,
+	".adoc":       prose,
+}
+
+var languagesByName = map[string]language{
+	"dockerfile":    hashComment,
+	"makefile":      hashComment,
+	"gemfile":       hashComment,
+	"rakefile":      hashComment,
+	".env":          hashConfig,
+	".gitmodules":   iniConfig,
+	".editorconfig": iniConfig,
+	"readme":        prose,
+	"license":       prose,
+	"changelog":     prose,
+}
+
+func languageForFile(relPath string) language {
+	name := strings.ToLower(filepath.Base(relPath))
+	if lang, ok := languagesByName[name]; ok {
+		return lang
+	}
+	if lang, ok := languagesByExt[filepath.Ext(name)]; ok {
+		return lang
+	}
+	return unknownLang
+}
+
+// isTestFixture reports whether a file looks like it belongs to a test suite or its fixtures.
+func isTestFixture(relPath string) bool {
+	p := "/" + strings.ToLower(filepath.ToSlash(relPath))
+	for _, dir := range []string{"/testdata/", "/fixtures/", "/__fixtures__/", "/__tests__/", "/test/", "/tests/", "/spec/", "/__mocks__/"} {
+		if strings.Contains(p, dir) {
+			return true
+		}
+	}
+
+	name := filepath.Base(p)
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	return strings.HasSuffix(base, "_test") ||
+		strings.HasSuffix(base, ".test") ||
+		strings.HasSuffix(base, ".spec") ||
+		strings.HasSuffix(base, "_spec") ||
+		strings.HasPrefix(base, "test_")
+}
+
+// linkClassifier tracks lexer state through a file, one line at a time.
+type linkClassifier struct {
+	lang        language
+	testFixture bool
+
+	inBlock  string // closing delimiter of the block comment we are in
+	inString string // quote of the multi-line string we are in
+	inFence  bool   // inside a fenced code block (prose only)
+	inTag    bool   // inside a tag, between < and > (markup only)
+	inScript string // closing tag of the script or style element we are in (markup only)
+}
+
+func newLinkClassifier(relPath string) *linkClassifier {
+	return &linkClassifier{
+		lang:        languageForFile(relPath),
+		testFixture: isTestFixture(relPath),
+	}
+}
+
+// next consumes a line of the file and returns the classification at byte offset col
+// Offsets outside of the line (e.g. -1) still advance the lexer state and return LinkUnclassified.
+func (c *linkClassifier) next(line string, col int) string {
+	state := c.scan(line, col)
+	if col < 0 || col > len(line) {
+		return models.LinkUnclassified
+	}
+	if c.testFixture {
+		return models.LinkInTestFixture
+	}
+	return state
+}
+
+// scan walks the line, updating the lexer state and returning the classification at col.
+func (c *linkClassifier) scan(line string, col int) string {
+	if c.lang.family == familyProse {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") || strings.HasPrefix(strings.TrimSpace(line), "~~~") {
+			c.inFence = !c.inFence
+			return models.LinkInCode
+		}
+		if c.inFence {
+			return models.LinkInCode
+		}
+	}
+
+	result := models.LinkUnclassified
+	// record sets the result if col falls within [start, end)
+	record := func(start, end int, classification string) {
+		if col >= start && col < end {
+			result = classification
+		}
+	}
+
+	i := 0
+	for i < len(line) {
+		switch {
+		case c.inBlock != "":
+			if strings.HasPrefix(line[i:], c.inBlock) {
+				record(i, i+len(c.inBlock), models.LinkInComment)
+				i += len(c.inBlock)
+				c.inBlock = ""
+				continue
+			}
+			record(i, i+1, models.LinkInComment)
+			i++
+		case c.inString != "":
+			if line[i] == '\\' && !contains(c.lang.rawQuotes, c.inString) {
+				record(i, i+2, models.LinkInString)
+				i += 2
+				continue
+			}
+			if strings.HasPrefix(line[i:], c.inString) {
+				record(i, i+len(c.inString), models.LinkInString)
+				i += len(c.inString)
+				c.inString = ""
+				continue
+			}
+			record(i, i+1, models.LinkInString)
+			i++
+		default:
+			if prefix := matchPrefix(line[i:], c.lang.lineComments); prefix != "" {
+				// Everything else on this line is a comment
+				record(i, len(line), models.LinkInComment)
+				return result
+			}
+			if open, close := matchBlock(line[i:], c.lang.blockComments); open != "" {
+				record(i, i+len(open), models.LinkInComment)
+				c.inBlock = close
+				i += len(open)
+				continue
+			}
+			if c.lang.family == familyMarkup && !c.inTag {
+				i = c.scanMarkupText(line, i, record)
+				continue
+			}
+			if c.lang.family == familyMarkup && line[i] == '>' {
+				record(i, i+1, models.LinkInCode)
+				c.inTag = false
+				i++
+				continue
+			}
+			if quote := matchPrefix(line[i:], c.lang.quotes); quote != "" {
+				record(i, i+len(quote), models.LinkInString)
+				c.inString = quote
+				i += len(quote)
+				continue
+			}
+			record(i, i+1, c.plainClassification())
+			i++
+		}
+	}
+
+	// Strings which can not span lines are terminated at the end of the line
+	if c.inString != "" && !contains(c.lang.multiLineQuotes, c.inString) {
+		c.inString = ""
+	}
+
+	return result
+}
+
+// scanMarkupText handles a byte of markup outside of tags, returning the offset of the next byte.
+// Text is docs, except in script and style elements, which are code.
+func (c *linkClassifier) scanMarkupText(line string, i int, record func(start, end int, classification string)) int {
+	if c.inScript != "" {
+		if hasPrefixFold(line[i:], c.inScript) {
+			c.inScript = ""
+			c.inTag = true
+			record(i, i+1, models.LinkInCode)
+			return i + 1
+		}
+		record(i, i+1, models.LinkInCode)
+		return i + 1
+	}
+
+	if line[i] == '<' {
+		for _, element := range []string{"script", "style"} {
+			if hasPrefixFold(line[i+1:], element) {
+				c.inScript = "</" + element
+			}
+		}
+		c.inTag = true
+		record(i, i+1, models.LinkInCode)
+		return i + 1
+	}
+
+	record(i, i+1, models.LinkInMarkdown)
+	return i + 1
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+// plainClassification is the classification of text outside of comments and strings
+func (c *linkClassifier) plainClassification() string {
+	switch c.lang.family {
+	case familyCode, familyMarkup:
+		// Markup text outside of tags is handled by scanMarkupText, this is inside a tag
+		return models.LinkInCode
+	case familyConfig:
+		return models.LinkInConfig
+	case familyProse:
+		return models.LinkInMarkdown
+	default:
+		return models.LinkUnclassified
+	}
+}
+
+func matchPrefix(s string, prefixes []string) string {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+func matchBlock(s string, blocks [][2]string) (string, string) {
+	for _, b := range blocks {
+		if strings.HasPrefix(s, b[0]) {
+			return b[0], b[1]
+		}
+	}
+	return "", ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkClassifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		expected string
+	}{
+		{
+			name:     "go line comment",
+			file:     "main.go",
+			contents: "package main\n// See https://goo.gl/abc for details",
+			expected: models.LinkInComment,
+		},
+		{
+			name:     "go string literal",
+			file:     "main.go",
+			contents: "package main\nconst docs = \"https://goo.gl/abc\"",
+			expected: models.LinkInString,
+		},
+		{
+			name:     "go block comment spanning lines",
+			file:     "main.go",
+			contents: "/*\n Docs:\n https://goo.gl/abc\n*/",
+			expected: models.LinkInComment,
+		},
+		{
+			name:     "go raw string spanning lines",
+			file:     "main.go",
+			contents: "var tmpl = `\n<a href=\"https://goo.gl/abc\">\n`",
+			expected: models.LinkInString,
+		},
+		{
+			name:     "comment marker inside a string is not a comment",
+			file:     "app.js",
+			contents: "const url = \"// https://goo.gl/abc\";",
+			expected: models.LinkInString,
+		},
+		{
+			name:     "escaped quote does not end the string",
+			file:     "app.js",
+			contents: "const s = \"say \\\"hi\\\" https://goo.gl/abc\";",
+			expected: models.LinkInString,
+		},
+		{
+			name:     "python docstring",
+			file:     "lib.py",
+			contents: "def f():\n    \"\"\"\n    https://goo.gl/abc\n    \"\"\"",
+			expected: models.LinkInString,
+		},
+		{
+			name:     "python hash comment",
+			file:     "lib.py",
+			contents: "x = 1  # https://goo.gl/abc",
+			expected: models.LinkInComment,
+		},
+		{
+			name:     "markdown prose",
+			file:     "README.md",
+			contents: "# Title\nRead more at https://goo.gl/abc",
+			expected: models.LinkInMarkdown,
+		},
+		{
+			name:     "markdown fenced code",
+			file:     "README.md",
+			contents: "```go\nhttp.Get(\"https://goo.gl/abc\")",
+			expected: models.LinkInCode,
+		},
+		{
+			name:     "yaml value",
+			file:     "config.yml",
+			contents: "docs: https://goo.gl/abc",
+			expected: models.LinkInConfig,
+		},
+		{
+			name:     "yaml comment",
+			file:     "config.yml",
+			contents: "# docs: https://goo.gl/abc",
+			expected: models.LinkInComment,
+		},
+		{
+			name:     "html comment",
+			file:     "index.html",
+			contents: "<!-- https://goo.gl/abc -->",
+			expected: models.LinkInComment,
+		},
+		{
+			name:     "html text",
+			file:     "index.html",
+			contents: "<p>See https://goo.gl/abc for details</p>",
+			expected: models.LinkInMarkdown,
+		},
+		{
+			name:     "html text with quotes",
+			file:     "index.html",
+			contents: "<p>It's at \"https://goo.gl/abc\"</p>",
+			expected: models.LinkInMarkdown,
+		},
+		{
+			name:     "html attribute",
+			file:     "index.html",
+			contents: "<a href=\"https://goo.gl/abc\">docs</a>",
+			expected: models.LinkInString,
+		},
+		{
+			name:     "html unquoted attribute",
+			file:     "index.html",
+			contents: "<a\n  href=https://goo.gl/abc>docs</a>",
+			expected: models.LinkInCode,
+		},
+		{
+			name:     "html script",
+			file:     "index.html",
+			contents: "<script>\nfetch(u || https://goo.gl/abc)\n</script>",
+			expected: models.LinkInCode,
+		},
+		{
+			name:     "html text after script",
+			file:     "index.vue",
+			contents: "<SCRIPT src=\"app.js\"></SCRIPT>\n<p>https://goo.gl/abc</p>",
+			expected: models.LinkInMarkdown,
+		},
+		{
+			name:     "objective-c or matlab",
+			file:     "main.m",
+			contents: "% https://goo.gl/abc",
+			expected: models.LinkUnclassified,
+		},
+		{
+			name:     "test fixture",
+			file:     "pkg/testdata/links.go",
+			contents: "var u = \"https://goo.gl/abc\"",
+			expected: models.LinkInTestFixture,
+		},
+		{
+			name:     "go test file",
+			file:     "pkg/links_test.go",
+			contents: "// https://goo.gl/abc",
+			expected: models.LinkInTestFixture,
+		},
+		{
+			name:     "unknown extension",
+			file:     "data.bin",
+			contents: "https://goo.gl/abc",
+			expected: models.LinkUnclassified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classifier := newLinkClassifier(tt.file)
+			classification := ""
+			for _, line := range strings.Split(tt.contents, "\n") {
+				_, col := findGooGlLink(line)
+				c := classifier.next(line, col)
+				if col >= 0 {
+					classification = c
+				}
+			}
+			assert.Equal(t, tt.expected, classification)
+		})
+	}
+}
@@ -2,6 +2,8 @@ package parser
 
 import (
 	"fmt"
+	"net/url"
+	"os"
 	"os/exec"
 	"strings"
 
@@ -9,7 +11,8 @@ import (
 )
 
 type GitCmdLineI interface {
-	Clone(url, destination string) (string, error)
+	// Clone shallow clones a repo, returning the current branch. The token is used for private repos, and is empty for public repos.
+	Clone(url, destination, token string) (string, error)
 }
 
 type GitCmdLine struct {
@@ -22,12 +25,34 @@ func NewGitCmdLine(log common.Logger) *GitCmdLine {
 	}
 }
 
-func (g *GitCmdLine) Clone(url, destination string) (string, error) {
+// gitTokenEnv is the env var the credential helper reads the token from
+const gitTokenEnv = "GOOGL_BYE_GIT_TOKEN"
+
+// credentialHelper answers git's credential requests with the token in gitTokenEnv.
+// The token is only ever in the env of the git process, so it is never written to disk, the process args or logs.
+const credentialHelper = `!f() { test "$1" = get && echo username=x-access-token && echo "password=$` + gitTokenEnv + `"; }; f`
+
+func (g *GitCmdLine) Clone(cloneUrl, destination, token string) (string, error) {
 	// Clone the repository
-	g.log.Infof("Cloning repo '%s' into '%s'", url, destination)
-	cloneCmd := exec.Command("git", "clone", "--depth", "1", url, destination)
-	if err := cloneCmd.Run(); err != nil {
-		return "", fmt.Errorf("failed to clone repository: %w", err)
+	g.log.Infof("Cloning repo '%s' into '%s'", cloneUrl, destination)
+
+	args := []string{"clone", "--depth", "1", cloneUrl, destination}
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if token != "" {
+		helperKey, err := credentialHelperKey(cloneUrl)
+		if err != nil {
+			return "", err
+		}
+		// The empty helper clears any configured helpers, so the token isn't saved by a credential store.
+		// The token's helper is only used for the clone url's host, so redirects or submodules elsewhere don't get it.
+		args = append([]string{"-c", "credential.helper=", "-c", helperKey + "=" + credentialHelper}, args...)
+		env = append(env, gitTokenEnv+"="+token)
+	}
+
+	cloneCmd := exec.Command("git", args...)
+	cloneCmd.Env = env
+	if output, err := cloneCmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to clone repository: %w: %s", err, strings.TrimSpace(string(output)))
 	}
 
 	// Get the current branch
@@ -40,7 +65,16 @@ func (g *GitCmdLine) Clone(url, destination string) (string, error) {
 	// Trim any whitespace from the branch name
 	branch := strings.TrimSpace(string(output))
 
-	g.log.Infof("Cloned repo '%s' into '%s'. Current branch: %s", url, destination, branch)
+	g.log.Infof("Cloned repo '%s' into '%s'. Current branch: %s", cloneUrl, destination, branch)
 
 	return branch, nil
 }
+
+// credentialHelperKey is the git config key for a credential helper limited to the host of the clone url, e.g. credential.https://github.com.helper
+func credentialHelperKey(cloneUrl string) (string, error) {
+	u, err := url.Parse(cloneUrl)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("refusing to send a token to clone url '%s', expected an https url", cloneUrl)
+	}
+	return fmt.Sprintf("credential.https://%s.helper", u.Host), nil
+}
@@ -1,8 +1,13 @@
 package parser
 
 import (
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/jwtly10/googl-bye/internal/common"
@@ -25,7 +30,7 @@ func TestGitCmdLineClone(t *testing.T) {
 	destination := filepath.Join(tmpDir, "repo")
 
 	// Execute
-	branch, err := gitCmdLine.Clone(repoURL, destination)
+	branch, err := gitCmdLine.Clone(repoURL, destination, "")
 
 	// Assert
 	assert.NoError(t, err)
@@ -36,3 +41,69 @@ func TestGitCmdLineClone(t *testing.T) {
 	_, err = os.Stat(readmePath)
 	assert.NoError(t, err, "README.md should exist in the cloned repository")
 }
+
+func TestGitCmdLineCloneWithToken(t *testing.T) {
+	logger := common.NewLogger(false, zapcore.DebugLevel)
+	gitCmdLine := NewGitCmdLine(logger)
+
+	// Serve a local repo over https, only allowing the token. The test server's certificate isn't trusted by git.
+	t.Setenv("GIT_SSL_NO_VERIFY", "1")
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	for _, args := range [][]string{
+		{"init", "-b", "main", work},
+		{"-C", work, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--allow-empty", "-m", "init"},
+		{"clone", "--bare", work, filepath.Join(root, "private.git")},
+	} {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+
+	gitPath, err := exec.Command("git", "--exec-path").Output()
+	assert.NoError(t, err)
+	backend := &cgi.Handler{
+		Path: filepath.Join(strings.TrimSpace(string(gitPath)), "git-http-backend"),
+		Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "x-access-token" || password != "secret-token" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		backend.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	branch, err := gitCmdLine.Clone(server.URL+"/private.git", filepath.Join(root, "clone"), "secret-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "main", branch)
+
+	// The token must not be saved in the clone's config
+	config, err := os.ReadFile(filepath.Join(root, "clone", ".git", "config"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(config), "secret-token")
+
+	_, err = gitCmdLine.Clone(server.URL+"/private.git", filepath.Join(root, "no-token"), "")
+	assert.Error(t, err)
+
+	_, err = gitCmdLine.Clone(server.URL+"/private.git", filepath.Join(root, "wrong-token"), "wrong-token")
+	assert.Error(t, err)
+}
+
+func TestCredentialHelperKey(t *testing.T) {
+	key, err := credentialHelperKey("https://github.com/owner/repo.git")
+	assert.NoError(t, err)
+	assert.Equal(t, "credential.https://github.com.helper", key)
+
+	key, err = credentialHelperKey("https://gitea.example.com:3000/owner/repo.git")
+	assert.NoError(t, err)
+	assert.Equal(t, "credential.https://gitea.example.com:3000.helper", key)
+
+	// Tokens are never sent in plain text, or to urls without a host
+	for _, cloneUrl := range []string{"http://github.com/owner/repo.git", "git@github.com:owner/repo.git", "/tmp/repo"} {
+		_, err := credentialHelperKey(cloneUrl)
+		assert.Error(t, err, cloneUrl)
+	}
+}
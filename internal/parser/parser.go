@@ -7,21 +7,29 @@ import (
 	"time"
 
 	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/forge"
 	"github.com/jwtly10/googl-bye/internal/models"
 	"github.com/jwtly10/googl-bye/internal/repository"
 )
 
+// Notifier is told when repos finish parsing, successfully or not
+type Notifier interface {
+	RepoParsed(ctx context.Context, repo models.RepositoryModel, links int)
+}
+
 type Parser struct {
 	repoParser RepoParser
 	log        common.Logger
 	repoRepo   repository.RepoRepository
 	stateRepo  repository.ParserStateRepository
 	linkRepo   repository.ParserLinksRepository
+	notifier   Notifier
 }
 
-func NewParser(log common.Logger, repoRepo repository.RepoRepository, stateRepo repository.ParserStateRepository, linkRepo repository.ParserLinksRepository) *Parser {
+// NewParser creates the parser job. The notifier is optional.
+func NewParser(log common.Logger, repoRepo repository.RepoRepository, stateRepo repository.ParserStateRepository, linkRepo repository.ParserLinksRepository, forges *forge.Registry, repoTokens RepoTokens, notifier Notifier) *Parser {
 	git := NewGitCmdLine(log)
-	rp := NewRepoParser(git, log)
+	rp := NewRepoParser(git, log, forges).WithRepoTokens(repoTokens)
 
 	return &Parser{
 		repoParser: *rp,
@@ -29,6 +37,7 @@ func NewParser(log common.Logger, repoRepo repository.RepoRepository, stateRepo
 		repoRepo:   repoRepo,
 		linkRepo:   linkRepo,
 		stateRepo:  stateRepo,
+		notifier:   notifier,
 	}
 }
 
@@ -70,24 +79,49 @@ func (p *Parser) StartParser(ctx context.Context, limit int) {
 
 			done := make(chan struct{})
 
+			// The parse can't be stopped, so whichever of the parse and the timeout ends first
+			// saves the result of the repo, and only one event is sent for it
+			var claimMu sync.Mutex
+			claimed := false
+			claim := func() bool {
+				claimMu.Lock()
+				defer claimMu.Unlock()
+				ok := !claimed
+				claimed = true
+				return ok
+			}
+			timedOut := repo
+
 			go func() {
 				defer close(done)
 				repo.State = "PROCESSING"
-				err = p.repoRepo.UpdateRepo(&repo)
+				err := p.repoRepo.UpdateRepo(&repo)
 				if err != nil {
 					p.log.Errorf("[%s] Error updateing repo state : %v", fmt.Sprintf("%s/%s", repo.Author, repo.Name), err)
 				}
 
 				links, err := p.repoParser.ParseRepository(repo)
+				if !claim() {
+					p.log.Warnf("[%s] Discarding result of parse that finished after timing out", fmt.Sprintf("%s/%s", repo.Author, repo.Name))
+					return
+				}
 				if err != nil {
 					p.log.Errorf("[%s] Error parsing repo: %v", fmt.Sprintf("%s/%s", repo.Author, repo.Name), err)
 					// If this fails, we should set state failed
 					repo.State = "ERROR"
 					repo.ErrorMsg = err.Error()
-					err = p.repoRepo.UpdateRepo(&repo)
-					if err != nil {
-						p.log.Errorf("[%s] Error updating repo state: %v", fmt.Sprintf("%s/%s", repo.Author, repo.Name), err)
+					if p.finish(&repo) {
+						p.notify(ctx, repo, 0)
 					}
+					return
+				}
+
+				// Links from earlier parses are replaced, only those in the changed files if the repo was pushed to
+				deleted, err := p.linkRepo.DeleteParserLinks(repo.ID, repo.ChangedFiles)
+				if err != nil {
+					p.log.Errorf("[%s] Error deleting old repo links: %v", fmt.Sprintf("%s/%s", repo.Author, repo.Name), err)
+				} else if deleted > 0 {
+					p.log.Infof("[%s] Deleted '%v' old goo.gl links", fmt.Sprintf("%s/%s", repo.Author, repo.Name), deleted)
 				}
 
 				// Save any links
@@ -102,9 +136,9 @@ func (p *Parser) StartParser(ctx context.Context, limit int) {
 
 				// Update states on success
 				repo.State = "COMPLETED"
-				err = p.repoRepo.UpdateRepo(&repo)
-				if err != nil {
-					p.log.Errorf("[%s] Error updating repo state: %v", fmt.Sprintf("%s/%s", repo.Author, repo.Name), err)
+				repo.ChangedFiles = nil
+				if p.finish(&repo) {
+					p.notify(ctx, repo, len(links))
 				}
 
 				resultChan <- repo
@@ -113,13 +147,18 @@ func (p *Parser) StartParser(ctx context.Context, limit int) {
 
 			select {
 			case <-timeoutCtx.Done():
-				if timeoutCtx.Err() == context.DeadlineExceeded {
-					p.log.Warnf("[%s] Processing timed out after 30 seconds", fmt.Sprintf("%s/%s", repo.Author, repo.Name))
-					repo.State = "TIMEOUT"
-					err := p.repoRepo.UpdateRepo(&repo)
-					if err != nil {
-						p.log.Errorf("[%s] Error updating repo state after timeout: %v", fmt.Sprintf("%s/%s", repo.Author, repo.Name), err)
-					}
+				if timeoutCtx.Err() != context.DeadlineExceeded {
+					break
+				}
+				if !claim() {
+					// The parse finished in time, and is still saving its result
+					<-done
+					break
+				}
+				p.log.Warnf("[%s] Processing timed out after 30 seconds", fmt.Sprintf("%s/%s", timedOut.Author, timedOut.Name))
+				timedOut.State = "TIMEOUT"
+				if p.finish(&timedOut) {
+					p.notify(ctx, timedOut, 0)
 				}
 			case <-done:
 				// Processing completed within the timeout
@@ -138,7 +177,7 @@ func (p *Parser) StartParser(ctx context.Context, limit int) {
 		lastRepo = repo
 	}
 
-	if lastRepo != (models.RepositoryModel{}) {
+	if lastRepo.ID != 0 {
 		jobState, err := p.stateRepo.GetParserState()
 		if err != nil {
 			p.log.Errorf("[%s] Error getting job state: %v", fmt.Sprintf("%s/%s", lastRepo.Author, lastRepo.Name), err)
@@ -152,3 +191,24 @@ func (p *Parser) StartParser(ctx context.Context, limit int) {
 		}
 	}
 }
+
+// finish saves the result of parsing a repo, returning false if it wasn't saved.
+// Repos requeued while being parsed, e.g. after a push, are left PENDING so they are parsed again.
+func (p *Parser) finish(repo *models.RepositoryModel) bool {
+	finished, err := p.repoRepo.FinishRepo(repo)
+	if err != nil {
+		p.log.Errorf("[%s] Error updating repo state: %v", fmt.Sprintf("%s/%s", repo.Author, repo.Name), err)
+		return false
+	}
+	if !finished {
+		p.log.Infof("[%s] Repo was requeued while parsing, it will be parsed again", fmt.Sprintf("%s/%s", repo.Author, repo.Name))
+	}
+	return finished
+}
+
+// notify tells the notifier, if there is one, that a repo finished parsing
+func (p *Parser) notify(ctx context.Context, repo models.RepositoryModel, links int) {
+	if p.notifier != nil {
+		p.notifier.RepoParsed(ctx, repo, links)
+	}
+}
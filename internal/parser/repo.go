@@ -2,32 +2,52 @@ package parser
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/forge"
 	"github.com/jwtly10/googl-bye/internal/models"
 )
 
 // This file handles finding repos to clone locally and parse
 
+// RepoTokens gets the token to clone a private repo with
+type RepoTokens interface {
+	RepoToken(repo *models.RepositoryModel) (string, error)
+}
+
 type RepoParser struct {
-	git GitCmdLineI
-	log common.Logger
+	git        GitCmdLineI
+	log        common.Logger
+	forges     *forge.Registry
+	expandLink func(link string) (string, error)
+	// repoTokens is needed to parse private repos. Private repos fail to parse when nil.
+	repoTokens RepoTokens
 }
 
-func NewRepoParser(git GitCmdLineI, log common.Logger) *RepoParser {
+func NewRepoParser(git GitCmdLineI, log common.Logger, forges *forge.Registry) *RepoParser {
 	return &RepoParser{
-		git: git,
-		log: log,
+		git:        git,
+		log:        log,
+		forges:     forges,
+		expandLink: ExpandGooGlLink,
 	}
 }
 
+// WithRepoTokens sets where the tokens to clone private repos come from
+func (p *RepoParser) WithRepoTokens(repoTokens RepoTokens) *RepoParser {
+	p.repoTokens = repoTokens
+	return p
+}
+
 func (p *RepoParser) ParseRepository(repo models.RepositoryModel) ([]models.ParserLinksModel, error) {
 	p.log.Infof("[%s] Parsing repo", fmt.Sprintf("%s/%s", repo.Author, repo.Name))
 	tempDir, err := os.MkdirTemp("", fmt.Sprintf("%s%s%s%s%s", "repo-clone-", repo.Author, "-", repo.Name, "-"))
@@ -36,14 +56,38 @@ func (p *RepoParser) ParseRepository(repo models.RepositoryModel) ([]models.Pars
 	}
 	defer os.RemoveAll(tempDir)
 
+	var token string
+	if repo.Private {
+		if p.repoTokens == nil {
+			return nil, fmt.Errorf("unable to clone private repo without a token")
+		}
+		token, err = p.repoTokens.RepoToken(&repo)
+		if err != nil {
+			return nil, fmt.Errorf("error getting token for private repo: %w", err)
+		}
+	}
+
+	repoForge, err := p.forges.ForRepo(&repo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tokens are only ever sent to the forge's own clone url, never one that was saved with the repo
+	cloneUrl := repo.CloneUrl
+	if token != "" {
+		cloneUrl = repoForge.CloneURL(repo.Author, repo.Name)
+	}
+
 	// Clone the repository
-	branch, err := p.git.Clone(repo.CloneUrl, tempDir)
+	branch, err := p.git.Clone(cloneUrl, tempDir, token)
 	if err != nil {
 		return nil, err
 	}
 
 	// Parse the files of cloned repository
-	links, err := p.parseRepositoryFiles(repo, tempDir, branch)
+	links, err := p.parseRepositoryFiles(context.Background(), repo, tempDir, func(relPath string, lineNumber int) string {
+		return repoForge.BlobURL(repo.Author, repo.Name, branch, filepath.ToSlash(relPath), lineNumber)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -51,12 +95,35 @@ func (p *RepoParser) ParseRepository(repo models.RepositoryModel) ([]models.Pars
 	return links, nil
 }
 
+// ParseDirectory parses files that are already on disk (a local checkout or an extracted archive)
+// Links found will not have a forge url, as the files are not from a known repository.
+// Parsing stops with the context's error once it is done.
+func (p *RepoParser) ParseDirectory(ctx context.Context, name, dir string) ([]models.ParserLinksModel, error) {
+	p.log.Infof("[%s] Parsing directory '%s'", name, dir)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	return p.parseRepositoryFiles(ctx, models.RepositoryModel{Name: name}, dir, nil)
+}
+
 const maxFileSizeMB = 10
 
-func (p *RepoParser) parseRepositoryFiles(repo models.RepositoryModel, dest string, branch string) ([]models.ParserLinksModel, error) {
+// parseRepositoryFiles walks dest looking for links. linkUrl (optional) generates the url to view the link on its forge.
+func (p *RepoParser) parseRepositoryFiles(ctx context.Context, repo models.RepositoryModel, dest string, linkUrl func(relPath string, lineNumber int) string) ([]models.ParserLinksModel, error) {
 	p.log.Infof("[%s] Parsing files", fmt.Sprintf("%s/%s", repo.Author, repo.Name))
 	var foundLinks []models.ParserLinksModel
 
+	// Repos that were pushed to only need their changed files parsed
+	changedFiles := make(map[string]bool)
+	for _, file := range repo.ChangedFiles {
+		changedFiles[file] = true
+	}
+
 	// TODO: Review the error handling
 	// Currently if we find an error parsing a file, we just log it and continue
 	// The application can still function as intended if a few files are unable to be processed
@@ -78,11 +145,22 @@ func (p *RepoParser) parseRepositoryFiles(repo models.RepositoryModel, dest stri
 			return nil
 		}
 
-		// Skip directories
-		if info.IsDir() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Skip directories, and symlinks as they can point outside of dest
+		if !info.Mode().IsRegular() {
 			return nil
 		}
 
+		if len(changedFiles) > 0 {
+			relPath, _ := filepath.Rel(dest, path)
+			if !changedFiles[filepath.ToSlash(relPath)] {
+				return nil
+			}
+		}
+
 		// Check file size
 		if info.Size() > int64(maxFileSizeMB*1024*1024) {
 			p.log.Infof("[%s] Skipping large file: %s (%.2f MB)", fmt.Sprintf("%s/%s", repo.Author, repo.Name), path, float64(info.Size())/(1024*1024))
@@ -102,6 +180,15 @@ func (p *RepoParser) parseRepositoryFiles(repo models.RepositoryModel, dest stri
 		// Although current implementation works well, as we are limiting max file size and it also makes getting line numbers trivial
 		scanner := bufio.NewScanner(file)
 		lineNumber := 0
+		relPath, _ := filepath.Rel(dest, path)
+		classifier := newLinkClassifier(relPath)
+		snippets := newSnippetCollector(func(linkIndex int, s *snippet) {
+			foundLinks[linkIndex].Snippet = s.String()
+			foundLinks[linkIndex].SnippetStartLine = s.StartLine
+			foundLinks[linkIndex].MatchStart = s.MatchStart
+			foundLinks[linkIndex].MatchEnd = s.MatchEnd
+		})
+		defer snippets.flush()
 
 		errorsInFile := 0
 		for scanner.Scan() {
@@ -109,27 +196,45 @@ func (p *RepoParser) parseRepositoryFiles(repo models.RepositoryModel, dest stri
 			line := scanner.Text()
 
 			// Check if the line contains a goo.gl link
+			url, col := "", -1
 			if strings.Contains(line, "goo.gl/") {
-				relPath, _ := filepath.Rel(dest, path)
-				url := extractGooGlLink(line)
-				expandedUrl, err := expandGooGlLink(url)
+				url, col = findGooGlLink(line)
+			}
+
+			// The classifier must see every line, so it can follow comments and strings that span lines
+			classification := classifier.next(line, col)
+			snippets.next(line)
+
+			if url != "" {
+				// Expanding is the slow part of parsing, so check if we should stop before each link
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				expandedUrl, err := p.expandLink(url)
 				if err != nil {
 					p.log.Errorf("[%s] Error expanding url: '%s': %v", fmt.Sprintf("%s/%s", repo.Author, repo.Name), url, err)
 					expandedUrl = fmt.Sprintf("ERROR: %s", err.Error())
 				}
+				githubUrl := ""
+				if linkUrl != nil {
+					githubUrl = linkUrl(relPath, lineNumber)
+				}
 				foundLinks = append(foundLinks, models.ParserLinksModel{
-					Url:         url,
-					ExpandedUrl: expandedUrl,
-					File:        relPath,
-					LineNumber:  lineNumber,
-					GithubUrl:   generateGithubUrlOfUrl(&repo, branch, relPath, lineNumber),
-					Path:        path,
+					Url:            url,
+					ExpandedUrl:    expandedUrl,
+					File:           relPath,
+					LineNumber:     lineNumber,
+					ColumnNumber:   utf8.RuneCountInString(line[:col]) + 1,
+					Classification: classification,
+					GithubUrl:      githubUrl,
+					Path:           path,
 				})
+				snippets.start(len(foundLinks)-1, lineNumber, line, col, col+len(url))
 			}
+			snippets.advance(line)
 		}
 
 		if err := scanner.Err(); err != nil {
-			relPath, _ := filepath.Rel(dest, path)
 			// This error happens alot and is out of our control
 			// (We can increase the buffer size of the scanner, but chosing against this for now)
 			// TODO: Review this
@@ -153,51 +258,33 @@ func (p *RepoParser) parseRepositoryFiles(repo models.RepositoryModel, dest stri
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("error walking the path %s: %v", dest, err)
+		return nil, fmt.Errorf("error walking the path %s: %w", dest, err)
 	}
 
 	return foundLinks, nil
 }
 
-func generateGithubUrlOfUrl(repo *models.RepositoryModel, branch, filePath string, lineNumber int) string {
-	// Ensure the owner and name are available in the RepositoryModel
-	owner := repo.Author // Assuming Author is used for the owner
-	name := repo.Name
+var gooGlLinkRegex = regexp.MustCompile(`(?i)(?:https?://)?goo\.gl(?:/forms)?/[a-zA-Z0-9_-]+`)
 
-	// URL encode the file path to handle special characters
-	encodedFilePath := url.PathEscape(filePath)
-
-	// Construct the base GitHub URL
-	githubUrl := fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s",
-		owner, name, branch, encodedFilePath)
-
-	// Check if the file is a Markdown file
-	isMarkdown := strings.HasSuffix(strings.ToLower(filePath), ".md")
-
-	// Add ?plain=1 for Markdown files
-	if isMarkdown {
-		githubUrl += "?plain=1"
-	}
+func extractGooGlLink(line string) string {
+	match, _ := findGooGlLink(line)
+	return match
+}
 
-	// Add line number if provided
-	if lineNumber > 0 {
-		if isMarkdown {
-			githubUrl += fmt.Sprintf("#L%d", lineNumber)
-		} else {
-			githubUrl += fmt.Sprintf("#L%d", lineNumber)
-		}
+// findGooGlLink returns the first goo.gl link in the line and its byte offset, or -1 if there is none
+func findGooGlLink(line string) (string, int) {
+	loc := gooGlLinkRegex.FindStringIndex(line)
+	if loc == nil {
+		return "", -1
 	}
-
-	return githubUrl
+	return line[loc[0]:loc[1]], loc[0]
 }
 
-func extractGooGlLink(line string) string {
-	re := regexp.MustCompile(`(?i)(?:https?://)?goo\.gl(?:/forms)?/[a-zA-Z0-9_-]+`)
-	match := re.FindString(line)
-	return match
-}
+// expandTimeout limits how long expanding a link can take, so an unresponsive server can't hold up a parse
+const expandTimeout = 10 * time.Second
 
-func expandGooGlLink(link string) (string, error) {
+// ExpandGooGlLink follows the redirect of a goo.gl link, returning the url it points to
+func ExpandGooGlLink(link string) (string, error) {
 	// Check that the url starts with https
 	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
 		link = "https://" + link
@@ -210,6 +297,7 @@ func expandGooGlLink(link string) (string, error) {
 
 	// Create a new HTTP client that doesn't automatically follow redirects
 	client := &http.Client{
+		Timeout: expandTimeout,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
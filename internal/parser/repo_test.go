@@ -1,9 +1,13 @@
 package parser
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/forge"
 	"github.com/jwtly10/googl-bye/internal/models"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap/zapcore"
@@ -13,7 +17,7 @@ func TestParseRepository(t *testing.T) {
 	logger := common.NewLogger(false, zapcore.DebugLevel)
 	git := NewGitCmdLine(logger)
 
-	parser := NewRepoParser(git, logger)
+	parser := NewRepoParser(git, logger, forge.NewRegistry(forge.NewGithubForge(nil)))
 
 	repo := models.RepositoryModel{
 		Name:     "googl-bye-test",
@@ -52,3 +56,158 @@ func TestParseRepository(t *testing.T) {
 	assert.Equal(t, 7, links[3].LineNumber)
 	assert.Equal(t, "https://github.com/jwtly10/googl-bye-test/blob/main/main.go#L7", links[3].GithubUrl)
 }
+
+func TestParseRepositoryFilesClassifiesLinks(t *testing.T) {
+	logger := common.NewLogger(false, zapcore.DebugLevel)
+	parser := NewRepoParser(NewGitCmdLine(logger), logger, forge.NewRegistry(forge.NewGithubForge(nil)))
+	parser.expandLink = func(link string) (string, error) {
+		return "https://example.com", nil
+	}
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\n// https://goo.gl/comment\nvar u = \"https://goo.gl/string\"\n\nfunc main() {}\n\n// end\n"), 0644)
+	assert.NoError(t, err)
+
+	repo := models.RepositoryModel{Name: "repo", Author: "author"}
+	links, err := parser.parseRepositoryFiles(context.Background(), repo, dir, nil)
+	assert.NoError(t, err)
+	assert.Len(t, links, 2)
+
+	assert.Equal(t, "https://goo.gl/comment", links[0].Url)
+	assert.Equal(t, models.LinkInComment, links[0].Classification)
+	assert.Equal(t, 4, links[0].ColumnNumber)
+	assert.Equal(t, "package main\n\n// https://goo.gl/comment\nvar u = \"https://goo.gl/string\"\n", links[0].Snippet)
+	assert.Equal(t, 1, links[0].SnippetStartLine)
+	assert.Equal(t, "https://goo.gl/comment", links[0].Snippet[links[0].MatchStart:links[0].MatchEnd])
+
+	assert.Equal(t, "https://goo.gl/string", links[1].Url)
+	assert.Equal(t, models.LinkInString, links[1].Classification)
+	assert.Equal(t, 10, links[1].ColumnNumber)
+	assert.Equal(t, "https://example.com", links[1].ExpandedUrl)
+	assert.Equal(t, "\n// https://goo.gl/comment\nvar u = \"https://goo.gl/string\"\n\nfunc main() {}", links[1].Snippet)
+	assert.Equal(t, 2, links[1].SnippetStartLine)
+	assert.Equal(t, "https://goo.gl/string", links[1].Snippet[links[1].MatchStart:links[1].MatchEnd])
+}
+
+func TestParseRepositoryFilesCountsColumnsInCharacters(t *testing.T) {
+	logger := common.NewLogger(false, zapcore.DebugLevel)
+	parser := NewRepoParser(NewGitCmdLine(logger), logger, forge.NewRegistry(forge.NewGithubForge(nil)))
+	parser.expandLink = func(link string) (string, error) {
+		return "https://example.com", nil
+	}
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("// 界 https://goo.gl/abc\n"), 0644))
+
+	links, err := parser.parseRepositoryFiles(context.Background(), models.RepositoryModel{Name: "repo", Author: "author"}, dir, nil)
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+	assert.Equal(t, 6, links[0].ColumnNumber)
+	assert.Equal(t, "https://goo.gl/abc", links[0].Snippet[links[0].MatchStart:links[0].MatchEnd])
+}
+
+func TestParseRepositoryFilesOnlyParsesChangedFiles(t *testing.T) {
+	logger := common.NewLogger(false, zapcore.DebugLevel)
+	parser := NewRepoParser(NewGitCmdLine(logger), logger, forge.NewRegistry(forge.NewGithubForge(nil)))
+	parser.expandLink = func(link string) (string, error) {
+		return "https://example.com", nil
+	}
+
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("// https://goo.gl/unchanged\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "README.md"), []byte("https://goo.gl/changed\n"), 0644))
+
+	repo := models.RepositoryModel{Name: "repo", Author: "author", ChangedFiles: []string{"docs/README.md", "deleted.go"}}
+	links, err := parser.parseRepositoryFiles(context.Background(), repo, dir, nil)
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+	assert.Equal(t, "https://goo.gl/changed", links[0].Url)
+
+	// Without changed files, the whole repo is parsed
+	repo.ChangedFiles = nil
+	links, err = parser.parseRepositoryFiles(context.Background(), repo, dir, nil)
+	assert.NoError(t, err)
+	assert.Len(t, links, 2)
+}
+
+func TestParseRepositoryFilesSkipsSymlinks(t *testing.T) {
+	logger := common.NewLogger(false, zapcore.DebugLevel)
+	parser := NewRepoParser(NewGitCmdLine(logger), logger, forge.NewRegistry(forge.NewGithubForge(nil)))
+	parser.expandLink = func(link string) (string, error) {
+		return "https://example.com", nil
+	}
+
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	assert.NoError(t, os.WriteFile(outside, []byte("https://goo.gl/outside\n"), 0644))
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("// https://goo.gl/inside\n"), 0644))
+	assert.NoError(t, os.Symlink(outside, filepath.Join(dir, "link.txt")))
+	assert.NoError(t, os.Symlink(filepath.Dir(outside), filepath.Join(dir, "linked-dir")))
+
+	links, err := parser.ParseDirectory(context.Background(), "dir", dir)
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+	assert.Equal(t, "https://goo.gl/inside", links[0].Url)
+}
+
+func TestParseDirectoryStopsWhenContextIsDone(t *testing.T) {
+	logger := common.NewLogger(false, zapcore.DebugLevel)
+	parser := NewRepoParser(NewGitCmdLine(logger), logger, forge.NewRegistry(forge.NewGithubForge(nil)))
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("// https://goo.gl/abc\n"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	parser.expandLink = func(link string) (string, error) {
+		cancel()
+		return "https://example.com", nil
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "other.go"), []byte("// https://goo.gl/xyz\n"), 0644))
+
+	_, err := parser.ParseDirectory(ctx, "dir", dir)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+type fakeGit struct {
+	url   string
+	token string
+}
+
+func (g *fakeGit) Clone(url, destination, token string) (string, error) {
+	g.url = url
+	g.token = token
+	return "main", nil
+}
+
+type fakeRepoTokens map[int]string
+
+func (t fakeRepoTokens) RepoToken(repo *models.RepositoryModel) (string, error) {
+	return t[*repo.OwnerUserID], nil
+}
+
+func TestParseRepositoryClonesPrivateReposWithOwnersToken(t *testing.T) {
+	logger := common.NewLogger(false, zapcore.DebugLevel)
+	git := &fakeGit{}
+	forges := forge.NewRegistry(forge.NewGithubForge(nil))
+
+	owner := 7
+	repo := models.RepositoryModel{Name: "secret", Author: "author", CloneUrl: "https://attacker.example.com/secret.git", Private: true, OwnerUserID: &owner}
+
+	// Private repos can't be cloned without tokens
+	_, err := NewRepoParser(git, logger, forges).ParseRepository(repo)
+	assert.Error(t, err)
+
+	_, err = NewRepoParser(git, logger, forges).WithRepoTokens(fakeRepoTokens{7: "owner-token"}).ParseRepository(repo)
+	assert.NoError(t, err)
+	assert.Equal(t, "owner-token", git.token)
+	// The token is only sent to the forge, not the saved clone url
+	assert.Equal(t, "https://github.com/author/secret.git", git.url)
+
+	// Public repos are cloned without a token
+	repo.Private = false
+	_, err = NewRepoParser(git, logger, forges).WithRepoTokens(fakeRepoTokens{7: "owner-token"}).ParseRepository(repo)
+	assert.NoError(t, err)
+	assert.Equal(t, "", git.token)
+}
@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// This file handles capturing the lines of source surrounding each link found in a file
+
+const (
+	// snippetContextLines is the number of lines captured either side of the matched line
+	snippetContextLines = 2
+	// maxSnippetLineLength is the max number of bytes kept from a single line of the snippet
+	maxSnippetLineLength = 300
+)
+
+// snippet is a few lines of source around a match, with the match located by byte offsets
+type snippet struct {
+	StartLine  int
+	Lines      []string
+	MatchStart int
+	MatchEnd   int
+}
+
+func (s *snippet) String() string {
+	return strings.Join(s.Lines, "\n")
+}
+
+type pendingSnippet struct {
+	linkIndex int
+	snippet   *snippet
+	remaining int
+}
+
+// snippetCollector follows a file line by line, remembering the previous lines so that
+// snippets can be started when a link is found, and finished once the following lines are read.
+type snippetCollector struct {
+	previous []string
+	pending  []*pendingSnippet
+	done     func(linkIndex int, s *snippet)
+}
+
+func newSnippetCollector(done func(linkIndex int, s *snippet)) *snippetCollector {
+	return &snippetCollector{done: done}
+}
+
+// next adds a line to any snippets still waiting on context lines.
+// It must be called for each line of the file before any call to start for that line.
+func (c *snippetCollector) next(line string) {
+	stillPending := c.pending[:0]
+	for _, p := range c.pending {
+		p.snippet.Lines = append(p.snippet.Lines, clipLine(line))
+		p.remaining--
+		if p.remaining == 0 {
+			c.done(p.linkIndex, p.snippet)
+		} else {
+			stillPending = append(stillPending, p)
+		}
+	}
+	c.pending = stillPending
+}
+
+// start begins a snippet for a match at [start, end) in line
+func (c *snippetCollector) start(linkIndex int, lineNumber int, line string, start, end int) {
+	s := &snippet{
+		StartLine: lineNumber - len(c.previous),
+	}
+
+	offset := 0
+	for _, prev := range c.previous {
+		s.Lines = append(s.Lines, prev)
+		offset += len(prev) + 1
+	}
+
+	clipped, matchStart, matchEnd := clipLineAround(line, start, end)
+	s.Lines = append(s.Lines, clipped)
+	s.MatchStart = offset + matchStart
+	s.MatchEnd = offset + matchEnd
+
+	c.pending = append(c.pending, &pendingSnippet{
+		linkIndex: linkIndex,
+		snippet:   s,
+		remaining: snippetContextLines,
+	})
+}
+
+// advance records the line as a previous line for snippets started later in the file.
+func (c *snippetCollector) advance(line string) {
+	c.previous = append(c.previous, clipLine(line))
+	if len(c.previous) > snippetContextLines {
+		c.previous = c.previous[1:]
+	}
+}
+
+// flush finishes any snippets at the end of the file
+func (c *snippetCollector) flush() {
+	for _, p := range c.pending {
+		c.done(p.linkIndex, p.snippet)
+	}
+	c.pending = nil
+	c.previous = nil
+}
+
+// cleanLine makes a line safe to store as text, replacing invalid UTF-8 and removing NUL bytes (which postgres rejects)
+func cleanLine(line string) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(line, "\uFFFD"), "\x00", "")
+}
+
+// clipLine cleans a context line and limits it to maxSnippetLineLength bytes, without splitting a character
+func clipLine(line string) string {
+	line = cleanLine(line)
+	if len(line) <= maxSnippetLineLength {
+		return line
+	}
+
+	to := maxSnippetLineLength
+	for to > 0 && !utf8.RuneStart(line[to]) {
+		to--
+	}
+	return line[:to]
+}
+
+// clipLineAround cleans a line and limits it to maxSnippetLineLength bytes while keeping the match at [start, end) in view,
+// without splitting a character. It returns the clipped line, and the byte offsets of the match in it.
+func clipLineAround(line string, start, end int) (string, int, int) {
+	// Clean each part separately so the match can still be found
+	before, match, after := cleanLine(line[:start]), cleanLine(line[start:end]), cleanLine(line[end:])
+	line = before + match + after
+	start, end = len(before), len(before)+len(match)
+	if len(line) <= maxSnippetLineLength {
+		return line, start, end
+	}
+
+	// Centre the window on the match
+	from := start - (maxSnippetLineLength-(end-start))/2
+	if from < 0 {
+		from = 0
+	}
+	to := from + maxSnippetLineLength
+	if to < end {
+		to = end
+	}
+	if to > len(line) {
+		to = len(line)
+		from = to - maxSnippetLineLength
+		if from > start {
+			from = start
+		}
+		if from < 0 {
+			from = 0
+		}
+	}
+
+	// Move the window inwards to the nearest character boundaries, the match starts and ends on one
+	for from < start && !utf8.RuneStart(line[from]) {
+		from++
+	}
+	for to > end && to < len(line) && !utf8.RuneStart(line[to]) {
+		to--
+	}
+
+	return line[from:to], start - from, end - from
+}
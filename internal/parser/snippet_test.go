@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnippetCollectorAtEndOfFile(t *testing.T) {
+	var got *snippet
+	c := newSnippetCollector(func(linkIndex int, s *snippet) {
+		got = s
+	})
+
+	lines := []string{"one", "two", "three goo.gl/abc"}
+	for i, line := range lines {
+		c.next(line)
+		if i == 2 {
+			c.start(0, i+1, line, 6, 16)
+		}
+		c.advance(line)
+	}
+	c.flush()
+
+	assert.NotNil(t, got)
+	assert.Equal(t, "one\ntwo\nthree goo.gl/abc", got.String())
+	assert.Equal(t, 1, got.StartLine)
+	assert.Equal(t, "goo.gl/abc", got.String()[got.MatchStart:got.MatchEnd])
+}
+
+func TestClipLineAroundKeepsMatch(t *testing.T) {
+	line := strings.Repeat("a", 500) + "goo.gl/abc" + strings.Repeat("b", 500)
+	clipped, start, end := clipLineAround(line, 500, 510)
+
+	assert.Len(t, clipped, maxSnippetLineLength)
+	assert.Equal(t, "goo.gl/abc", clipped[start:end])
+}
+
+func TestClipLineKeepsValidUTF8(t *testing.T) {
+	// Each character is 3 bytes, so clipping by bytes would split one
+	line := strings.Repeat("界", 200)
+	clipped := clipLine(line)
+
+	assert.True(t, utf8.ValidString(clipped))
+	assert.Equal(t, strings.Repeat("界", maxSnippetLineLength/3), clipped)
+
+	line = strings.Repeat("界", 200) + "goo.gl/abc" + strings.Repeat("界", 200)
+	clipped, start, end := clipLineAround(line, 600, 610)
+
+	assert.True(t, utf8.ValidString(clipped))
+	assert.LessOrEqual(t, len(clipped), maxSnippetLineLength)
+	assert.Equal(t, "goo.gl/abc", clipped[start:end])
+}
+
+func TestClipLineAroundCleansLine(t *testing.T) {
+	line := "a\x00b\xff goo.gl/abc \x00end"
+	clipped, start, end := clipLineAround(line, 5, 15)
+
+	assert.Equal(t, "ab\uFFFD goo.gl/abc end", clipped)
+	assert.Equal(t, "goo.gl/abc", clipped[start:end])
+	assert.Equal(t, "ab\uFFFD", clipLine("a\x00b\xff"))
+}
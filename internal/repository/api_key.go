@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/lib/pq"
+)
+
+type ApiKeyRepository interface {
+	CreateApiKey(key *models.ApiKeyModel) error
+	GetApiKeysForUser(userID int) ([]models.ApiKeyModel, error)
+	GetApiKeyByHash(keyHash string) (*models.ApiKeyModel, error)
+	RevokeApiKey(id, userID int, now time.Time) (bool, error)
+	TouchApiKey(id int, now time.Time) error
+}
+
+type sqlApiKeyRepository struct {
+	database *sql.DB
+}
+
+func NewApiKeyRepository(database *sql.DB) ApiKeyRepository {
+	return &sqlApiKeyRepository{database: database}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, last_used_at, revoked_at, created_at, updated_at`
+
+func (r *sqlApiKeyRepository) CreateApiKey(key *models.ApiKeyModel) error {
+	key.BeforeCreate()
+
+	query := `
+		INSERT INTO public.api_key_tb (user_id, name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at`
+
+	err := r.database.QueryRow(query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		pq.Array(key.Scopes),
+	).Scan(&key.ID, &key.CreatedAt, &key.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert api key: %w", err)
+	}
+
+	key.AfterCreate()
+	return nil
+}
+
+// GetApiKeysForUser gets all of a user's keys, including revoked ones, newest first
+func (r *sqlApiKeyRepository) GetApiKeysForUser(userID int) ([]models.ApiKeyModel, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM public.api_key_tb WHERE user_id = $1 ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.database.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.ApiKeyModel{}
+	for rows.Next() {
+		var key models.ApiKeyModel
+		if err := scanApiKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("error scanning api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// GetApiKeyByHash gets a key that hasn't been revoked. Returns nil if there is no such key.
+func (r *sqlApiKeyRepository) GetApiKeyByHash(keyHash string) (*models.ApiKeyModel, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM public.api_key_tb WHERE key_hash = $1 AND revoked_at IS NULL`
+
+	key := &models.ApiKeyModel{}
+	if err := scanApiKey(r.database.QueryRow(query, keyHash), key); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error querying api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// RevokeApiKey revokes one of a user's keys, returning false if the user has no such active key
+func (r *sqlApiKeyRepository) RevokeApiKey(id, userID int, now time.Time) (bool, error) {
+	query := `
+		UPDATE public.api_key_tb
+		SET revoked_at = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	res, err := r.database.Exec(query, id, userID, now)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// TouchApiKey records when a key was last used
+func (r *sqlApiKeyRepository) TouchApiKey(id int, now time.Time) error {
+	_, err := r.database.Exec(`UPDATE public.api_key_tb SET last_used_at = $2 WHERE id = $1`, id, now)
+	if err != nil {
+		return fmt.Errorf("failed to update api key last used: %w", err)
+	}
+	return nil
+}
+
+// scanApiKey scans a row selected with apiKeyColumns
+func scanApiKey(row interface{ Scan(dest ...any) error }, key *models.ApiKeyModel) error {
+	return row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		pq.Array(&key.Scopes),
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+		&key.UpdatedAt,
+	)
+}
@@ -0,0 +1,110 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/test"
+)
+
+func TestApiKeyRepository_Integration(t *testing.T) {
+	container, db, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+
+	userRepo := repository.NewUserRepository(db)
+	apiKeyRepo := repository.NewApiKeyRepository(db)
+
+	user := &models.UserModel{GithubID: 1, Login: "jwtly10", AccessToken: []byte("encrypted")}
+	if err := userRepo.SaveUser(user); err != nil {
+		t.Fatal(err)
+	}
+	other := &models.UserModel{GithubID: 2, Login: "other", AccessToken: []byte("encrypted")}
+	if err := userRepo.SaveUser(other); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	key := &models.ApiKeyModel{UserID: user.ID, Name: "ci", Prefix: "gb_abcdefgh", KeyHash: "hash", Scopes: []string{models.ScopeRead, models.ScopeSearch}}
+
+	t.Run("Create and get api key", func(t *testing.T) {
+		if err := apiKeyRepo.CreateApiKey(key); err != nil {
+			t.Fatalf("expected no error when creating api key but got %v", err)
+		}
+
+		loaded, err := apiKeyRepo.GetApiKeyByHash("hash")
+		if err != nil {
+			t.Fatalf("expected no error when getting api key but got %v", err)
+		}
+		if loaded == nil || loaded.UserID != user.ID || loaded.Name != "ci" {
+			t.Fatalf("expected api key 'ci' for user %d but got %v", user.ID, loaded)
+		}
+		if len(loaded.Scopes) != 2 || loaded.Scopes[0] != models.ScopeRead || loaded.Scopes[1] != models.ScopeSearch {
+			t.Errorf("expected scopes [read search] but got %v", loaded.Scopes)
+		}
+		if loaded.LastUsedAt != nil {
+			t.Errorf("expected new key to be unused but got %v", loaded.LastUsedAt)
+		}
+	})
+
+	t.Run("Touch api key", func(t *testing.T) {
+		if err := apiKeyRepo.TouchApiKey(key.ID, now); err != nil {
+			t.Fatalf("expected no error when touching api key but got %v", err)
+		}
+
+		keys, err := apiKeyRepo.GetApiKeysForUser(user.ID)
+		if err != nil {
+			t.Fatalf("expected no error when listing api keys but got %v", err)
+		}
+		if len(keys) != 1 {
+			t.Fatalf("expected 1 api key but got %d", len(keys))
+		}
+		if keys[0].LastUsedAt == nil || !keys[0].LastUsedAt.Equal(now) {
+			t.Errorf("expected key last used at %v but got %v", now, keys[0].LastUsedAt)
+		}
+	})
+
+	t.Run("Other users can't revoke a key", func(t *testing.T) {
+		revoked, err := apiKeyRepo.RevokeApiKey(key.ID, other.ID, now)
+		if err != nil {
+			t.Fatalf("expected no error when revoking api key but got %v", err)
+		}
+		if revoked {
+			t.Error("expected key of another user not to be revoked")
+		}
+	})
+
+	t.Run("Revoked keys are not returned", func(t *testing.T) {
+		revoked, err := apiKeyRepo.RevokeApiKey(key.ID, user.ID, now)
+		if err != nil {
+			t.Fatalf("expected no error when revoking api key but got %v", err)
+		}
+		if !revoked {
+			t.Error("expected key to be revoked")
+		}
+
+		loaded, err := apiKeyRepo.GetApiKeyByHash("hash")
+		if err != nil {
+			t.Errorf("expected no error when getting revoked api key but got %v", err)
+		}
+		if loaded != nil {
+			t.Errorf("expected no api key but got %v", loaded)
+		}
+
+		keys, err := apiKeyRepo.GetApiKeysForUser(user.ID)
+		if err != nil {
+			t.Fatalf("expected no error when listing api keys but got %v", err)
+		}
+		if len(keys) != 1 || keys[0].RevokedAt == nil {
+			t.Errorf("expected revoked key to still be listed but got %v", keys)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/lib/pq"
+)
+
+type DigestSubscriptionRepository interface {
+	CreateSubscription(sub *models.DigestSubscriptionModel) (bool, error)
+	ConfirmSubscription(token string) (bool, error)
+	GetSubscriptionsForUser(userID int) ([]models.DigestSubscriptionModel, error)
+	GetSubscriptions() ([]models.DigestSubscriptionModel, error)
+	UpdateLastDigest(id int, lastDigestAt time.Time) error
+	MarkLinksSent(id int, links []models.DigestSentLink) error
+	DeleteSubscription(id, userID int) (bool, error)
+	DeleteSubscriptionByToken(token string) (bool, error)
+}
+
+type sqlDigestSubscriptionRepository struct {
+	database *sql.DB
+}
+
+func NewDigestSubscriptionRepository(database *sql.DB) DigestSubscriptionRepository {
+	return &sqlDigestSubscriptionRepository{database: database}
+}
+
+const digestSubscriptionColumns = `id, email, author, user_id, unsubscribe_token, confirm_token, confirmed_at, last_digest_at, created_at, updated_at`
+
+// CreateSubscription subscribes an email to an author's digest, unconfirmed. If the user already has the same
+// subscription, it is kept as is and sub is set to it. Returns false if the subscription already existed.
+func (r *sqlDigestSubscriptionRepository) CreateSubscription(sub *models.DigestSubscriptionModel) (bool, error) {
+	sub.BeforeCreate()
+
+	// xmax is only 0 for rows that were inserted rather than updated
+	query := `
+		INSERT INTO public.digest_subscription_tb (email, author, user_id, unsubscribe_token, confirm_token)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		ON CONFLICT (user_id, email, author) DO UPDATE SET updated_at = digest_subscription_tb.updated_at
+		RETURNING ` + digestSubscriptionColumns + `, (xmax = 0)`
+
+	var created bool
+	err := scanDigestSubscription(r.database.QueryRow(query,
+		sub.Email,
+		sub.Author,
+		sub.UserID,
+		sub.UnsubscribeToken,
+		sub.ConfirmToken,
+	), sub, &created)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert digest subscription: %w", err)
+	}
+
+	sub.AfterCreate()
+	return created, nil
+}
+
+// ConfirmSubscription confirms the subscription with a confirm token, returning false if there is none.
+// Only links found after it is confirmed are sent.
+func (r *sqlDigestSubscriptionRepository) ConfirmSubscription(token string) (bool, error) {
+	query := `
+		UPDATE public.digest_subscription_tb
+		SET confirmed_at = CURRENT_TIMESTAMP, confirm_token = NULL, last_digest_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE confirm_token = $1`
+	res, err := r.database.Exec(query, token)
+	if err != nil {
+		return false, fmt.Errorf("failed to confirm digest subscription: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to confirm digest subscription: %w", err)
+	}
+	return affected > 0, nil
+}
+
+func (r *sqlDigestSubscriptionRepository) GetSubscriptionsForUser(userID int) ([]models.DigestSubscriptionModel, error) {
+	query := `SELECT ` + digestSubscriptionColumns + ` FROM public.digest_subscription_tb WHERE user_id = $1 ORDER BY id`
+	return r.querySubscriptions(query, userID)
+}
+
+// GetSubscriptions gets every confirmed subscription, for sending digests
+func (r *sqlDigestSubscriptionRepository) GetSubscriptions() ([]models.DigestSubscriptionModel, error) {
+	query := `SELECT ` + digestSubscriptionColumns + ` FROM public.digest_subscription_tb WHERE confirmed_at IS NOT NULL ORDER BY id`
+	return r.querySubscriptions(query)
+}
+
+// UpdateLastDigest records when the newest link sent to a subscription was found
+func (r *sqlDigestSubscriptionRepository) UpdateLastDigest(id int, lastDigestAt time.Time) error {
+	query := `UPDATE public.digest_subscription_tb SET last_digest_at = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+	if _, err := r.database.Exec(query, id, lastDigestAt); err != nil {
+		return fmt.Errorf("failed to update digest subscription: %w", err)
+	}
+	return nil
+}
+
+// MarkLinksSent records the links sent to a subscription, so they aren't sent again when they are found again
+func (r *sqlDigestSubscriptionRepository) MarkLinksSent(id int, links []models.DigestSentLink) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	repoIDs := make([]int64, len(links))
+	urls := make([]string, len(links))
+	files := make([]string, len(links))
+	lines := make([]int64, len(links))
+	for i, link := range links {
+		repoIDs[i] = int64(link.RepoID)
+		urls[i] = link.Url
+		files[i] = link.File
+		lines[i] = int64(link.LineNumber)
+	}
+
+	query := `
+		INSERT INTO public.digest_sent_link_tb (subscription_id, repo_id, url, file, line_number)
+		SELECT $1, * FROM unnest($2::int[], $3::text[], $4::text[], $5::int[])
+		ON CONFLICT DO NOTHING`
+	if _, err := r.database.Exec(query, id, pq.Array(repoIDs), pq.Array(urls), pq.Array(files), pq.Array(lines)); err != nil {
+		return fmt.Errorf("failed to record sent digest links: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubscription deletes one of a user's subscriptions, returning false if the user has no such subscription
+func (r *sqlDigestSubscriptionRepository) DeleteSubscription(id, userID int) (bool, error) {
+	return r.delete(`DELETE FROM public.digest_subscription_tb WHERE id = $1 AND user_id = $2`, id, userID)
+}
+
+// DeleteSubscriptionByToken deletes the subscription with an unsubscribe token, returning false if there is none
+func (r *sqlDigestSubscriptionRepository) DeleteSubscriptionByToken(token string) (bool, error) {
+	return r.delete(`DELETE FROM public.digest_subscription_tb WHERE unsubscribe_token = $1`, token)
+}
+
+func (r *sqlDigestSubscriptionRepository) delete(query string, args ...any) (bool, error) {
+	res, err := r.database.Exec(query, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete digest subscription: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete digest subscription: %w", err)
+	}
+	return affected > 0, nil
+}
+
+func (r *sqlDigestSubscriptionRepository) querySubscriptions(query string, args ...any) ([]models.DigestSubscriptionModel, error) {
+	rows, err := r.database.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying digest subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []models.DigestSubscriptionModel{}
+	for rows.Next() {
+		var sub models.DigestSubscriptionModel
+		if err := scanDigestSubscription(rows, &sub); err != nil {
+			return nil, fmt.Errorf("error scanning digest subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating digest subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+// scanDigestSubscription scans a row selected with digestSubscriptionColumns, followed by any extra columns
+func scanDigestSubscription(row interface{ Scan(dest ...any) error }, sub *models.DigestSubscriptionModel, extra ...any) error {
+	var confirmToken sql.NullString
+	dest := append([]any{
+		&sub.ID,
+		&sub.Email,
+		&sub.Author,
+		&sub.UserID,
+		&sub.UnsubscribeToken,
+		&confirmToken,
+		&sub.ConfirmedAt,
+		&sub.LastDigestAt,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	sub.ConfirmToken = confirmToken.String
+	return nil
+}
@@ -0,0 +1,141 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/test"
+)
+
+func TestDigestSubscriptionRepository_Integration(t *testing.T) {
+	container, db, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+
+	userRepo := repository.NewUserRepository(db)
+	subRepo := repository.NewDigestSubscriptionRepository(db)
+
+	owner := &models.UserModel{GithubID: 1, Login: "jwtly10", AccessToken: []byte("encrypted")}
+	if err := userRepo.SaveUser(owner); err != nil {
+		t.Fatal(err)
+	}
+	other := &models.UserModel{GithubID: 2, Login: "other", AccessToken: []byte("encrypted")}
+	if err := userRepo.SaveUser(other); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := &models.DigestSubscriptionModel{Email: "me@example.com", Author: "jwtly10", UserID: owner.ID, UnsubscribeToken: "token-1", ConfirmToken: "confirm-1"}
+
+	t.Run("Create subscription", func(t *testing.T) {
+		created, err := subRepo.CreateSubscription(sub)
+		if err != nil {
+			t.Fatalf("expected no error when creating subscription but got %v", err)
+		}
+		if !created || sub.ID == 0 || sub.LastDigestAt.IsZero() || sub.ConfirmedAt != nil {
+			t.Errorf("expected new unconfirmed subscription with id and last digest time but got %v, %+v", created, sub)
+		}
+
+		// Subscribing again keeps the existing subscription and its tokens
+		again := &models.DigestSubscriptionModel{Email: "me@example.com", Author: "jwtly10", UserID: owner.ID, UnsubscribeToken: "token-2", ConfirmToken: "confirm-2"}
+		created, err = subRepo.CreateSubscription(again)
+		if err != nil {
+			t.Fatalf("expected no error when subscribing again but got %v", err)
+		}
+		if created || again.ID != sub.ID || again.UnsubscribeToken != "token-1" || again.ConfirmToken != "confirm-1" {
+			t.Errorf("expected existing subscription but got %v, %+v", created, again)
+		}
+
+		subs, err := subRepo.GetSubscriptionsForUser(owner.ID)
+		if err != nil {
+			t.Fatalf("expected no error when listing subscriptions but got %v", err)
+		}
+		if len(subs) != 1 {
+			t.Errorf("expected 1 subscription but got %d", len(subs))
+		}
+	})
+
+	t.Run("Only confirmed subscriptions are sent digests", func(t *testing.T) {
+		subs, err := subRepo.GetSubscriptions()
+		if err != nil {
+			t.Fatalf("expected no error when listing subscriptions but got %v", err)
+		}
+		if len(subs) != 0 {
+			t.Errorf("expected unconfirmed subscription not to be sent digests but got %+v", subs)
+		}
+
+		confirmed, err := subRepo.ConfirmSubscription("confirm-2")
+		if err != nil || confirmed {
+			t.Errorf("expected unknown token not to confirm, got %v, %v", confirmed, err)
+		}
+		confirmed, err = subRepo.ConfirmSubscription("confirm-1")
+		if err != nil || !confirmed {
+			t.Fatalf("expected subscription to be confirmed, got %v, %v", confirmed, err)
+		}
+		// Confirm tokens only work once
+		confirmed, err = subRepo.ConfirmSubscription("confirm-1")
+		if err != nil || confirmed {
+			t.Errorf("expected confirmed subscription not to be confirmed again, got %v, %v", confirmed, err)
+		}
+
+		subs, err = subRepo.GetSubscriptions()
+		if err != nil {
+			t.Fatalf("expected no error when listing subscriptions but got %v", err)
+		}
+		if len(subs) != 1 || subs[0].ConfirmedAt == nil || subs[0].ConfirmToken != "" {
+			t.Errorf("expected confirmed subscription but got %+v", subs)
+		}
+	})
+
+	t.Run("Mark links sent", func(t *testing.T) {
+		links := []models.DigestSentLink{
+			{RepoID: 1, Url: "https://goo.gl/abc", File: "README.md", LineNumber: 3},
+			{RepoID: 1, Url: "https://goo.gl/xyz", File: "main.go", LineNumber: 10},
+		}
+		if err := subRepo.MarkLinksSent(sub.ID, links); err != nil {
+			t.Fatalf("expected no error when marking links sent but got %v", err)
+		}
+		// Links already sent are ignored
+		if err := subRepo.MarkLinksSent(sub.ID, links[:1]); err != nil {
+			t.Fatalf("expected no error when marking links sent again but got %v", err)
+		}
+	})
+
+	t.Run("Update last digest", func(t *testing.T) {
+		at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		if err := subRepo.UpdateLastDigest(sub.ID, at); err != nil {
+			t.Fatalf("expected no error when updating last digest but got %v", err)
+		}
+
+		subs, err := subRepo.GetSubscriptions()
+		if err != nil {
+			t.Fatalf("expected no error when listing subscriptions but got %v", err)
+		}
+		if len(subs) != 1 || !subs[0].LastDigestAt.Equal(at) {
+			t.Errorf("expected last digest at %v but got %+v", at, subs)
+		}
+	})
+
+	t.Run("Delete subscription", func(t *testing.T) {
+		deleted, err := subRepo.DeleteSubscription(sub.ID, other.ID)
+		if err != nil || deleted {
+			t.Errorf("expected another user's subscription not to be deleted, got %v, %v", deleted, err)
+		}
+
+		deleted, err = subRepo.DeleteSubscriptionByToken("token-1")
+		if err != nil || !deleted {
+			t.Errorf("expected subscription to be deleted by token, got %v, %v", deleted, err)
+		}
+
+		deleted, err = subRepo.DeleteSubscription(sub.ID, owner.ID)
+		if err != nil || deleted {
+			t.Errorf("expected subscription to already be deleted, got %v, %v", deleted, err)
+		}
+	})
+}
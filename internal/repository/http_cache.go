@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+type HttpCacheRepository interface {
+	GetCachedResponse(key string) (*models.HttpCacheModel, error)
+	SaveCachedResponse(entry *models.HttpCacheModel) error
+	DeleteCachedResponses(before time.Time, maxSize int64) (int64, error)
+}
+
+type sqlHttpCacheRepository struct {
+	database *sql.DB
+}
+
+func NewHttpCacheRepository(database *sql.DB) HttpCacheRepository {
+	return &sqlHttpCacheRepository{database: database}
+}
+
+// GetCachedResponse gets the cached response for a request key. Returns nil if nothing is cached.
+func (r *sqlHttpCacheRepository) GetCachedResponse(key string) (*models.HttpCacheModel, error) {
+	query := `SELECT id, key, url, etag, last_modified, status_code, header, body, created_at, updated_at FROM public.http_cache_tb WHERE key = $1`
+
+	entry := &models.HttpCacheModel{}
+	var headerJSON []byte
+	err := r.database.QueryRow(query, key).Scan(
+		&entry.ID,
+		&entry.Key,
+		&entry.Url,
+		&entry.ETag,
+		&entry.LastModified,
+		&entry.StatusCode,
+		&headerJSON,
+		&entry.Body,
+		&entry.CreatedAt,
+		&entry.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error querying cached response: %w", err)
+	}
+
+	if err := json.Unmarshal(headerJSON, &entry.Header); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal header: %w", err)
+	}
+
+	return entry, nil
+}
+
+// SaveCachedResponse upserts the cached response by key
+func (r *sqlHttpCacheRepository) SaveCachedResponse(entry *models.HttpCacheModel) error {
+	entry.BeforeCreate()
+
+	headerJSON, err := json.Marshal(entry.Header)
+	if err != nil {
+		return fmt.Errorf("failed marshal header: %w", err)
+	}
+
+	query := `
+		INSERT INTO public.http_cache_tb (key, url, etag, last_modified, status_code, header, body)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (key) DO UPDATE
+		SET url = EXCLUDED.url,
+			etag = EXCLUDED.etag,
+			last_modified = EXCLUDED.last_modified,
+			status_code = EXCLUDED.status_code,
+			header = EXCLUDED.header,
+			body = EXCLUDED.body,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at, updated_at`
+
+	err = r.database.QueryRow(query,
+		entry.Key,
+		entry.Url,
+		entry.ETag,
+		entry.LastModified,
+		entry.StatusCode,
+		headerJSON,
+		entry.Body,
+	).Scan(&entry.ID, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert cached response: %w", err)
+	}
+
+	entry.AfterCreate()
+	return nil
+}
+
+// DeleteCachedResponses deletes responses last saved before a time, then the least recently saved responses
+// until the bodies of those left total at most maxSize bytes. Returns how many were deleted.
+func (r *sqlHttpCacheRepository) DeleteCachedResponses(before time.Time, maxSize int64) (int64, error) {
+	query := `
+		DELETE FROM public.http_cache_tb
+		WHERE updated_at < $1
+			OR id IN (
+				SELECT id FROM (
+					SELECT id, SUM(octet_length(body)) OVER (ORDER BY updated_at DESC, id DESC) AS total
+					FROM public.http_cache_tb
+				) c
+				WHERE c.total > $2
+			)`
+
+	res, err := r.database.Exec(query, before, maxSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete cached responses: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,102 @@
+package repository_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/test"
+)
+
+func TestHttpCacheRepository_Integration(t *testing.T) {
+	container, db, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+
+	httpCacheRepo := repository.NewHttpCacheRepository(db)
+
+	t.Run("Get missing response", func(t *testing.T) {
+		entry, err := httpCacheRepo.GetCachedResponse("missing")
+		if err != nil {
+			t.Errorf("expected no error when getting missing response but got %v", err)
+		}
+		if entry != nil {
+			t.Errorf("expected no cached response but got %v", entry)
+		}
+	})
+
+	t.Run("Save and replace response", func(t *testing.T) {
+		entry := &models.HttpCacheModel{
+			Key:        "key",
+			Url:        "https://api.github.com/users/jwtly10",
+			ETag:       `"v1"`,
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       []byte(`{"login": "jwtly10"}`),
+		}
+		if err := httpCacheRepo.SaveCachedResponse(entry); err != nil {
+			t.Fatalf("expected no error when saving response but got %v", err)
+		}
+
+		entry.ETag = `"v2"`
+		if err := httpCacheRepo.SaveCachedResponse(entry); err != nil {
+			t.Fatalf("expected no error when replacing response but got %v", err)
+		}
+
+		loaded, err := httpCacheRepo.GetCachedResponse("key")
+		if err != nil {
+			t.Fatalf("expected no error when getting response but got %v", err)
+		}
+		if loaded.ETag != `"v2"` {
+			t.Errorf("expected etag to be replaced but was '%s'", loaded.ETag)
+		}
+		if loaded.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected headers to be loaded but were %v", loaded.Header)
+		}
+		if string(loaded.Body) != `{"login": "jwtly10"}` {
+			t.Errorf("expected body to be loaded but was '%s'", loaded.Body)
+		}
+	})
+
+	t.Run("Delete old responses and the oldest responses over the max size", func(t *testing.T) {
+		for _, key := range []string{"first", "second", "third"} {
+			entry := &models.HttpCacheModel{Key: key, Url: "https://api.github.com/" + key, StatusCode: http.StatusOK, Body: []byte("0123456789")}
+			if err := httpCacheRepo.SaveCachedResponse(entry); err != nil {
+				t.Fatalf("expected no error when saving response but got %v", err)
+			}
+		}
+
+		// Only the two most recently saved responses fit
+		deleted, err := httpCacheRepo.DeleteCachedResponses(time.Now().Add(-time.Hour), 20)
+		if err != nil {
+			t.Fatalf("expected no error when deleting responses but got %v", err)
+		}
+		if deleted != 2 {
+			t.Errorf("expected 2 responses to be deleted but got %d", deleted)
+		}
+		for key, kept := range map[string]bool{"key": false, "first": false, "second": true, "third": true} {
+			loaded, err := httpCacheRepo.GetCachedResponse(key)
+			if err != nil {
+				t.Fatalf("expected no error when getting response but got %v", err)
+			}
+			if (loaded != nil) != kept {
+				t.Errorf("expected response '%s' kept to be %v", key, kept)
+			}
+		}
+
+		deleted, err = httpCacheRepo.DeleteCachedResponses(time.Now().Add(time.Hour), 1<<20)
+		if err != nil {
+			t.Fatalf("expected no error when deleting responses but got %v", err)
+		}
+		if deleted != 2 {
+			t.Errorf("expected old responses to be deleted but got %d", deleted)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/lib/pq"
+)
+
+type OutboundWebhookRepository interface {
+	CreateWebhook(webhook *models.OutboundWebhookModel) error
+	GetWebhooksForUser(userID int) ([]models.OutboundWebhookModel, error)
+	GetWebhookForUser(id, userID int) (*models.OutboundWebhookModel, error)
+	GetWebhooksForEvent(event string, repoID int) ([]models.OutboundWebhookModel, error)
+	DeleteWebhook(id, userID int) (bool, error)
+	CreateDelivery(delivery *models.OutboundDeliveryModel) error
+	GetDeliveries(webhookID, limit int) ([]models.OutboundDeliveryModel, error)
+	DeleteDeliveriesBefore(before time.Time) (int64, error)
+}
+
+type sqlOutboundWebhookRepository struct {
+	database *sql.DB
+}
+
+func NewOutboundWebhookRepository(database *sql.DB) OutboundWebhookRepository {
+	return &sqlOutboundWebhookRepository{database: database}
+}
+
+const outboundWebhookColumns = `w.id, w.user_id, w.url, w.secret, w.events, w.format, w.active, w.created_at, w.updated_at`
+
+func (r *sqlOutboundWebhookRepository) CreateWebhook(webhook *models.OutboundWebhookModel) error {
+	webhook.BeforeCreate()
+
+	query := `
+		INSERT INTO public.outbound_webhook_tb (user_id, url, secret, events, format, active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`
+
+	err := r.database.QueryRow(query,
+		webhook.UserID,
+		webhook.URL,
+		webhook.Secret,
+		pq.Array(webhook.Events),
+		webhook.Format,
+		webhook.Active,
+	).Scan(&webhook.ID, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook: %w", err)
+	}
+
+	webhook.AfterCreate()
+	return nil
+}
+
+func (r *sqlOutboundWebhookRepository) GetWebhooksForUser(userID int) ([]models.OutboundWebhookModel, error) {
+	query := `SELECT ` + outboundWebhookColumns + ` FROM public.outbound_webhook_tb w WHERE w.user_id = $1 ORDER BY w.id`
+	return r.queryWebhooks(query, userID)
+}
+
+// GetWebhookForUser gets one of a user's webhooks. Returns nil if the user has no such webhook.
+func (r *sqlOutboundWebhookRepository) GetWebhookForUser(id, userID int) (*models.OutboundWebhookModel, error) {
+	query := `SELECT ` + outboundWebhookColumns + ` FROM public.outbound_webhook_tb w WHERE w.id = $1 AND w.user_id = $2`
+
+	webhook := &models.OutboundWebhookModel{}
+	if err := scanOutboundWebhook(r.database.QueryRow(query, id, userID), webhook); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error querying webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// GetWebhooksForEvent gets the active webhooks subscribed to an event, whose owners can see the repo it is about
+func (r *sqlOutboundWebhookRepository) GetWebhooksForEvent(event string, repoID int) ([]models.OutboundWebhookModel, error) {
+	query := `
+		SELECT ` + outboundWebhookColumns + `
+		FROM public.outbound_webhook_tb w
+		JOIN public.repository_tb r ON r.id = $2
+		WHERE w.active AND $1 = ANY(w.events)
+			AND ` + repoVisibleToViewer("r", "w.user_id") + `
+		ORDER BY w.id`
+	return r.queryWebhooks(query, event, repoID)
+}
+
+// DeleteWebhook deletes one of a user's webhooks and its deliveries, returning false if the user has no such webhook
+func (r *sqlOutboundWebhookRepository) DeleteWebhook(id, userID int) (bool, error) {
+	res, err := r.database.Exec(`DELETE FROM public.outbound_webhook_tb WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// CreateDelivery logs an attempt to deliver an event
+func (r *sqlOutboundWebhookRepository) CreateDelivery(delivery *models.OutboundDeliveryModel) error {
+	delivery.BeforeCreate()
+
+	query := `
+		INSERT INTO public.outbound_delivery_tb (webhook_id, delivery_id, event, attempt, status_code, error_msg, success, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at`
+
+	err := r.database.QueryRow(query,
+		delivery.WebhookID,
+		delivery.DeliveryID,
+		delivery.Event,
+		delivery.Attempt,
+		delivery.StatusCode,
+		delivery.ErrorMsg,
+		delivery.Success,
+		delivery.DurationMs,
+	).Scan(&delivery.ID, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook delivery: %w", err)
+	}
+
+	delivery.AfterCreate()
+	return nil
+}
+
+// GetDeliveries gets the latest delivery attempts of a webhook, newest first
+func (r *sqlOutboundWebhookRepository) GetDeliveries(webhookID, limit int) ([]models.OutboundDeliveryModel, error) {
+	query := `
+		SELECT id, webhook_id, delivery_id, event, attempt, status_code, error_msg, success, duration_ms, created_at, updated_at
+		FROM public.outbound_delivery_tb
+		WHERE webhook_id = $1
+		ORDER BY id DESC
+		LIMIT $2`
+
+	rows, err := r.database.Query(query, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.OutboundDeliveryModel{}
+	for rows.Next() {
+		var d models.OutboundDeliveryModel
+		err := rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.DeliveryID,
+			&d.Event,
+			&d.Attempt,
+			&d.StatusCode,
+			&d.ErrorMsg,
+			&d.Success,
+			&d.DurationMs,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// DeleteDeliveriesBefore deletes delivery attempts made before a time, returning how many were deleted
+func (r *sqlOutboundWebhookRepository) DeleteDeliveriesBefore(before time.Time) (int64, error) {
+	res, err := r.database.Exec(`DELETE FROM public.outbound_delivery_tb WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func (r *sqlOutboundWebhookRepository) queryWebhooks(query string, args ...any) ([]models.OutboundWebhookModel, error) {
+	rows, err := r.database.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.OutboundWebhookModel{}
+	for rows.Next() {
+		var webhook models.OutboundWebhookModel
+		if err := scanOutboundWebhook(rows, &webhook); err != nil {
+			return nil, fmt.Errorf("error scanning webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// scanOutboundWebhook scans a row selected with outboundWebhookColumns
+func scanOutboundWebhook(row interface{ Scan(dest ...any) error }, webhook *models.OutboundWebhookModel) error {
+	return row.Scan(
+		&webhook.ID,
+		&webhook.UserID,
+		&webhook.URL,
+		&webhook.Secret,
+		pq.Array(&webhook.Events),
+		&webhook.Format,
+		&webhook.Active,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+}
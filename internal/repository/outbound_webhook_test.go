@@ -0,0 +1,148 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/test"
+)
+
+func TestOutboundWebhookRepository_Integration(t *testing.T) {
+	container, db, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+
+	userRepo := repository.NewUserRepository(db)
+	repoRepo := repository.NewRepoRepository(db)
+	webhookRepo := repository.NewOutboundWebhookRepository(db)
+
+	owner := &models.UserModel{GithubID: 1, Login: "jwtly10", AccessToken: []byte("encrypted")}
+	if err := userRepo.SaveUser(owner); err != nil {
+		t.Fatal(err)
+	}
+	other := &models.UserModel{GithubID: 2, Login: "other", AccessToken: []byte("encrypted")}
+	if err := userRepo.SaveUser(other); err != nil {
+		t.Fatal(err)
+	}
+
+	publicRepo := models.RepositoryModel{Name: "public", Author: "jwtly10", CloneUrl: "https://github.com/jwtly10/public.git"}
+	privateRepo := models.RepositoryModel{Name: "private", Author: "jwtly10", CloneUrl: "https://github.com/jwtly10/private.git", Visibility: models.VisibilityPrivate, OwnerUserID: &owner.ID}
+	for _, repo := range []*models.RepositoryModel{&publicRepo, &privateRepo} {
+		if err := repoRepo.CreateRepo(repo); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ownerHook := &models.OutboundWebhookModel{UserID: owner.ID, URL: "https://example.com/owner", Secret: "secret", Events: []string{models.EventRepoLinksFound}, Format: models.WebhookFormatJSON, Active: true}
+	otherHook := &models.OutboundWebhookModel{UserID: other.ID, URL: "https://example.com/other", Secret: "secret", Events: []string{models.EventRepoLinksFound, models.EventRepoFailed}, Format: models.WebhookFormatSlack, Active: true}
+
+	t.Run("Create webhooks", func(t *testing.T) {
+		for _, hook := range []*models.OutboundWebhookModel{ownerHook, otherHook} {
+			if err := webhookRepo.CreateWebhook(hook); err != nil {
+				t.Fatalf("expected no error when creating webhook but got %v", err)
+			}
+		}
+
+		hooks, err := webhookRepo.GetWebhooksForUser(owner.ID)
+		if err != nil {
+			t.Fatalf("expected no error when listing webhooks but got %v", err)
+		}
+		if len(hooks) != 1 || hooks[0].URL != ownerHook.URL || hooks[0].Secret != "secret" {
+			t.Errorf("expected owner's webhook but got %v", hooks)
+		}
+
+		hook, err := webhookRepo.GetWebhookForUser(ownerHook.ID, other.ID)
+		if err != nil {
+			t.Errorf("expected no error when getting another user's webhook but got %v", err)
+		}
+		if hook != nil {
+			t.Errorf("expected no webhook for another user but got %v", hook)
+		}
+	})
+
+	t.Run("Get webhooks for event", func(t *testing.T) {
+		hooks, err := webhookRepo.GetWebhooksForEvent(models.EventRepoLinksFound, publicRepo.ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting webhooks for event but got %v", err)
+		}
+		if len(hooks) != 2 {
+			t.Errorf("expected both webhooks for a public repo but got %d", len(hooks))
+		}
+
+		hooks, err = webhookRepo.GetWebhooksForEvent(models.EventRepoLinksFound, privateRepo.ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting webhooks for event but got %v", err)
+		}
+		if len(hooks) != 1 || hooks[0].ID != ownerHook.ID {
+			t.Errorf("expected only the owner's webhook for a private repo but got %v", hooks)
+		}
+
+		hooks, err = webhookRepo.GetWebhooksForEvent(models.EventRepoCompleted, publicRepo.ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting webhooks for event but got %v", err)
+		}
+		if len(hooks) != 0 {
+			t.Errorf("expected no webhooks subscribed to '%s' but got %d", models.EventRepoCompleted, len(hooks))
+		}
+	})
+
+	t.Run("Log deliveries", func(t *testing.T) {
+		for attempt := 1; attempt <= 2; attempt++ {
+			delivery := &models.OutboundDeliveryModel{WebhookID: ownerHook.ID, DeliveryID: "delivery", Event: models.EventRepoLinksFound, Attempt: attempt, StatusCode: 500}
+			if err := webhookRepo.CreateDelivery(delivery); err != nil {
+				t.Fatalf("expected no error when logging delivery but got %v", err)
+			}
+		}
+
+		deliveries, err := webhookRepo.GetDeliveries(ownerHook.ID, 1)
+		if err != nil {
+			t.Fatalf("expected no error when getting deliveries but got %v", err)
+		}
+		if len(deliveries) != 1 || deliveries[0].Attempt != 2 {
+			t.Errorf("expected the latest delivery attempt but got %v", deliveries)
+		}
+	})
+
+	t.Run("Delete old deliveries", func(t *testing.T) {
+		deleted, err := webhookRepo.DeleteDeliveriesBefore(time.Now().Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("expected no error when deleting old deliveries but got %v", err)
+		}
+		if deleted != 0 {
+			t.Errorf("expected recent deliveries to be kept but %d were deleted", deleted)
+		}
+
+		deleted, err = webhookRepo.DeleteDeliveriesBefore(time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("expected no error when deleting old deliveries but got %v", err)
+		}
+		if deleted != 2 {
+			t.Errorf("expected 2 deliveries to be deleted but got %d", deleted)
+		}
+	})
+
+	t.Run("Delete webhook", func(t *testing.T) {
+		deleted, err := webhookRepo.DeleteWebhook(ownerHook.ID, other.ID)
+		if err != nil {
+			t.Fatalf("expected no error when deleting webhook but got %v", err)
+		}
+		if deleted {
+			t.Error("expected webhook of another user not to be deleted")
+		}
+
+		deleted, err = webhookRepo.DeleteWebhook(ownerHook.ID, owner.ID)
+		if err != nil {
+			t.Fatalf("expected no error when deleting webhook but got %v", err)
+		}
+		if !deleted {
+			t.Error("expected webhook to be deleted")
+		}
+	})
+}
@@ -8,10 +8,12 @@ import (
 	"reflect"
 
 	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/lib/pq"
 )
 
 type ParserLinksRepository interface {
 	CreateParserLink(Repo *models.ParserLinksModel) error
+	DeleteParserLinks(repoID int, files []string) (int64, error)
 }
 
 type sqlParserLinkRepository struct {
@@ -35,14 +37,20 @@ func (r *sqlParserLinkRepository) handleError(err error) error {
 // CreateParserLink inserts a new link into the database
 func (r *sqlParserLinkRepository) CreateParserLink(link *models.ParserLinksModel) error {
 	link.BeforeCreate()
-	query := `INSERT INTO public.parser_links_tb (repo_id, url, expanded_url, file, line_number, github_url, path)
-        VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	query := `INSERT INTO public.parser_links_tb (repo_id, url, expanded_url, file, line_number, column_number, classification, snippet, snippet_start_line, match_start, match_end, github_url, path)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id`
 	err := r.database.QueryRow(query,
 		link.RepoId,
 		link.Url,
 		link.ExpandedUrl,
 		link.File,
 		link.LineNumber,
+		link.ColumnNumber,
+		link.Classification,
+		link.Snippet,
+		link.SnippetStartLine,
+		link.MatchStart,
+		link.MatchEnd,
 		link.GithubUrl,
 		link.Path,
 	).Scan(&link.ID)
@@ -52,3 +60,17 @@ func (r *sqlParserLinkRepository) CreateParserLink(link *models.ParserLinksModel
 	link.AfterCreate()
 	return nil
 }
+
+// DeleteParserLinks deletes a repo's links in files, or all of its links when files is empty,
+// so a repo can be parsed again. Returns how many links were deleted.
+func (r *sqlParserLinkRepository) DeleteParserLinks(repoID int, files []string) (int64, error) {
+	query := `DELETE FROM public.parser_links_tb WHERE repo_id = $1 AND (cardinality($2::text[]) = 0 OR file = ANY($2))`
+	if files == nil {
+		files = []string{}
+	}
+	rs, err := r.database.Exec(query, repoID, pq.Array(files))
+	if err != nil {
+		return 0, r.handleError(err)
+	}
+	return rs.RowsAffected()
+}
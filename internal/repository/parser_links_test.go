@@ -80,4 +80,24 @@ func TestParserLinkRepository_Integration(t *testing.T) {
 			t.Error("expected error when creating duplicate parser link, but got nil")
 		}
 	})
+
+	t.Run("Delete parser links in files", func(t *testing.T) {
+		deleted, err := parserLinkRepo.DeleteParserLinks(repo.ID, []string{"main.go"})
+		if err != nil {
+			t.Fatalf("expected no error when deleting parser links but got %v", err)
+		}
+		if deleted != 1 {
+			t.Errorf("expected 1 parser link in main.go to be deleted but got %d", deleted)
+		}
+	})
+
+	t.Run("Delete all parser links", func(t *testing.T) {
+		deleted, err := parserLinkRepo.DeleteParserLinks(repo.ID, nil)
+		if err != nil {
+			t.Fatalf("expected no error when deleting parser links but got %v", err)
+		}
+		if deleted != 1 {
+			t.Errorf("expected remaining parser link to be deleted but got %d", deleted)
+		}
+	})
 }
@@ -3,7 +3,7 @@ package repository
 import (
 	"database/sql"
 	"fmt"
-	"strconv"
+	"time"
 
 	"github.com/jwtly10/googl-bye/internal/models"
 )
@@ -16,14 +16,19 @@ func NewRepoLinkRepository(db *sql.DB) *RepoLinkRepository {
 	return &RepoLinkRepository{db: db}
 }
 
-func (r *RepoLinkRepository) GetRepositoryWithLinks() ([]*models.RepoWithLinks, error) {
+// NoViewer is the viewer of requests without a signed in user. They can only see public repos.
+const NoViewer = 0
+
+// GetRepositoryWithLinks returns completed repos with links and errored repos. Repos are only returned if the viewer can see them.
+func (r *RepoLinkRepository) GetRepositoryWithLinks(viewerID int) ([]*models.RepoWithLinks, error) {
 	rows, err := r.db.Query(`
         SELECT 
-            r.id, r.name, r.author, r.state, r.api_url, r.gh_url, 
+            r.id, r.name, r.author, r.forge, r.state, r.api_url, r.gh_url, 
             r.language, r.stars, r.forks, r.size, r.last_push, r.clone_url, 
-            r.error_msg, r.created_at, r.updated_at,
-            l.id, l.url, l.expanded_url, l.file, l.line_number, l.github_url,
-            l.path, l.created_at, l.updated_at
+            r.error_msg, r.private, r.team_id, r.visibility, r.created_at, r.updated_at,
+            l.id, l.url, l.expanded_url, l.file, l.line_number, l.column_number,
+            l.classification, l.snippet, l.snippet_start_line, l.match_start, l.match_end,
+            l.github_url, l.path, l.created_at, l.updated_at
         FROM 
             repository_tb r
         LEFT JOIN 
@@ -31,9 +36,10 @@ func (r *RepoLinkRepository) GetRepositoryWithLinks() ([]*models.RepoWithLinks,
         WHERE 
             (r.state = 'COMPLETED' OR r.state = 'ERROR')
             AND (r.state = 'ERROR' OR l.id IS NOT NULL)
+            AND `+visibleTo(1)+`
         ORDER BY 
             r.id DESC, l.id
-    `)
+    `, viewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -47,11 +53,12 @@ func (r *RepoLinkRepository) GetRepositoryWithLinks() ([]*models.RepoWithLinks,
 		var linkID sql.NullInt64
 
 		err := rows.Scan(
-			&r.ID, &r.Name, &r.Author, &r.State, &r.ApiUrl, &r.GhUrl,
+			&r.ID, &r.Name, &r.Author, &r.Forge, &r.State, &r.ApiUrl, &r.GhUrl,
 			&r.Language, &r.Stars, &r.Forks, &r.Size, &r.LastPush, &r.CloneURL,
-			&r.ErrorMsg, &r.CreatedAt, &r.UpdatedAt,
-			&linkID, &l.Url, &l.ExpandedURL, &l.File, &l.LineNumber, &l.GithubUrl,
-			&l.Path, &l.CreatedAt, &l.UpdatedAt,
+			&r.ErrorMsg, &r.Private, &r.TeamID, &r.Visibility, &r.CreatedAt, &r.UpdatedAt,
+			&linkID, &l.Url, &l.ExpandedURL, &l.File, &l.LineNumber, &l.ColumnNumber,
+			&l.Classification, &l.Snippet, &l.SnippetStartLine, &l.MatchStart, &l.MatchEnd,
+			&l.GithubUrl, &l.Path, &l.CreatedAt, &l.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -66,6 +73,7 @@ func (r *RepoLinkRepository) GetRepositoryWithLinks() ([]*models.RepoWithLinks,
 
 		if linkID.Valid {
 			l.ID = int(linkID.Int64)
+			l.AffectsRuntime = models.LinkAffectsRuntime(l.Classification)
 			repo.Links = append(repo.Links, l)
 		}
 	}
@@ -82,41 +90,105 @@ func (r *RepoLinkRepository) GetRepositoryWithLinks() ([]*models.RepoWithLinks,
 
 }
 
-func (r *RepoLinkRepository) GetRepositoryWithLinksForUser(author string) ([]*models.RepoWithLinks, error) {
+// GetRepositoryWithLinksForUser returns the repos of an author. Repos are only returned if the viewer can see them.
+func (r *RepoLinkRepository) GetRepositoryWithLinksForUser(author string, viewerID int) ([]*models.RepoWithLinks, error) {
 	rows, err := r.db.Query(`
         SELECT 
-            r.id, r.name, r.author, r.state, r.api_url, r.gh_url, 
+            r.id, r.name, r.author, r.forge, r.state, r.api_url, r.gh_url, 
             r.language, r.stars, r.forks, r.size, r.last_push, r.clone_url, 
-            r.error_msg, r.created_at, r.updated_at,
-            l.id, l.url, l.expanded_url, l.file, l.line_number, l.github_url,
-            l.path, l.created_at, l.updated_at
+            r.error_msg, r.private, r.team_id, r.visibility, r.created_at, r.updated_at,
+            l.id, l.url, l.expanded_url, l.file, l.line_number, l.column_number,
+            l.classification, l.snippet, l.snippet_start_line, l.match_start, l.match_end,
+            l.github_url, l.path, l.created_at, l.updated_at
         FROM 
             repository_tb r
         LEFT JOIN 
             parser_links_tb l ON r.id = l.repo_id
         WHERE 
             r.author = $1
+            AND r.state != 'DELETED'
+            AND `+visibleTo(2)+`
         ORDER BY 
             r.id DESC, l.id
-    `, author)
+    `, author, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	repositories, err := scanReposWithOptionalLinks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.RepoWithLinks, 0, len(repositories))
+	for _, repo := range repositories {
+		// if repo.State == "COMPLETED" && len(repo.Links) == 0 {
+		// 	return nil, fmt.Errorf("repository with id %d has 'COMPLETED' status but no links", repo.ID)
+		// }
+		result = append(result, repo)
+	}
+	return result, nil
+}
+
+// GetRepositoryWithLinksByID returns a single repository and its links, ordered by file and line.
+// Repos the viewer can't see are not found.
+func (r *RepoLinkRepository) GetRepositoryWithLinksByID(id int, viewerID int) (*models.RepoWithLinks, error) {
+	rows, err := r.db.Query(`
+        SELECT 
+            r.id, r.name, r.author, r.forge, r.state, r.api_url, r.gh_url, 
+            r.language, r.stars, r.forks, r.size, r.last_push, r.clone_url, 
+            r.error_msg, r.private, r.team_id, r.visibility, r.created_at, r.updated_at,
+            l.id, l.url, l.expanded_url, l.file, l.line_number, l.column_number,
+            l.classification, l.snippet, l.snippet_start_line, l.match_start, l.match_end,
+            l.github_url, l.path, l.created_at, l.updated_at
+        FROM 
+            repository_tb r
+        LEFT JOIN 
+            parser_links_tb l ON r.id = l.repo_id
+        WHERE 
+            r.id = $1
+            AND r.state != 'DELETED'
+            AND `+visibleTo(2)+`
+        ORDER BY 
+            l.file, l.line_number, l.id
+    `, id, viewerID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
+
+	repositories, err := scanReposWithOptionalLinks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, exists := repositories[id]
+	if !exists {
+		return nil, ErrRepoNotFound
+	}
+	return repo, nil
+}
+
+// scanReposWithOptionalLinks groups the rows of a repository_tb LEFT JOIN parser_links_tb query by repo
+// Link columns are nullable, as repos may not have any links
+func scanReposWithOptionalLinks(rows *sql.Rows) (map[int]*models.RepoWithLinks, error) {
 	repositories := make(map[int]*models.RepoWithLinks)
 	for rows.Next() {
 		var r models.RepoWithLinks
 		var l models.Link
-		var linkID, url, expandedURL, file, githubURL, path sql.NullString
+		var linkID sql.NullInt64
+		var url, expandedURL, file, classification, snippet, githubURL, path sql.NullString
 		var linkCreatedAt, linkUpdatedAt sql.NullTime
-		var lineNumber sql.NullInt16
+		var lineNumber, columnNumber, snippetStartLine, matchStart, matchEnd sql.NullInt32
 
 		err := rows.Scan(
-			&r.ID, &r.Name, &r.Author, &r.State, &r.ApiUrl, &r.GhUrl,
+			&r.ID, &r.Name, &r.Author, &r.Forge, &r.State, &r.ApiUrl, &r.GhUrl,
 			&r.Language, &r.Stars, &r.Forks, &r.Size, &r.LastPush, &r.CloneURL,
-			&r.ErrorMsg, &r.CreatedAt, &r.UpdatedAt,
-			&linkID, &url, &expandedURL, &file, &lineNumber, &githubURL,
-			&path, &linkCreatedAt, &linkUpdatedAt,
+			&r.ErrorMsg, &r.Private, &r.TeamID, &r.Visibility, &r.CreatedAt, &r.UpdatedAt,
+			&linkID, &url, &expandedURL, &file, &lineNumber, &columnNumber,
+			&classification, &snippet, &snippetStartLine, &matchStart, &matchEnd,
+			&githubURL, &path, &linkCreatedAt, &linkUpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -130,11 +202,18 @@ func (r *RepoLinkRepository) GetRepositoryWithLinksForUser(author string) ([]*mo
 		}
 
 		if linkID.Valid {
-			l.ID, _ = strconv.Atoi(linkID.String)
+			l.ID = int(linkID.Int64)
 			l.Url = url.String
 			l.ExpandedURL = expandedURL.String
 			l.File = file.String
-			l.LineNumber = int(lineNumber.Int16)
+			l.LineNumber = int(lineNumber.Int32)
+			l.ColumnNumber = int(columnNumber.Int32)
+			l.Classification = classification.String
+			l.AffectsRuntime = models.LinkAffectsRuntime(l.Classification)
+			l.Snippet = snippet.String
+			l.SnippetStartLine = int(snippetStartLine.Int32)
+			l.MatchStart = int(matchStart.Int32)
+			l.MatchEnd = int(matchEnd.Int32)
 			l.GithubUrl = githubURL.String
 			l.Path = path.String
 			if linkCreatedAt.Valid {
@@ -146,13 +225,108 @@ func (r *RepoLinkRepository) GetRepositoryWithLinksForUser(author string) ([]*mo
 			repo.Links = append(repo.Links, l)
 		}
 	}
+	return repositories, rows.Err()
+}
 
-	result := make([]*models.RepoWithLinks, 0, len(repositories))
-	for _, repo := range repositories {
-		// if repo.State == "COMPLETED" && len(repo.Links) == 0 {
-		// 	return nil, fmt.Errorf("repository with id %d has 'COMPLETED' status but no links", repo.ID)
-		// }
-		result = append(result, repo)
+// RepoLinkFilter narrows the repos returned by StreamRepositoryLinks
+type RepoLinkFilter struct {
+	// Author matches repos of a single user, like GetRepositoryWithLinksForUser. When empty, repos are filtered
+	// the same as GetRepositoryWithLinks (completed repos with links and errored repos)
+	Author string
+	// Name matches repos with a name containing the value, ignoring case
+	Name string
+	// ViewerID is the user exporting the links. Only repos the viewer can see are included.
+	ViewerID int
+	// Since only matches links found after the time, when set. Repos without links are then excluded.
+	Since time.Time
+	// NotSentTo excludes links already sent to the digest subscription with the id, when set
+	NotSentTo int
+}
+
+// StreamRepositoryLinks calls fn for every repo/link row matching the filter, ordered by author, repo and link location.
+// Rows are read one at a time so large exports are never held in memory.
+// link is nil for repos without any links (e.g. repos that errored while being parsed).
+func (r *RepoLinkRepository) StreamRepositoryLinks(filter RepoLinkFilter, fn func(repo *models.RepoWithLinks, link *models.Link) error) error {
+	query := `
+        SELECT 
+            r.id, r.name, r.author, r.forge, r.state, r.api_url, r.gh_url, 
+            r.language, r.stars, r.forks, r.size, r.last_push, r.clone_url, 
+            r.error_msg, r.private, r.team_id, r.visibility, r.created_at, r.updated_at,
+            l.id, l.url, l.expanded_url, l.file, l.line_number, l.column_number,
+            l.classification, l.snippet, l.snippet_start_line, l.match_start, l.match_end,
+            l.github_url, l.path, l.created_at, l.updated_at
+        FROM 
+            repository_tb r
+        LEFT JOIN 
+            parser_links_tb l ON r.id = l.repo_id
+        WHERE 
+            (
+                ($1 <> '' AND r.author = $1)
+                OR ($1 = '' AND (r.state = 'COMPLETED' OR r.state = 'ERROR') AND (r.state = 'ERROR' OR l.id IS NOT NULL))
+            )
+            AND ($2 = '' OR r.name ILIKE '%' || $2 || '%')
+            AND r.state != 'DELETED'
+            AND ` + visibleTo(3) + `
+            AND ($4::timestamptz IS NULL OR l.created_at > $4)
+            AND ($5 = 0 OR NOT EXISTS (
+                SELECT 1 FROM digest_sent_link_tb s
+                WHERE s.subscription_id = $5 AND s.repo_id = l.repo_id AND s.url = l.url AND s.file = l.file AND s.line_number = l.line_number
+            ))
+        ORDER BY 
+            r.author, r.name, r.id, l.file, l.line_number, l.id
+    `
+	since := sql.NullTime{Time: filter.Since, Valid: !filter.Since.IsZero()}
+	rows, err := r.db.Query(query, filter.Author, filter.Name, filter.ViewerID, since, filter.NotSentTo)
+	if err != nil {
+		return err
 	}
-	return result, nil
+	defer rows.Close()
+
+	for rows.Next() {
+		var repo models.RepoWithLinks
+		var linkID sql.NullInt64
+		var url, expandedURL, file, classification, snippet, githubURL, path sql.NullString
+		var linkCreatedAt, linkUpdatedAt sql.NullTime
+		var lineNumber, columnNumber, snippetStartLine, matchStart, matchEnd sql.NullInt32
+
+		err := rows.Scan(
+			&repo.ID, &repo.Name, &repo.Author, &repo.Forge, &repo.State, &repo.ApiUrl, &repo.GhUrl,
+			&repo.Language, &repo.Stars, &repo.Forks, &repo.Size, &repo.LastPush, &repo.CloneURL,
+			&repo.ErrorMsg, &repo.Private, &repo.TeamID, &repo.Visibility, &repo.CreatedAt, &repo.UpdatedAt,
+			&linkID, &url, &expandedURL, &file, &lineNumber, &columnNumber,
+			&classification, &snippet, &snippetStartLine, &matchStart, &matchEnd,
+			&githubURL, &path, &linkCreatedAt, &linkUpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		var link *models.Link
+		if linkID.Valid {
+			link = &models.Link{
+				ID:               int(linkID.Int64),
+				Url:              url.String,
+				ExpandedURL:      expandedURL.String,
+				File:             file.String,
+				LineNumber:       int(lineNumber.Int32),
+				ColumnNumber:     int(columnNumber.Int32),
+				Classification:   classification.String,
+				AffectsRuntime:   models.LinkAffectsRuntime(classification.String),
+				Snippet:          snippet.String,
+				SnippetStartLine: int(snippetStartLine.Int32),
+				MatchStart:       int(matchStart.Int32),
+				MatchEnd:         int(matchEnd.Int32),
+				GithubUrl:        githubURL.String,
+				Path:             path.String,
+				CreatedAt:        linkCreatedAt.Time,
+				UpdatedAt:        linkUpdatedAt.Time,
+			}
+		}
+
+		if err := fn(&repo, link); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
 }
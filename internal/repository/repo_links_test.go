@@ -1 +1,352 @@
 package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/test"
+)
+
+func TestRepoLinkRepository_Integration(t *testing.T) {
+	container, db, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+
+	repoLinkRepo := repository.NewRepoLinkRepository(db)
+	parserLinkRepo := repository.NewParserLinkRepository(db)
+	repoRepo := repository.NewRepoRepository(db)
+
+	// Two parsed repos with links, and one repo that is still pending
+	repos := []*models.RepositoryModel{
+		{Name: "zeta", Author: "alice", CloneUrl: "https://github.com/alice/zeta.git"},
+		{Name: "alpha", Author: "alice", CloneUrl: "https://github.com/alice/alpha.git"},
+		{Name: "pending", Author: "bob", CloneUrl: "https://github.com/bob/pending.git"},
+	}
+	for _, repo := range repos {
+		if err := repoRepo.CreateRepo(repo); err != nil {
+			t.Fatalf("expected no error when creating repo but got %v", err)
+		}
+	}
+	for _, repo := range repos[:2] {
+		repo.State = "COMPLETED"
+		if err := repoRepo.UpdateRepo(repo); err != nil {
+			t.Fatalf("expected no error when updating repo but got %v", err)
+		}
+		for _, line := range []int{20, 5} {
+			link := models.ParserLinksModel{RepoId: repo.ID, Url: "goo.gl/abc", File: "main.go", LineNumber: line}
+			if err := parserLinkRepo.CreateParserLink(&link); err != nil {
+				t.Fatalf("expected no error when creating parser link but got %v", err)
+			}
+		}
+	}
+
+	t.Run("Get repository with links by id", func(t *testing.T) {
+		repo, err := repoLinkRepo.GetRepositoryWithLinksByID(repos[0].ID, repository.NoViewer)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if len(repo.Links) != 2 || repo.Links[0].LineNumber != 5 {
+			t.Errorf("expected 2 links ordered by line, got %+v", repo.Links)
+		}
+	})
+
+	t.Run("Get missing repository with links by id", func(t *testing.T) {
+		_, err := repoLinkRepo.GetRepositoryWithLinksByID(9999, repository.NoViewer)
+		if err != repository.ErrRepoNotFound {
+			t.Errorf("expected ErrRepoNotFound but got %v", err)
+		}
+	})
+
+	t.Run("Stream repository links", func(t *testing.T) {
+		var got []string
+		err := repoLinkRepo.StreamRepositoryLinks(repository.RepoLinkFilter{}, func(repo *models.RepoWithLinks, link *models.Link) error {
+			if link == nil {
+				t.Errorf("expected only repos with links, got %s/%s", repo.Author, repo.Name)
+				return nil
+			}
+			got = append(got, repo.Name)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		// Pending repos are excluded, and rows are ordered by repo name
+		expected := []string{"alpha", "alpha", "zeta", "zeta"}
+		if len(got) != len(expected) {
+			t.Fatalf("expected %v but got %v", expected, got)
+		}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Errorf("expected %v but got %v", expected, got)
+				break
+			}
+		}
+	})
+
+	t.Run("Stream repository links filtered by author and name", func(t *testing.T) {
+		rows := 0
+		err := repoLinkRepo.StreamRepositoryLinks(repository.RepoLinkFilter{Author: "bob", Name: "PEND"}, func(repo *models.RepoWithLinks, link *models.Link) error {
+			rows++
+			if repo.Name != "pending" || link != nil {
+				t.Errorf("expected bob/pending without links, got %s/%s", repo.Author, repo.Name)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if rows != 1 {
+			t.Errorf("expected 1 row but got %d", rows)
+		}
+	})
+
+	t.Run("Stream repository links found since a time", func(t *testing.T) {
+		rows := 0
+		err := repoLinkRepo.StreamRepositoryLinks(repository.RepoLinkFilter{Author: "alice", Since: time.Now().Add(-time.Hour)}, func(repo *models.RepoWithLinks, link *models.Link) error {
+			rows++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if rows != 4 {
+			t.Errorf("expected 4 rows but got %d", rows)
+		}
+
+		rows = 0
+		err = repoLinkRepo.StreamRepositoryLinks(repository.RepoLinkFilter{Author: "alice", Since: time.Now().Add(time.Hour)}, func(repo *models.RepoWithLinks, link *models.Link) error {
+			rows++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if rows != 0 {
+			t.Errorf("expected no rows but got %d", rows)
+		}
+	})
+
+	t.Run("Stream repository links not sent to a digest", func(t *testing.T) {
+		user := &models.UserModel{GithubID: 41, Login: "dave", AccessToken: []byte("encrypted")}
+		if err := repository.NewUserRepository(db).SaveUser(user); err != nil {
+			t.Fatal(err)
+		}
+		subRepo := repository.NewDigestSubscriptionRepository(db)
+		sub := &models.DigestSubscriptionModel{Email: "dave@example.com", Author: "alice", UserID: user.ID, UnsubscribeToken: "unsubscribe", ConfirmToken: "confirm"}
+		if _, err := subRepo.CreateSubscription(sub); err != nil {
+			t.Fatal(err)
+		}
+
+		var sent []models.DigestSentLink
+		filter := repository.RepoLinkFilter{Author: "alice", Since: time.Now().Add(-time.Hour), NotSentTo: sub.ID}
+		err := repoLinkRepo.StreamRepositoryLinks(filter, func(repo *models.RepoWithLinks, link *models.Link) error {
+			if len(sent) == 0 {
+				sent = append(sent, models.DigestSentLink{RepoID: repo.ID, Url: link.Url, File: link.File, LineNumber: link.LineNumber})
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if err := subRepo.MarkLinksSent(sub.ID, sent); err != nil {
+			t.Fatal(err)
+		}
+
+		rows := 0
+		err = repoLinkRepo.StreamRepositoryLinks(filter, func(repo *models.RepoWithLinks, link *models.Link) error {
+			rows++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if rows != 3 {
+			t.Errorf("expected 3 rows not yet sent but got %d", rows)
+		}
+	})
+
+	t.Run("Private repos are only visible to their owner", func(t *testing.T) {
+		owner := &models.UserModel{GithubID: 42, Login: "carol", AccessToken: []byte("encrypted")}
+		if err := repository.NewUserRepository(db).SaveUser(owner); err != nil {
+			t.Fatal(err)
+		}
+
+		private := &models.RepositoryModel{Name: "secret", Author: "carol", CloneUrl: "https://github.com/carol/secret.git", Private: true, OwnerUserID: &owner.ID}
+		if err := repoRepo.CreateRepo(private); err != nil {
+			t.Fatalf("expected no error when creating repo but got %v", err)
+		}
+		private.State = "COMPLETED"
+		if err := repoRepo.UpdateRepo(private); err != nil {
+			t.Fatalf("expected no error when updating repo but got %v", err)
+		}
+		link := models.ParserLinksModel{RepoId: private.ID, Url: "goo.gl/secret", File: "main.go", LineNumber: 1}
+		if err := parserLinkRepo.CreateParserLink(&link); err != nil {
+			t.Fatalf("expected no error when creating parser link but got %v", err)
+		}
+
+		repos, err := repoLinkRepo.GetRepositoryWithLinksForUser("carol", repository.NoViewer)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if len(repos) != 0 {
+			t.Errorf("expected private repo to be hidden but got %v", repos)
+		}
+
+		repos, err = repoLinkRepo.GetRepositoryWithLinksForUser("carol", owner.ID)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if len(repos) != 1 || !repos[0].Private {
+			t.Errorf("expected private repo to be visible to its owner but got %v", repos)
+		}
+
+		_, err = repoLinkRepo.GetRepositoryWithLinksByID(private.ID, repository.NoViewer)
+		if err != repository.ErrRepoNotFound {
+			t.Errorf("expected ErrRepoNotFound but got %v", err)
+		}
+
+		all, err := repoLinkRepo.GetRepositoryWithLinks(repository.NoViewer)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		for _, repo := range all {
+			if repo.ID == private.ID {
+				t.Errorf("expected private repo to be hidden from all repos")
+			}
+		}
+	})
+
+	t.Run("Team repos are only visible to team members", func(t *testing.T) {
+		userRepo := repository.NewUserRepository(db)
+		teamRepo := repository.NewTeamRepository(db)
+
+		member := &models.UserModel{GithubID: 43, Login: "dave", AccessToken: []byte("encrypted")}
+		outsider := &models.UserModel{GithubID: 44, Login: "erin", AccessToken: []byte("encrypted")}
+		for _, user := range []*models.UserModel{member, outsider} {
+			if err := userRepo.SaveUser(user); err != nil {
+				t.Fatal(err)
+			}
+		}
+		team := &models.TeamModel{Name: "team"}
+		if err := teamRepo.CreateTeam(team, member.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		repo := &models.RepositoryModel{Name: "shared", Author: "org", CloneUrl: "https://github.com/org/shared.git", TeamID: &team.ID, Visibility: models.VisibilityTeam}
+		if err := repoRepo.CreateRepo(repo); err != nil {
+			t.Fatalf("expected no error when creating repo but got %v", err)
+		}
+
+		for viewer, visible := range map[int]bool{member.ID: true, outsider.ID: false, repository.NoViewer: false} {
+			repos, err := repoLinkRepo.GetRepositoryWithLinksForUser("org", viewer)
+			if err != nil {
+				t.Fatalf("expected no error but got %v", err)
+			}
+			if (len(repos) == 1) != visible {
+				t.Errorf("expected repo visible to %d to be %v but got %v", viewer, visible, repos)
+			}
+		}
+
+		// Public saves share the repo with everyone, without changing the team's save of it
+		public := &models.RepositoryModel{Name: "shared", Author: "org", CloneUrl: "https://github.com/org/shared.git"}
+		if err := repoRepo.CreateRepos([]*models.RepositoryModel{public}); err != nil {
+			t.Fatalf("expected no error when saving repo again but got %v", err)
+		}
+		saved, err := repoRepo.GetRepoByID(repo.ID)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if saved.Visibility != models.VisibilityTeam {
+			t.Errorf("expected visibility to stay %s but got %s", models.VisibilityTeam, saved.Visibility)
+		}
+		for _, viewer := range []int{member.ID, outsider.ID, repository.NoViewer} {
+			repos, err := repoLinkRepo.GetRepositoryWithLinksForUser("org", viewer)
+			if err != nil {
+				t.Fatalf("expected no error but got %v", err)
+			}
+			if len(repos) != 1 {
+				t.Errorf("expected publicly saved repo visible to %d but got %v", viewer, repos)
+			}
+		}
+	})
+
+	t.Run("Saving a repo that is already saved shares it with the saver", func(t *testing.T) {
+		userRepo := repository.NewUserRepository(db)
+		owner := &models.UserModel{GithubID: 45, Login: "frank", AccessToken: []byte("encrypted")}
+		saver := &models.UserModel{GithubID: 46, Login: "grace", AccessToken: []byte("encrypted")}
+		for _, user := range []*models.UserModel{owner, saver} {
+			if err := userRepo.SaveUser(user); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		repo := &models.RepositoryModel{Name: "hidden", Author: "frank", CloneUrl: "https://github.com/frank/hidden.git", Private: true, OwnerUserID: &owner.ID, Visibility: models.VisibilityPrivate}
+		if err := repoRepo.CreateRepos([]*models.RepositoryModel{repo}); err != nil {
+			t.Fatalf("expected no error when creating repo but got %v", err)
+		}
+
+		again := &models.RepositoryModel{Name: "hidden", Author: "frank", CloneUrl: "https://github.com/frank/hidden.git", Private: true, OwnerUserID: &saver.ID, Visibility: models.VisibilityPrivate}
+		if err := repoRepo.CreateRepos([]*models.RepositoryModel{again}); err != nil {
+			t.Fatalf("expected no error when saving repo again but got %v", err)
+		}
+		if again.ID != repo.ID {
+			t.Errorf("expected the id of the saved repo %d but got %d", repo.ID, again.ID)
+		}
+
+		// Public saves of a private repo don't make it public
+		public := &models.RepositoryModel{Name: "hidden", Author: "frank", CloneUrl: "https://github.com/frank/hidden.git"}
+		if err := repoRepo.CreateRepos([]*models.RepositoryModel{public}); err != nil {
+			t.Fatalf("expected no error when saving repo again but got %v", err)
+		}
+
+		for viewer, visible := range map[int]bool{owner.ID: true, saver.ID: true, repository.NoViewer: false} {
+			repos, err := repoLinkRepo.GetRepositoryWithLinksForUser("frank", viewer)
+			if err != nil {
+				t.Fatalf("expected no error but got %v", err)
+			}
+			if (len(repos) == 1) != visible {
+				t.Errorf("expected repo visible to %d to be %v but got %v", viewer, visible, repos)
+			}
+		}
+	})
+
+	t.Run("Deleted repos are hidden", func(t *testing.T) {
+		repo := &models.RepositoryModel{Name: "removed", Author: "henry", CloneUrl: "https://github.com/henry/removed.git"}
+		if err := repoRepo.CreateRepo(repo); err != nil {
+			t.Fatalf("expected no error when creating repo but got %v", err)
+		}
+		link := models.ParserLinksModel{RepoId: repo.ID, Url: "goo.gl/abc", File: "main.go", LineNumber: 1}
+		if err := parserLinkRepo.CreateParserLink(&link); err != nil {
+			t.Fatalf("expected no error when creating parser link but got %v", err)
+		}
+		if err := repoRepo.DeleteRepo(repo.ID); err != nil {
+			t.Fatalf("expected no error when deleting repo but got %v", err)
+		}
+
+		if _, err := repoLinkRepo.GetRepositoryWithLinksByID(repo.ID, repository.NoViewer); err != repository.ErrRepoNotFound {
+			t.Errorf("expected ErrRepoNotFound but got %v", err)
+		}
+		repos, err := repoLinkRepo.GetRepositoryWithLinksForUser("henry", repository.NoViewer)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if len(repos) != 0 {
+			t.Errorf("expected no repos but got %v", repos)
+		}
+		err = repoLinkRepo.StreamRepositoryLinks(repository.RepoLinkFilter{Author: "henry"}, func(repo *models.RepoWithLinks, link *models.Link) error {
+			t.Errorf("expected no links but got %s/%s", repo.Author, repo.Name)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+	})
+}
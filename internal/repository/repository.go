@@ -8,16 +8,23 @@ import (
 	"reflect"
 
 	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/lib/pq"
 )
 
 type RepoRepository interface {
 	CreateRepo(Repo *models.RepositoryModel) error
 	CreateRepos(Repo []*models.RepositoryModel) error
 	GetRepoByID(id int) (*models.RepositoryModel, error)
+	GetRepoByName(forge, author, name string) (*models.RepositoryModel, error)
 	GetPendingRepos() ([]models.RepositoryModel, error)
 	GetAllRepos() ([]models.RepositoryModel, error)
 	DeleteRepo(id int) error
 	UpdateRepo(Repo *models.RepositoryModel) error
+	FinishRepo(Repo *models.RepositoryModel) (bool, error)
+	RequeueRepo(id int, changedFiles []string) error
+	// GetRepoIssueUrl gets the url of the issue raised against a repo, or "" if there isn't one
+	GetRepoIssueUrl(repoID int) (string, error)
+	SaveRepoIssue(repoID, userID int, url string) error
 }
 
 type sqlRepoRepository struct {
@@ -39,7 +46,7 @@ func (r *sqlRepoRepository) handleError(err error) error {
 }
 
 // CreateRepos inserts multiple new repos into the database using a transaction
-// By default we will DO NOTHING on CONFLICTS TODO: Review - is there a reason to ever update a repo on 'save'?
+// Repos that are already saved aren't updated, they are shared with the new saver instead (see shareRepo)
 func (r *sqlRepoRepository) CreateRepos(repos []*models.RepositoryModel) error {
 	// Start a transaction
 	tx, err := r.database.Begin()
@@ -48,9 +55,9 @@ func (r *sqlRepoRepository) CreateRepos(repos []*models.RepositoryModel) error {
 	}
 
 	query := `
-        INSERT INTO public.repository_tb (name, author, state, language, stars, forks, size, last_push, api_url, gh_url, clone_url)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-        ON CONFLICT (name, author) DO NOTHING
+        INSERT INTO public.repository_tb (name, author, state, language, stars, forks, size, last_push, api_url, gh_url, clone_url, forge, priority, candidate_files, private, owner_user_id, team_id, visibility)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+        ON CONFLICT (forge, name, author) DO NOTHING
         RETURNING id`
 
 	stmt, err := tx.Prepare(query)
@@ -75,9 +82,24 @@ func (r *sqlRepoRepository) CreateRepos(repos []*models.RepositoryModel) error {
 			repo.ApiUrl,
 			repo.GhUrl,
 			repo.CloneUrl,
+			repo.ForgeName(),
+			repo.Priority,
+			pq.Array(candidateFiles(repo)),
+			repo.Private,
+			repo.OwnerUserID,
+			repo.TeamID,
+			repo.VisibilityOrDefault(),
 		).Scan(&id)
 
-		if err != nil && err != sql.ErrNoRows {
+		if err == sql.ErrNoRows {
+			// The repo was already saved, so it is shared with this saver instead
+			if err := shareRepo(tx, repo); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to share repo: %w", err)
+			}
+			continue
+		}
+		if err != nil {
 			tx.Rollback()
 			return fmt.Errorf("failed to insert repo: %w", err)
 		}
@@ -97,11 +119,42 @@ func (r *sqlRepoRepository) CreateRepos(repos []*models.RepositoryModel) error {
 	return nil
 }
 
+// shareRepo makes a repo that was already saved visible to a new saver of it, setting the repo's id.
+// The repo itself isn't changed, the saver is given access instead. Public saves give everyone access, with a grant
+// that has no user or team, unless the repo is private on the forge. Otherwise the saver, and their team for team saves, are given access.
+func shareRepo(tx *sql.Tx, repo *models.RepositoryModel) error {
+	err := tx.QueryRow(`SELECT id FROM public.repository_tb WHERE forge = $1 AND name = $2 AND author = $3`, repo.ForgeName(), repo.Name, repo.Author).Scan(&repo.ID)
+	if err != nil {
+		return err
+	}
+
+	if repo.VisibilityOrDefault() == models.VisibilityPublic {
+		_, err = tx.Exec(`
+        INSERT INTO public.repository_access_tb (repo_id)
+        SELECT id FROM public.repository_tb WHERE id = $1 AND NOT private
+        ON CONFLICT DO NOTHING`, repo.ID)
+		return err
+	}
+	if repo.OwnerUserID == nil {
+		return nil
+	}
+
+	var teamID *int
+	if repo.VisibilityOrDefault() == models.VisibilityTeam {
+		teamID = repo.TeamID
+	}
+	_, err = tx.Exec(`
+        INSERT INTO public.repository_access_tb (repo_id, user_id, team_id)
+        VALUES ($1, $2, $3)
+        ON CONFLICT DO NOTHING`, repo.ID, repo.OwnerUserID, teamID)
+	return err
+}
+
 // CreateRepo inserts a new repo into the database
 func (r *sqlRepoRepository) CreateRepo(repo *models.RepositoryModel) error {
 	repo.BeforeCreate()
-	query := `INSERT INTO public.repository_tb (name, author, state, language, stars, forks, size, last_push, api_url, gh_url, clone_url )
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
+	query := `INSERT INTO public.repository_tb (name, author, state, language, stars, forks, size, last_push, api_url, gh_url, clone_url, forge, priority, candidate_files, private, owner_user_id, team_id, visibility)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18) RETURNING id`
 	err := r.database.QueryRow(query,
 		repo.Name,
 		repo.Author,
@@ -114,6 +167,13 @@ func (r *sqlRepoRepository) CreateRepo(repo *models.RepositoryModel) error {
 		repo.ApiUrl,
 		repo.GhUrl,
 		repo.CloneUrl,
+		repo.ForgeName(),
+		repo.Priority,
+		pq.Array(candidateFiles(repo)),
+		repo.Private,
+		repo.OwnerUserID,
+		repo.TeamID,
+		repo.VisibilityOrDefault(),
 	).Scan(&repo.ID)
 	if err != nil {
 		return fmt.Errorf("failed to insert repo: %w", err)
@@ -124,12 +184,13 @@ func (r *sqlRepoRepository) CreateRepo(repo *models.RepositoryModel) error {
 
 // GetRepoByID retrieves a repo from the database by its unique ID
 func (r *sqlRepoRepository) GetRepoByID(id int) (*models.RepositoryModel, error) {
-	query := `SELECT id, name, author, state, language, stars, forks, size, last_push, api_url, gh_url, clone_url, created_at, updated_at FROM public.repository_tb WHERE id = $1`
+	query := `SELECT id, name, author, forge, state, language, stars, forks, size, last_push, api_url, gh_url, clone_url, priority, candidate_files, changed_files, private, owner_user_id, team_id, visibility, created_at, updated_at FROM public.repository_tb WHERE id = $1`
 	repo := &models.RepositoryModel{}
 	err := r.database.QueryRow(query, id).Scan(
 		&repo.ID,
 		&repo.Name,
 		&repo.Author,
+		&repo.Forge,
 		&repo.State,
 		&repo.Language,
 		&repo.Stars,
@@ -139,6 +200,13 @@ func (r *sqlRepoRepository) GetRepoByID(id int) (*models.RepositoryModel, error)
 		&repo.ApiUrl,
 		&repo.GhUrl,
 		&repo.CloneUrl,
+		&repo.Priority,
+		pq.Array(&repo.CandidateFiles),
+		pq.Array(&repo.ChangedFiles),
+		&repo.Private,
+		&repo.OwnerUserID,
+		&repo.TeamID,
+		&repo.Visibility,
 		&repo.CreatedAt,
 		&repo.UpdatedAt,
 	)
@@ -148,9 +216,46 @@ func (r *sqlRepoRepository) GetRepoByID(id int) (*models.RepositoryModel, error)
 	return repo, nil
 }
 
-// GetAllRepos retrieves all repositories from the database
+// GetRepoByName retrieves a repo by where it is hosted. Returns nil if the repo isn't tracked.
+func (r *sqlRepoRepository) GetRepoByName(forge, author, name string) (*models.RepositoryModel, error) {
+	query := `SELECT id, name, author, forge, state, language, stars, forks, size, last_push, api_url, gh_url, clone_url, priority, candidate_files, changed_files, private, owner_user_id, team_id, visibility, created_at, updated_at FROM public.repository_tb WHERE forge = $1 AND LOWER(author) = LOWER($2) AND LOWER(name) = LOWER($3) AND state != 'DELETED'`
+	repo := &models.RepositoryModel{}
+	err := r.database.QueryRow(query, forge, author, name).Scan(
+		&repo.ID,
+		&repo.Name,
+		&repo.Author,
+		&repo.Forge,
+		&repo.State,
+		&repo.Language,
+		&repo.Stars,
+		&repo.Forks,
+		&repo.Size,
+		&repo.LastPush,
+		&repo.ApiUrl,
+		&repo.GhUrl,
+		&repo.CloneUrl,
+		&repo.Priority,
+		pq.Array(&repo.CandidateFiles),
+		pq.Array(&repo.ChangedFiles),
+		&repo.Private,
+		&repo.OwnerUserID,
+		&repo.TeamID,
+		&repo.Visibility,
+		&repo.CreatedAt,
+		&repo.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, r.handleError(err)
+	}
+	return repo, nil
+}
+
+// GetAllRepos retrieves all repositories from the database, regardless of visibility. Only for background jobs.
 func (r *sqlRepoRepository) GetAllRepos() ([]models.RepositoryModel, error) {
-	query := `SELECT id, name, author, state, language, stars, forks, size, last_push, api_url, gh_url, clone_url, error_msg, created_at, updated_at FROM public.repository_tb WHERE state != 'DELETED'`
+	query := `SELECT id, name, author, forge, state, language, stars, forks, size, last_push, api_url, gh_url, clone_url, error_msg, priority, candidate_files, changed_files, private, owner_user_id, team_id, visibility, created_at, updated_at FROM public.repository_tb WHERE state != 'DELETED'`
 
 	rows, err := r.database.Query(query)
 	if err != nil {
@@ -165,6 +270,7 @@ func (r *sqlRepoRepository) GetAllRepos() ([]models.RepositoryModel, error) {
 			&repo.ID,
 			&repo.Name,
 			&repo.Author,
+			&repo.Forge,
 			&repo.State,
 			&repo.Language,
 			&repo.Stars,
@@ -175,6 +281,13 @@ func (r *sqlRepoRepository) GetAllRepos() ([]models.RepositoryModel, error) {
 			&repo.GhUrl,
 			&repo.CloneUrl,
 			&repo.ErrorMsg,
+			&repo.Priority,
+			pq.Array(&repo.CandidateFiles),
+			pq.Array(&repo.ChangedFiles),
+			&repo.Private,
+			&repo.OwnerUserID,
+			&repo.TeamID,
+			&repo.Visibility,
 			&repo.CreatedAt,
 			&repo.UpdatedAt,
 		)
@@ -191,9 +304,9 @@ func (r *sqlRepoRepository) GetAllRepos() ([]models.RepositoryModel, error) {
 	return repos, nil
 }
 
-// GetPendingRepos retrieves all pending repositories from the database
+// GetPendingRepos retrieves all pending repositories from the database, regardless of visibility. Only for background jobs.
 func (r *sqlRepoRepository) GetPendingRepos() ([]models.RepositoryModel, error) {
-	query := `SELECT id, name, author, state, language, stars, forks, size, last_push, api_url, gh_url, clone_url, created_at, updated_at FROM public.repository_tb WHERE state = 'PENDING'`
+	query := `SELECT id, name, author, forge, state, language, stars, forks, size, last_push, api_url, gh_url, clone_url, priority, candidate_files, changed_files, private, owner_user_id, team_id, visibility, created_at, updated_at FROM public.repository_tb WHERE state = 'PENDING' ORDER BY priority DESC, id`
 
 	rows, err := r.database.Query(query)
 	if err != nil {
@@ -208,6 +321,7 @@ func (r *sqlRepoRepository) GetPendingRepos() ([]models.RepositoryModel, error)
 			&repo.ID,
 			&repo.Name,
 			&repo.Author,
+			&repo.Forge,
 			&repo.State,
 			&repo.Language,
 			&repo.Stars,
@@ -217,6 +331,13 @@ func (r *sqlRepoRepository) GetPendingRepos() ([]models.RepositoryModel, error)
 			&repo.ApiUrl,
 			&repo.GhUrl,
 			&repo.CloneUrl,
+			&repo.Priority,
+			pq.Array(&repo.CandidateFiles),
+			pq.Array(&repo.ChangedFiles),
+			&repo.Private,
+			&repo.OwnerUserID,
+			&repo.TeamID,
+			&repo.Visibility,
 			&repo.CreatedAt,
 			&repo.UpdatedAt,
 		)
@@ -235,10 +356,33 @@ func (r *sqlRepoRepository) GetPendingRepos() ([]models.RepositoryModel, error)
 
 // UpdateRepo updates a repo in the database
 func (r *sqlRepoRepository) UpdateRepo(repo *models.RepositoryModel) error {
+	affected, err := r.updateRepo(repo, "", "")
+	if err != nil {
+		return err
+	}
+	if affected < 1 {
+		return ErrRepoNotFound
+	}
+	return nil
+}
+
+// FinishRepo updates a repo that was being parsed with the result of the parse.
+// It returns false without updating the repo if it was requeued while it was being parsed, e.g. after a push,
+// so the requeue isn't lost and the repo is parsed again.
+func (r *sqlRepoRepository) FinishRepo(repo *models.RepositoryModel) (bool, error) {
+	affected, err := r.updateRepo(repo, ", parsed_at = CURRENT_TIMESTAMP", " AND state = 'PROCESSING'")
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// updateRepo updates a repo matching the extra condition, also setting the extra columns in set, returning the number of rows updated
+func (r *sqlRepoRepository) updateRepo(repo *models.RepositoryModel, set, condition string) (int64, error) {
 	repo.BeforeUpdate()
-	query := `UPDATE public.repository_tb SET name = $1, author = $2, state = $3, language = $4, stars = $5, forks = $6, size = $7, last_push = $8, api_url = $9, gh_url = $10, clone_url = $11, error_msg = $12 WHERE id = $13`
+	query := `UPDATE public.repository_tb SET name = $1, author = $2, state = $3, language = $4, stars = $5, forks = $6, size = $7, last_push = $8, api_url = $9, gh_url = $10, clone_url = $11, error_msg = $12, forge = $13, changed_files = $14` + set + ` WHERE id = $15` + condition
 	if repo.CreatedAt.Unix() == 0 {
-		return fmt.Errorf("unable to update a repo that was not loaded from the database")
+		return 0, fmt.Errorf("unable to update a repo that was not loaded from the database")
 	}
 	rs, err := r.database.Exec(
 		query,
@@ -254,19 +398,21 @@ func (r *sqlRepoRepository) UpdateRepo(repo *models.RepositoryModel) error {
 		repo.GhUrl,
 		repo.CloneUrl,
 		repo.ErrorMsg,
+		repo.ForgeName(),
+		pq.Array(changedFiles(repo)),
 		repo.ID,
 	)
 	if err != nil {
-		return r.handleError(err)
+		return 0, r.handleError(err)
 	}
-	if affected, err := rs.RowsAffected(); affected < 1 {
-		if err != nil {
-			return err
-		}
-		return ErrRepoNotFound
+	affected, err := rs.RowsAffected()
+	if err != nil {
+		return 0, err
 	}
-	repo.AfterUpdate()
-	return nil
+	if affected > 0 {
+		repo.AfterUpdate()
+	}
+	return affected, nil
 }
 
 // DeleteRepo deletes a repo from the database
@@ -289,6 +435,82 @@ func (r *sqlRepoRepository) DeleteRepo(id int) error {
 	return nil
 }
 
+func (r *sqlRepoRepository) GetRepoIssueUrl(repoID int) (string, error) {
+	var url string
+	err := r.database.QueryRow(`SELECT url FROM public.repository_issue_tb WHERE repo_id = $1`, repoID).Scan(&url)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", r.handleError(err)
+	}
+	return url, nil
+}
+
+// SaveRepoIssue records the issue raised against a repo. An issue that is already recorded is kept.
+func (r *sqlRepoRepository) SaveRepoIssue(repoID, userID int, url string) error {
+	query := `INSERT INTO public.repository_issue_tb (repo_id, url, user_id) VALUES ($1, $2, $3) ON CONFLICT (repo_id) DO NOTHING`
+	if _, err := r.database.Exec(query, repoID, url, userID); err != nil {
+		return r.handleError(err)
+	}
+	return nil
+}
+
+// requeueRepoQuery marks repo $1 to be parsed again with changed files $2.
+// Repos being parsed are queued for a full parse, as the parse may have missed the changes.
+const requeueRepoQuery = `
+		UPDATE public.repository_tb
+		SET changed_files = CASE
+				WHEN cardinality($2::text[]) = 0 THEN '{}'
+				WHEN state = 'PENDING' AND cardinality(changed_files) = 0 THEN '{}'
+				WHEN state NOT IN ('COMPLETED', 'PENDING') THEN '{}'
+				ELSE ARRAY(SELECT DISTINCT unnest(changed_files || $2::text[]) ORDER BY 1)
+			END,
+			state = 'PENDING',
+			error_msg = '',
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND state != 'DELETED'`
+
+// RequeueRepo marks a repo to be parsed again, e.g. after a push. Only changedFiles are parsed, unless
+// it is empty, or the repo still needs a full parse, because it was never completed or is already queued for one.
+// Files are added to those of earlier requeues that haven't been parsed yet.
+func (r *sqlRepoRepository) RequeueRepo(id int, changedFiles []string) error {
+	return requeueRepo(r.database, id, changedFiles)
+}
+
+// requeueRepo runs requeueRepoQuery, with the db or in a transaction
+func requeueRepo(db interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}, id int, changedFiles []string) error {
+	rs, err := db.Exec(requeueRepoQuery, id, pq.Array(changedFiles))
+	if err != nil {
+		return fmt.Errorf("failed to requeue repo: %w", err)
+	}
+	if affected, err := rs.RowsAffected(); affected < 1 {
+		if err != nil {
+			return err
+		}
+		return ErrRepoNotFound
+	}
+	return nil
+}
+
+// candidateFiles avoids saving NULL, as the column is not nullable
+func candidateFiles(repo *models.RepositoryModel) []string {
+	if repo.CandidateFiles == nil {
+		return []string{}
+	}
+	return repo.CandidateFiles
+}
+
+// changedFiles avoids saving NULL, as the column is not nullable
+func changedFiles(repo *models.RepositoryModel) []string {
+	if repo.ChangedFiles == nil {
+		return []string{}
+	}
+	return repo.ChangedFiles
+}
+
 var (
 	ErrRepoNotFound = errors.New("repo not found") // ErrRepoNotFound is returned when a repo is not found in the database.
 	ErrRepoConnErr  = errors.New("repository connection lost")
@@ -78,6 +78,164 @@ func TestRepoRepository_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("Get pending repos by priority", func(t *testing.T) {
+		priorityRepo := models.RepositoryModel{
+			Name:           "links",
+			Author:         "googl-bye",
+			CloneUrl:       "https://github.com/googl-bye/links.git",
+			Priority:       models.PriorityHigh,
+			CandidateFiles: []string{"README.md"},
+		}
+		if err := repoRepo.CreateRepo(&priorityRepo); err != nil {
+			t.Fatalf("expected no error when creating repo but got %v", err)
+		}
+
+		pending, err := repoRepo.GetPendingRepos()
+		if err != nil {
+			t.Fatalf("expected no error when getting pending repos but got %v", err)
+		}
+		if len(pending) < 2 {
+			t.Fatalf("expected at least 2 pending repos but got %d", len(pending))
+		}
+		if pending[0].ID != priorityRepo.ID {
+			t.Errorf("expected high priority repo '%d' to be first but was '%d'", priorityRepo.ID, pending[0].ID)
+		}
+		if len(pending[0].CandidateFiles) != 1 || pending[0].CandidateFiles[0] != "README.md" {
+			t.Errorf("expected candidate files to be loaded but were %v", pending[0].CandidateFiles)
+		}
+
+		if err := repoRepo.DeleteRepo(priorityRepo.ID); err != nil {
+			t.Errorf("expected no error when deleting repo but got %v", err)
+		}
+	})
+
+	t.Run("Get repo by name", func(t *testing.T) {
+		loaded, err := repoRepo.GetRepoByName(models.ForgeGitHub, "GIN-GONIC", "gin")
+		if err != nil {
+			t.Fatalf("expected no error when getting repo by name but got %v", err)
+		}
+		if loaded == nil || loaded.ID != repos[1].ID {
+			t.Errorf("expected repo '%d' to be found ignoring case but got %v", repos[1].ID, loaded)
+		}
+
+		loaded, err = repoRepo.GetRepoByName(models.ForgeGitLab, "gin-gonic", "gin")
+		if err != nil {
+			t.Errorf("expected no error when getting untracked repo but got %v", err)
+		}
+		if loaded != nil {
+			t.Errorf("expected no repo on another forge but got %v", loaded)
+		}
+	})
+
+	t.Run("Requeue repo with changed files", func(t *testing.T) {
+		repos[1].State = "COMPLETED"
+		if err := repoRepo.UpdateRepo(&repos[1]); err != nil {
+			t.Fatalf("expected no error when updating repo but got %v", err)
+		}
+
+		if err := repoRepo.RequeueRepo(repos[1].ID, []string{"b.go"}); err != nil {
+			t.Fatalf("expected no error when requeueing repo but got %v", err)
+		}
+		// Files of pushes before the repo is parsed again are added together
+		if err := repoRepo.RequeueRepo(repos[1].ID, []string{"a.go", "b.go"}); err != nil {
+			t.Fatalf("expected no error when requeueing repo but got %v", err)
+		}
+
+		loaded, err := repoRepo.GetRepoByID(repos[1].ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting repo but got %v", err)
+		}
+		if loaded.State != "PENDING" {
+			t.Errorf("expected requeued repo to be pending but was '%s'", loaded.State)
+		}
+		if len(loaded.ChangedFiles) != 2 || loaded.ChangedFiles[0] != "a.go" || loaded.ChangedFiles[1] != "b.go" {
+			t.Errorf("expected changed files [a.go b.go] but were %v", loaded.ChangedFiles)
+		}
+
+		// Once a full parse is queued, it isn't narrowed down by later pushes
+		if err := repoRepo.RequeueRepo(repos[1].ID, nil); err != nil {
+			t.Fatalf("expected no error when requeueing repo but got %v", err)
+		}
+		if err := repoRepo.RequeueRepo(repos[1].ID, []string{"c.go"}); err != nil {
+			t.Fatalf("expected no error when requeueing repo but got %v", err)
+		}
+		loaded, err = repoRepo.GetRepoByID(repos[1].ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting repo but got %v", err)
+		}
+		if len(loaded.ChangedFiles) != 0 {
+			t.Errorf("expected full parse to be queued but changed files were %v", loaded.ChangedFiles)
+		}
+	})
+
+	t.Run("Finishing a repo requeued while parsing keeps it queued", func(t *testing.T) {
+		repos[1].State = "PROCESSING"
+		repos[1].ChangedFiles = nil
+		if err := repoRepo.UpdateRepo(&repos[1]); err != nil {
+			t.Fatalf("expected no error when updating repo but got %v", err)
+		}
+		if err := repoRepo.RequeueRepo(repos[1].ID, []string{"a.go"}); err != nil {
+			t.Fatalf("expected no error when requeueing repo but got %v", err)
+		}
+
+		repos[1].State = "COMPLETED"
+		finished, err := repoRepo.FinishRepo(&repos[1])
+		if err != nil {
+			t.Fatalf("expected no error when finishing repo but got %v", err)
+		}
+		if finished {
+			t.Error("expected requeued repo not to be finished")
+		}
+
+		loaded, err := repoRepo.GetRepoByID(repos[1].ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting repo but got %v", err)
+		}
+		if loaded.State != "PENDING" {
+			t.Errorf("expected requeued repo to be pending but was '%s'", loaded.State)
+		}
+		// The push may have been missed by the parse, so the whole repo is parsed again
+		if len(loaded.ChangedFiles) != 0 {
+			t.Errorf("expected full parse to be queued but changed files were %v", loaded.ChangedFiles)
+		}
+
+		loaded.State = "PROCESSING"
+		if err := repoRepo.UpdateRepo(loaded); err != nil {
+			t.Fatalf("expected no error when updating repo but got %v", err)
+		}
+		loaded.State = "COMPLETED"
+		finished, err = repoRepo.FinishRepo(loaded)
+		if err != nil {
+			t.Fatalf("expected no error when finishing repo but got %v", err)
+		}
+		if !finished {
+			t.Error("expected repo being parsed to be finished")
+		}
+	})
+
+	t.Run("Save repo issue", func(t *testing.T) {
+		user := &models.UserModel{GithubID: 1, Login: "jwtly10", AccessToken: []byte("encrypted")}
+		if err := repository.NewUserRepository(db).SaveUser(user); err != nil {
+			t.Fatal(err)
+		}
+
+		url, err := repoRepo.GetRepoIssueUrl(repos[0].ID)
+		if err != nil || url != "" {
+			t.Fatalf("expected no issue but got '%s', %v", url, err)
+		}
+
+		// Only the first issue is kept
+		for _, issue := range []string{"https://github.com/owner/repo/issues/1", "https://github.com/owner/repo/issues/2"} {
+			if err := repoRepo.SaveRepoIssue(repos[0].ID, user.ID, issue); err != nil {
+				t.Fatalf("expected no error when saving issue but got %v", err)
+			}
+		}
+		url, err = repoRepo.GetRepoIssueUrl(repos[0].ID)
+		if err != nil || url != "https://github.com/owner/repo/issues/1" {
+			t.Errorf("expected the first issue but got '%s', %v", url, err)
+		}
+	})
+
 	t.Run("Delete repos", func(t *testing.T) {
 		for _, repo := range repos {
 			if err := repoRepo.DeleteRepo(repo.ID); err != nil {
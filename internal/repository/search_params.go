@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/jwtly10/googl-bye/internal/models"
 )
@@ -11,7 +12,9 @@ import (
 type SearchParamRepository interface {
 	SaveSearchParams(params *models.SearchParamsModel) error
 	GetSearchParamsByID(id int) (*models.SearchParamsModel, error)
-	GetSearchParamsByName(name string) (*models.SearchParamsModel, error)
+	GetSearchParamsByName(name string, viewerID int) (*models.SearchParamsModel, error)
+	GetAllSearchParams(viewerID int) ([]models.SearchParamsModel, error)
+	GetDueSearchParams(now time.Time) ([]models.SearchParamsModel, error)
 }
 type sqlSearchParamRepository struct {
 	database *sql.DB
@@ -21,6 +24,7 @@ func NewSearchParamRepository(database *sql.DB) SearchParamRepository {
 	return &sqlSearchParamRepository{database: database}
 }
 
+// SaveSearchParams upserts the search params by owner and name. This is also used to save the progress of a search.
 func (r *sqlSearchParamRepository) SaveSearchParams(params *models.SearchParamsModel) error {
 	params.BeforeCreate()
 
@@ -30,33 +34,73 @@ func (r *sqlSearchParamRepository) SaveSearchParams(params *models.SearchParamsM
 		return fmt.Errorf("failed marshal opts: %w", err)
 	}
 
+	forge := params.Forge
+	if forge == "" {
+		forge = models.ForgeGitHub
+	}
+	visibility := params.Visibility
+	if visibility == "" {
+		visibility = models.VisibilityPublic
+	}
+
 	query := `
-		INSERT INTO public.search_params_history_tb (name, query, opts, start_page, current_page, pages_to_process)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		ON CONFLICT (name) DO UPDATE
+		INSERT INTO public.search_params_history_tb (name, query, forge, opts, start_page, current_page, pages_to_process, auto_save, repos_found, exhausted, schedule, last_run_at, next_run_at, owner_user_id, team_id, visibility)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT ((COALESCE(owner_user_id, 0)), name) DO UPDATE
 		SET query = EXCLUDED.query,
+			forge = EXCLUDED.forge,
 			opts = EXCLUDED.opts,
 			start_page = EXCLUDED.start_page,
 			current_page = EXCLUDED.current_page,
 			pages_to_process = EXCLUDED.pages_to_process,
+			auto_save = EXCLUDED.auto_save,
+			repos_found = EXCLUDED.repos_found,
+			exhausted = EXCLUDED.exhausted,
+			schedule = EXCLUDED.schedule,
+			last_run_at = EXCLUDED.last_run_at,
+			next_run_at = EXCLUDED.next_run_at,
+			team_id = EXCLUDED.team_id,
+			visibility = EXCLUDED.visibility,
 			updated_at = CURRENT_TIMESTAMP
-		RETURNING id, name, query, opts, start_page, current_page, pages_to_process`
+		RETURNING id, name, query, forge, opts, start_page, current_page, pages_to_process, auto_save, repos_found, exhausted, schedule, last_run_at, next_run_at, owner_user_id, team_id, visibility, created_at, updated_at`
 
 	var optsJSON []byte
 	err = r.database.QueryRow(query,
 		params.Name,
 		params.Query,
+		forge,
 		jsonOpts,
 		params.StartPage,
 		params.CurrentPage,
 		params.PagesToProcess,
+		params.AutoSave,
+		params.ReposFound,
+		params.Exhausted,
+		params.Schedule,
+		params.LastRunAt,
+		params.NextRunAt,
+		params.OwnerUserID,
+		params.TeamID,
+		visibility,
 	).Scan(&params.ID,
 		&params.Name,
 		&params.Query,
+		&params.Forge,
 		&optsJSON,
 		&params.StartPage,
 		&params.CurrentPage,
 		&params.PagesToProcess,
+		&params.AutoSave,
+		&params.ReposFound,
+		&params.Exhausted,
+		&params.Schedule,
+		&params.LastRunAt,
+		&params.NextRunAt,
+		&params.OwnerUserID,
+		&params.TeamID,
+		&params.Visibility,
+		&params.CreatedAt,
+		&params.UpdatedAt,
 	)
 
 	if err != nil {
@@ -73,69 +117,118 @@ func (r *sqlSearchParamRepository) SaveSearchParams(params *models.SearchParamsM
 	return nil
 }
 
-func (r *sqlSearchParamRepository) GetSearchParamsByID(id int) (*models.SearchParamsModel, error) {
-	query := `
-		SELECT id, name, query, opts, start_page, current_page, pages_to_process, created_at, updated_at
-		FROM public.search_params_history_tb
-		WHERE id = $1`
+const selectSearchParams = `
+		SELECT s.id, s.name, s.query, s.forge, s.opts, s.start_page, s.current_page, s.pages_to_process, s.auto_save, s.repos_found, s.exhausted, s.schedule, s.last_run_at, s.next_run_at, s.owner_user_id, s.team_id, s.visibility, s.created_at, s.updated_at
+		FROM public.search_params_history_tb s`
 
-	var params models.SearchParamsModel
-	var optsJSON []byte
+func (r *sqlSearchParamRepository) GetSearchParamsByID(id int) (*models.SearchParamsModel, error) {
+	params, err := scanSearchParams(r.database.QueryRow(selectSearchParams+` WHERE s.id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("search params with id %d not found", id)
+		}
+		return nil, fmt.Errorf("error querying search params: %w", err)
+	}
 
-	err := r.database.QueryRow(query, id).Scan(
-		&params.ID,
-		&params.Name,
-		&params.Query,
-		&optsJSON,
-		&params.StartPage,
-		&params.CurrentPage,
-		&params.PagesToProcess,
-		&params.CreatedAt,
-		&params.UpdatedAt,
-	)
+	return params, nil
+}
 
+// GetSearchParamsByName gets the search with the name that the viewer can see.
+// Names are unique per owner, so the viewer's own search is preferred, then a search without an owner.
+func (r *sqlSearchParamRepository) GetSearchParamsByName(name string, viewerID int) (*models.SearchParamsModel, error) {
+	query := selectSearchParams + `
+		WHERE s.name = $1 AND ` + visibleToViewer("s", "$2") + `
+		ORDER BY CASE WHEN s.owner_user_id = $2 THEN 0 WHEN s.owner_user_id IS NULL THEN 1 ELSE 2 END, s.id
+		LIMIT 1`
+	params, err := scanSearchParams(r.database.QueryRow(query, name, viewerID))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("search params with id %d not found", id)
+			return nil, nil
 		}
 		return nil, fmt.Errorf("error querying search params: %w", err)
 	}
 
-	// Unmarshal the JSON-encoded opts back into the struct
-	err = json.Unmarshal(optsJSON, &params.Opts)
+	return params, nil
+}
+
+// GetAllSearchParams returns all saved searches the viewer can see, most recently updated first
+func (r *sqlSearchParamRepository) GetAllSearchParams(viewerID int) ([]models.SearchParamsModel, error) {
+	rows, err := r.database.Query(selectSearchParams+`
+		WHERE `+visibleToViewer("s", "$1")+`
+		ORDER BY s.updated_at DESC, s.id DESC`, viewerID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal opts: %w", err)
+		return nil, fmt.Errorf("error querying search params: %w", err)
 	}
+	defer rows.Close()
 
-	return &params, nil
+	allParams := []models.SearchParamsModel{}
+	for rows.Next() {
+		params, err := scanSearchParams(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error querying search params: %w", err)
+		}
+		allParams = append(allParams, *params)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying search params: %w", err)
+	}
+
+	return allParams, nil
 }
 
-func (r *sqlSearchParamRepository) GetSearchParamsByName(name string) (*models.SearchParamsModel, error) {
-	query := `
-		SELECT id, name, query, opts, start_page, current_page, pages_to_process, created_at, updated_at
-		FROM public.search_params_history_tb
-		WHERE name = $1`
+// GetDueSearchParams returns scheduled searches that have never run, or are due to run at now
+func (r *sqlSearchParamRepository) GetDueSearchParams(now time.Time) ([]models.SearchParamsModel, error) {
+	rows, err := r.database.Query(selectSearchParams+`
+		WHERE s.schedule <> '' AND (s.next_run_at IS NULL OR s.next_run_at <= $1)
+		ORDER BY s.next_run_at NULLS FIRST, s.id`, now)
+	if err != nil {
+		return nil, fmt.Errorf("error querying due search params: %w", err)
+	}
+	defer rows.Close()
 
+	dueParams := []models.SearchParamsModel{}
+	for rows.Next() {
+		params, err := scanSearchParams(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error querying due search params: %w", err)
+		}
+		dueParams = append(dueParams, *params)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying due search params: %w", err)
+	}
+
+	return dueParams, nil
+}
+
+// scanSearchParams scans a row selected with selectSearchParams
+func scanSearchParams(row interface{ Scan(dest ...any) error }) (*models.SearchParamsModel, error) {
 	var params models.SearchParamsModel
 	var optsJSON []byte
 
-	err := r.database.QueryRow(query, name).Scan(
+	err := row.Scan(
 		&params.ID,
 		&params.Name,
 		&params.Query,
+		&params.Forge,
 		&optsJSON,
 		&params.StartPage,
 		&params.CurrentPage,
 		&params.PagesToProcess,
+		&params.AutoSave,
+		&params.ReposFound,
+		&params.Exhausted,
+		&params.Schedule,
+		&params.LastRunAt,
+		&params.NextRunAt,
+		&params.OwnerUserID,
+		&params.TeamID,
+		&params.Visibility,
 		&params.CreatedAt,
 		&params.UpdatedAt,
 	)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("error querying search params: %w", err)
+		return nil, err
 	}
 
 	// Unmarshal the JSON-encoded opts back into the struct
@@ -96,4 +96,102 @@ func TestSearchParamRepository_Integration(t *testing.T) {
 			t.Errorf("Expected updated Query to be 'updated golang', but got %s", updatedParams.Query)
 		}
 	})
+
+	t.Run("SaveSearchProgress", func(t *testing.T) {
+		params := &models.SearchParamsModel{
+			Name:           "ResumableSearch",
+			Query:          "goo.gl in:readme",
+			Opts:           github.SearchOptions{Sort: "stars", Order: "desc"},
+			StartPage:      1,
+			CurrentPage:    1,
+			PagesToProcess: 3,
+			AutoSave:       true,
+		}
+		if err := searchParamRepo.SaveSearchParams(params); err != nil {
+			t.Fatalf("Failed to save search params: %v", err)
+		}
+		if params.Forge != models.ForgeGitHub {
+			t.Errorf("Expected Forge to default to github, but got %s", params.Forge)
+		}
+
+		// Simulate a page being processed
+		params.CurrentPage = 2
+		params.ReposFound = 50
+		if err := searchParamRepo.SaveSearchParams(params); err != nil {
+			t.Fatalf("Failed to save search progress: %v", err)
+		}
+
+		saved, err := searchParamRepo.GetSearchParamsByName("ResumableSearch", repository.NoViewer)
+		if err != nil {
+			t.Fatalf("Failed to retrieve search params: %v", err)
+		}
+		if saved.CurrentPage != 2 || saved.ReposFound != 50 || !saved.AutoSave || saved.Exhausted {
+			t.Errorf("Expected saved progress, but got %+v", saved)
+		}
+		if saved.IsComplete() {
+			t.Error("Expected search with pages remaining to not be complete")
+		}
+
+		missing, err := searchParamRepo.GetSearchParamsByName("MissingSearch", repository.NoViewer)
+		if err != nil || missing != nil {
+			t.Errorf("Expected nil params and no error for a missing search, but got %v, %v", missing, err)
+		}
+	})
+
+	t.Run("GetAllSearchParams", func(t *testing.T) {
+		allParams, err := searchParamRepo.GetAllSearchParams(repository.NoViewer)
+		if err != nil {
+			t.Fatalf("Failed to get all search params: %v", err)
+		}
+		if len(allParams) != 2 {
+			t.Fatalf("Expected 2 saved searches, but got %d", len(allParams))
+		}
+		// Most recently updated first
+		if allParams[0].Name != "ResumableSearch" {
+			t.Errorf("Expected ResumableSearch first, but got %s", allParams[0].Name)
+		}
+	})
+
+	t.Run("Searches are only visible to their owner unless public", func(t *testing.T) {
+		userRepo := repository.NewUserRepository(db)
+		alice := &models.UserModel{GithubID: 101, Login: "alice", AccessToken: []byte("encrypted")}
+		bob := &models.UserModel{GithubID: 102, Login: "bob", AccessToken: []byte("encrypted")}
+		for _, user := range []*models.UserModel{alice, bob} {
+			if err := userRepo.SaveUser(user); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// The same name can be saved by another owner without replacing their search
+		private := &models.SearchParamsModel{Name: "ResumableSearch", Query: "alice's query", PagesToProcess: 1, OwnerUserID: &alice.ID, Visibility: models.VisibilityPrivate}
+		if err := searchParamRepo.SaveSearchParams(private); err != nil {
+			t.Fatalf("Failed to save search params: %v", err)
+		}
+
+		saved, err := searchParamRepo.GetSearchParamsByName("ResumableSearch", alice.ID)
+		if err != nil {
+			t.Fatalf("Failed to get search params: %v", err)
+		}
+		if saved.ID != private.ID {
+			t.Errorf("Expected alice to get her own search, but got %+v", saved)
+		}
+
+		saved, err = searchParamRepo.GetSearchParamsByName("ResumableSearch", bob.ID)
+		if err != nil {
+			t.Fatalf("Failed to get search params: %v", err)
+		}
+		if saved.Query != "goo.gl in:readme" {
+			t.Errorf("Expected bob to get the public search, but got %+v", saved)
+		}
+
+		for viewer, expected := range map[int]int{alice.ID: 3, bob.ID: 2, repository.NoViewer: 2} {
+			allParams, err := searchParamRepo.GetAllSearchParams(viewer)
+			if err != nil {
+				t.Fatalf("Failed to get all search params: %v", err)
+			}
+			if len(allParams) != expected {
+				t.Errorf("Expected viewer %d to see %d searches, but got %d", viewer, expected, len(allParams))
+			}
+		}
+	})
 }
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+type SessionRepository interface {
+	CreateSession(session *models.SessionModel) error
+	GetSessionByTokenHash(tokenHash string, now time.Time) (*models.SessionModel, error)
+	DeleteSession(tokenHash string) error
+	DeleteExpiredSessions(now time.Time) (int64, error)
+}
+
+type sqlSessionRepository struct {
+	database *sql.DB
+}
+
+func NewSessionRepository(database *sql.DB) SessionRepository {
+	return &sqlSessionRepository{database: database}
+}
+
+func (r *sqlSessionRepository) CreateSession(session *models.SessionModel) error {
+	session.BeforeCreate()
+
+	query := `
+		INSERT INTO public.session_tb (token_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at`
+
+	err := r.database.QueryRow(query,
+		session.TokenHash,
+		session.UserID,
+		session.ExpiresAt,
+	).Scan(&session.ID, &session.CreatedAt, &session.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert session: %w", err)
+	}
+
+	session.AfterCreate()
+	return nil
+}
+
+// GetSessionByTokenHash gets a session that hasn't expired. Returns nil if there is no such session.
+func (r *sqlSessionRepository) GetSessionByTokenHash(tokenHash string, now time.Time) (*models.SessionModel, error) {
+	query := `SELECT id, token_hash, user_id, expires_at, created_at, updated_at FROM public.session_tb WHERE token_hash = $1 AND expires_at > $2`
+
+	session := &models.SessionModel{}
+	err := r.database.QueryRow(query, tokenHash, now).Scan(
+		&session.ID,
+		&session.TokenHash,
+		&session.UserID,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+		&session.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error querying session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *sqlSessionRepository) DeleteSession(tokenHash string) error {
+	_, err := r.database.Exec(`DELETE FROM public.session_tb WHERE token_hash = $1`, tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredSessions deletes sessions that have expired, returning how many were deleted
+func (r *sqlSessionRepository) DeleteExpiredSessions(now time.Time) (int64, error) {
+	res, err := r.database.Exec(`DELETE FROM public.session_tb WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,83 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/test"
+)
+
+func TestSessionRepository_Integration(t *testing.T) {
+	container, db, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+
+	userRepo := repository.NewUserRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+
+	user := &models.UserModel{GithubID: 1, Login: "jwtly10", AccessToken: []byte("encrypted")}
+	if err := userRepo.SaveUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+
+	t.Run("Get active session", func(t *testing.T) {
+		session := &models.SessionModel{TokenHash: "active", UserID: user.ID, ExpiresAt: now.Add(time.Hour)}
+		if err := sessionRepo.CreateSession(session); err != nil {
+			t.Fatalf("expected no error when creating session but got %v", err)
+		}
+
+		loaded, err := sessionRepo.GetSessionByTokenHash("active", now)
+		if err != nil {
+			t.Fatalf("expected no error when getting session but got %v", err)
+		}
+		if loaded == nil || loaded.UserID != user.ID {
+			t.Errorf("expected session for user %d but got %v", user.ID, loaded)
+		}
+	})
+
+	t.Run("Expired sessions are not returned", func(t *testing.T) {
+		session := &models.SessionModel{TokenHash: "expired", UserID: user.ID, ExpiresAt: now.Add(-time.Hour)}
+		if err := sessionRepo.CreateSession(session); err != nil {
+			t.Fatalf("expected no error when creating session but got %v", err)
+		}
+
+		loaded, err := sessionRepo.GetSessionByTokenHash("expired", now)
+		if err != nil {
+			t.Errorf("expected no error when getting expired session but got %v", err)
+		}
+		if loaded != nil {
+			t.Errorf("expected no session but got %v", loaded)
+		}
+
+		deleted, err := sessionRepo.DeleteExpiredSessions(now)
+		if err != nil {
+			t.Fatalf("expected no error when deleting expired sessions but got %v", err)
+		}
+		if deleted != 1 {
+			t.Errorf("expected 1 expired session to be deleted but got %d", deleted)
+		}
+	})
+
+	t.Run("Delete session", func(t *testing.T) {
+		if err := sessionRepo.DeleteSession("active"); err != nil {
+			t.Fatalf("expected no error when deleting session but got %v", err)
+		}
+
+		loaded, err := sessionRepo.GetSessionByTokenHash("active", now)
+		if err != nil {
+			t.Errorf("expected no error when getting deleted session but got %v", err)
+		}
+		if loaded != nil {
+			t.Errorf("expected no session but got %v", loaded)
+		}
+	})
+}
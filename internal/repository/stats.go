@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"net"
+	"reflect"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+type StatsRepository interface {
+	// GetStats computes the dashboard statistics of the repos the viewer can see. top limits the ranked lists, days is the length of the time series
+	GetStats(top, days, viewerID int) (*models.StatsModel, error)
+}
+
+type sqlStatsRepository struct {
+	database *sql.DB
+}
+
+func NewStatsRepository(database *sql.DB) StatsRepository {
+	return &sqlStatsRepository{database: database}
+}
+
+func (r *sqlStatsRepository) GetStats(top, days, viewerID int) (*models.StatsModel, error) {
+	stats := &models.StatsModel{
+		GeneratedAt:      time.Now(),
+		ReposByState:     make(map[string]int),
+		LinksByExpansion: make(map[string]int),
+	}
+
+	// Deleted repos, and their links, are left out like everywhere else
+	reposByState, err := r.queryCounts(`SELECT r.state, COUNT(*) FROM repository_tb r WHERE r.state != 'DELETED' AND `+visibleTo(1)+` GROUP BY r.state ORDER BY r.state`, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range reposByState {
+		stats.ReposByState[c.Label] = c.Value
+		stats.TotalRepos += c.Value
+	}
+
+	// Expansion failures are saved by the parser as 'ERROR: <reason>'
+	linksByExpansion, err := r.queryCounts(`
+        SELECT 
+            CASE 
+                WHEN expanded_url LIKE 'ERROR:%' THEN 'FAILED'
+                WHEN expanded_url IS NULL OR expanded_url = '' THEN 'UNEXPANDED'
+                ELSE 'EXPANDED'
+            END AS status,
+            COUNT(*)
+        FROM parser_links_tb l
+        JOIN repository_tb r ON r.id = l.repo_id
+        WHERE r.state != 'DELETED' AND `+visibleTo(1)+`
+        GROUP BY status
+    `, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range linksByExpansion {
+		stats.LinksByExpansion[c.Label] = c.Value
+		stats.TotalLinks += c.Value
+	}
+
+	stats.TopAuthors, err = r.queryCounts(`
+        SELECT r.author, COUNT(l.id) AS links
+        FROM repository_tb r
+        JOIN parser_links_tb l ON r.id = l.repo_id
+        WHERE r.state != 'DELETED' AND `+visibleTo(2)+`
+        GROUP BY r.author
+        ORDER BY links DESC, r.author
+        LIMIT $1
+    `, top, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.TopLanguages, err = r.queryCounts(`
+        SELECT COALESCE(NULLIF(r.language, ''), 'Unknown') AS lang, COUNT(l.id) AS links
+        FROM repository_tb r
+        JOIN parser_links_tb l ON r.id = l.repo_id
+        WHERE r.state != 'DELETED' AND `+visibleTo(2)+`
+        GROUP BY lang
+        ORDER BY links DESC, lang
+        LIMIT $1
+    `, top, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The same link is written with and without a scheme, so compare without it
+	stats.TopShortUrls, err = r.queryCounts(`
+        SELECT LOWER(REGEXP_REPLACE(l.url, '^https?://', '')) AS short_url, COUNT(*) AS links
+        FROM parser_links_tb l
+        JOIN repository_tb r ON r.id = l.repo_id
+        WHERE r.state != 'DELETED' AND `+visibleTo(2)+`
+        GROUP BY short_url
+        ORDER BY links DESC, short_url
+        LIMIT $1
+    `, top, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Repos are 'scanned' on the day the parser last finished them, days without scans are included as 0.
+	// updated_at can't be used, as it changes whenever a repo is requeued or edited.
+	stats.ReposScannedPerDay, err = r.queryCounts(`
+        SELECT TO_CHAR(d.day, 'YYYY-MM-DD'), COUNT(r.id)
+        FROM GENERATE_SERIES(CURRENT_DATE - ($1::INTEGER - 1), CURRENT_DATE, INTERVAL '1 day') AS d(day)
+        LEFT JOIN repository_tb r 
+            ON r.parsed_at::DATE = d.day::DATE
+            AND r.state IN ('COMPLETED', 'ERROR')
+            AND `+visibleTo(2)+`
+        GROUP BY d.day
+        ORDER BY d.day
+    `, days, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// queryCounts runs a query returning (label, count) rows
+func (r *sqlStatsRepository) queryCounts(query string, args ...interface{}) ([]models.StatCount, error) {
+	rows, err := r.database.Query(query, args...)
+	if err != nil {
+		return nil, r.handleError(err)
+	}
+	defer rows.Close()
+
+	counts := make([]models.StatCount, 0)
+	for rows.Next() {
+		var c models.StatCount
+		if err := rows.Scan(&c.Label, &c.Value); err != nil {
+			return nil, r.handleError(err)
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, r.handleError(err)
+	}
+	return counts, nil
+}
+
+func (r *sqlStatsRepository) handleError(err error) error {
+	if reflect.TypeOf(err) == reflect.TypeOf(&net.OpError{}) {
+		return ErrRepoConnErr
+	}
+	return err
+}
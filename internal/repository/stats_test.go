@@ -0,0 +1,106 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/test"
+)
+
+func TestStatsRepository_Integration(t *testing.T) {
+	container, db, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+
+	statsRepo := repository.NewStatsRepository(db)
+	parserLinkRepo := repository.NewParserLinkRepository(db)
+	repoRepo := repository.NewRepoRepository(db)
+
+	t.Run("Get stats of an empty database", func(t *testing.T) {
+		stats, err := statsRepo.GetStats(10, 7, repository.NoViewer)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if stats.TotalRepos != 0 || stats.TotalLinks != 0 {
+			t.Errorf("expected no repos or links, got %+v", stats)
+		}
+		if len(stats.ReposScannedPerDay) != 7 {
+			t.Errorf("expected a day for each of the last 7 days, got %d", len(stats.ReposScannedPerDay))
+		}
+	})
+
+	completed := models.RepositoryModel{Name: "repo", Author: "alice", Language: "Go", CloneUrl: "https://github.com/alice/repo.git"}
+	pending := models.RepositoryModel{Name: "other", Author: "bob", CloneUrl: "https://github.com/bob/other.git"}
+	deleted := models.RepositoryModel{Name: "gone", Author: "carol", CloneUrl: "https://github.com/carol/gone.git"}
+	for _, repo := range []*models.RepositoryModel{&completed, &pending, &deleted} {
+		if err := repoRepo.CreateRepo(repo); err != nil {
+			t.Fatalf("expected no error when creating repo but got %v", err)
+		}
+	}
+	completed.State = "PROCESSING"
+	if err := repoRepo.UpdateRepo(&completed); err != nil {
+		t.Fatalf("expected no error when updating repo but got %v", err)
+	}
+	completed.State = "COMPLETED"
+	if finished, err := repoRepo.FinishRepo(&completed); err != nil || !finished {
+		t.Fatalf("expected repo to be finished but got %v, %v", finished, err)
+	}
+	if err := repoRepo.DeleteRepo(deleted.ID); err != nil {
+		t.Fatalf("expected no error when deleting repo but got %v", err)
+	}
+	// Editing a repo after it was parsed doesn't count as another scan
+	pending.State = "COMPLETED"
+	if err := repoRepo.UpdateRepo(&pending); err != nil {
+		t.Fatalf("expected no error when updating repo but got %v", err)
+	}
+	pending.State = "PENDING"
+	if err := repoRepo.UpdateRepo(&pending); err != nil {
+		t.Fatalf("expected no error when updating repo but got %v", err)
+	}
+
+	links := []models.ParserLinksModel{
+		{RepoId: completed.ID, Url: "https://goo.gl/abc", ExpandedUrl: "https://example.com", File: "a.go", LineNumber: 1},
+		{RepoId: completed.ID, Url: "goo.gl/abc", ExpandedUrl: "https://example.com", File: "b.go", LineNumber: 1},
+		{RepoId: completed.ID, Url: "goo.gl/xyz", ExpandedUrl: "ERROR: unexpected status code 404", File: "c.go", LineNumber: 1},
+		{RepoId: deleted.ID, Url: "goo.gl/abc", ExpandedUrl: "https://example.com", File: "d.go", LineNumber: 1},
+	}
+	for i := range links {
+		if err := parserLinkRepo.CreateParserLink(&links[i]); err != nil {
+			t.Fatalf("expected no error when creating parser link but got %v", err)
+		}
+	}
+
+	t.Run("Get stats", func(t *testing.T) {
+		stats, err := statsRepo.GetStats(1, 7, repository.NoViewer)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		// Deleted repos and their links aren't counted
+		if stats.TotalRepos != 2 || stats.ReposByState["COMPLETED"] != 1 || stats.ReposByState["PENDING"] != 1 || stats.ReposByState["DELETED"] != 0 {
+			t.Errorf("unexpected repos by state: %+v", stats.ReposByState)
+		}
+		if stats.TotalLinks != 3 || stats.LinksByExpansion[models.LinkExpanded] != 2 || stats.LinksByExpansion[models.LinkFailed] != 1 {
+			t.Errorf("unexpected links by expansion: %+v", stats.LinksByExpansion)
+		}
+		if len(stats.TopAuthors) != 1 || stats.TopAuthors[0] != (models.StatCount{Label: "alice", Value: 3}) {
+			t.Errorf("unexpected top authors: %+v", stats.TopAuthors)
+		}
+		if len(stats.TopLanguages) != 1 || stats.TopLanguages[0] != (models.StatCount{Label: "Go", Value: 3}) {
+			t.Errorf("unexpected top languages: %+v", stats.TopLanguages)
+		}
+		// Links with and without a scheme are counted together
+		if len(stats.TopShortUrls) != 1 || stats.TopShortUrls[0] != (models.StatCount{Label: "goo.gl/abc", Value: 2}) {
+			t.Errorf("unexpected top short urls: %+v", stats.TopShortUrls)
+		}
+		if today := stats.ReposScannedPerDay[len(stats.ReposScannedPerDay)-1]; today.Value != 1 {
+			t.Errorf("expected 1 repo scanned today, got %+v", today)
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+type TeamRepository interface {
+	CreateTeam(team *models.TeamModel, ownerID int) error
+	GetTeamByID(id int) (*models.TeamModel, error)
+	GetTeamsForUser(userID int) ([]models.TeamModel, error)
+	GetTeamMember(teamID, userID int) (*models.TeamMemberModel, error)
+	GetTeamMembers(teamID int) ([]models.TeamMemberModel, error)
+	AddTeamMember(member *models.TeamMemberModel) error
+	RemoveTeamMember(teamID, userID int) error
+}
+
+type sqlTeamRepository struct {
+	database *sql.DB
+}
+
+func NewTeamRepository(database *sql.DB) TeamRepository {
+	return &sqlTeamRepository{database: database}
+}
+
+// CreateTeam inserts a team, with the user creating it as its owner
+func (r *sqlTeamRepository) CreateTeam(team *models.TeamModel, ownerID int) error {
+	team.BeforeCreate()
+
+	tx, err := r.database.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	err = tx.QueryRow(`INSERT INTO public.team_tb (name) VALUES ($1) RETURNING id, created_at, updated_at`, team.Name).
+		Scan(&team.ID, &team.CreatedAt, &team.UpdatedAt)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to insert team: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO public.team_member_tb (team_id, user_id, role) VALUES ($1, $2, $3)`, team.ID, ownerID, models.TeamRoleOwner)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to insert team owner: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	team.AfterCreate()
+	return nil
+}
+
+// GetTeamByID gets a team. Returns nil if the team doesn't exist.
+func (r *sqlTeamRepository) GetTeamByID(id int) (*models.TeamModel, error) {
+	team := &models.TeamModel{}
+	err := r.database.QueryRow(`SELECT id, name, created_at, updated_at FROM public.team_tb WHERE id = $1`, id).
+		Scan(&team.ID, &team.Name, &team.CreatedAt, &team.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error querying team: %w", err)
+	}
+	return team, nil
+}
+
+// GetTeamsForUser gets the teams a user is a member of
+func (r *sqlTeamRepository) GetTeamsForUser(userID int) ([]models.TeamModel, error) {
+	query := `
+		SELECT t.id, t.name, t.created_at, t.updated_at
+		FROM public.team_tb t
+		JOIN public.team_member_tb m ON m.team_id = t.id
+		WHERE m.user_id = $1
+		ORDER BY t.name`
+
+	rows, err := r.database.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying teams: %w", err)
+	}
+	defer rows.Close()
+
+	teams := make([]models.TeamModel, 0)
+	for rows.Next() {
+		var team models.TeamModel
+		if err := rows.Scan(&team.ID, &team.Name, &team.CreatedAt, &team.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning team: %w", err)
+		}
+		teams = append(teams, team)
+	}
+
+	return teams, rows.Err()
+}
+
+// GetTeamMember gets a user's membership of a team. Returns nil if they aren't a member.
+func (r *sqlTeamRepository) GetTeamMember(teamID, userID int) (*models.TeamMemberModel, error) {
+	query := `
+		SELECT m.id, m.team_id, m.user_id, m.role, u.login, m.created_at, m.updated_at
+		FROM public.team_member_tb m
+		JOIN public.user_tb u ON u.id = m.user_id
+		WHERE m.team_id = $1 AND m.user_id = $2`
+
+	member := &models.TeamMemberModel{}
+	err := r.database.QueryRow(query, teamID, userID).Scan(
+		&member.ID,
+		&member.TeamID,
+		&member.UserID,
+		&member.Role,
+		&member.Login,
+		&member.CreatedAt,
+		&member.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error querying team member: %w", err)
+	}
+
+	return member, nil
+}
+
+func (r *sqlTeamRepository) GetTeamMembers(teamID int) ([]models.TeamMemberModel, error) {
+	query := `
+		SELECT m.id, m.team_id, m.user_id, m.role, u.login, m.created_at, m.updated_at
+		FROM public.team_member_tb m
+		JOIN public.user_tb u ON u.id = m.user_id
+		WHERE m.team_id = $1
+		ORDER BY u.login`
+
+	rows, err := r.database.Query(query, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying team members: %w", err)
+	}
+	defer rows.Close()
+
+	members := make([]models.TeamMemberModel, 0)
+	for rows.Next() {
+		var member models.TeamMemberModel
+		err := rows.Scan(
+			&member.ID,
+			&member.TeamID,
+			&member.UserID,
+			&member.Role,
+			&member.Login,
+			&member.CreatedAt,
+			&member.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning team member: %w", err)
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// AddTeamMember adds a user to a team, updating their role if they are already a member
+func (r *sqlTeamRepository) AddTeamMember(member *models.TeamMemberModel) error {
+	member.BeforeCreate()
+
+	query := `
+		INSERT INTO public.team_member_tb (team_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (team_id, user_id) DO UPDATE
+		SET role = EXCLUDED.role,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at, updated_at`
+
+	err := r.database.QueryRow(query, member.TeamID, member.UserID, member.Role).
+		Scan(&member.ID, &member.CreatedAt, &member.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert team member: %w", err)
+	}
+
+	member.AfterCreate()
+	return nil
+}
+
+func (r *sqlTeamRepository) RemoveTeamMember(teamID, userID int) error {
+	_, err := r.database.Exec(`DELETE FROM public.team_member_tb WHERE team_id = $1 AND user_id = $2`, teamID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete team member: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/test"
+)
+
+func TestTeamRepository_Integration(t *testing.T) {
+	container, db, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+
+	userRepo := repository.NewUserRepository(db)
+	teamRepo := repository.NewTeamRepository(db)
+
+	owner := &models.UserModel{GithubID: 1, Login: "alice", AccessToken: []byte("encrypted")}
+	member := &models.UserModel{GithubID: 2, Login: "bob", AccessToken: []byte("encrypted")}
+	for _, user := range []*models.UserModel{owner, member} {
+		if err := userRepo.SaveUser(user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	team := &models.TeamModel{Name: "platform"}
+
+	t.Run("Create team with owner", func(t *testing.T) {
+		if err := teamRepo.CreateTeam(team, owner.ID); err != nil {
+			t.Fatalf("expected no error when creating team but got %v", err)
+		}
+
+		m, err := teamRepo.GetTeamMember(team.ID, owner.ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting team member but got %v", err)
+		}
+		if m == nil || m.Role != models.TeamRoleOwner || m.Login != "alice" {
+			t.Errorf("expected alice to own the team but got %v", m)
+		}
+
+		loaded, err := teamRepo.GetTeamByID(team.ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting team but got %v", err)
+		}
+		if loaded == nil || loaded.Name != "platform" {
+			t.Errorf("expected platform team but got %v", loaded)
+		}
+
+		if err := teamRepo.CreateTeam(&models.TeamModel{Name: "platform"}, owner.ID); err == nil {
+			t.Errorf("expected an error when creating a team with a duplicate name")
+		}
+	})
+
+	t.Run("Add and remove members", func(t *testing.T) {
+		if err := teamRepo.AddTeamMember(&models.TeamMemberModel{TeamID: team.ID, UserID: member.ID, Role: models.TeamRoleMember}); err != nil {
+			t.Fatalf("expected no error when adding team member but got %v", err)
+		}
+
+		members, err := teamRepo.GetTeamMembers(team.ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting team members but got %v", err)
+		}
+		if len(members) != 2 || members[0].Login != "alice" || members[1].Login != "bob" {
+			t.Errorf("expected alice and bob to be members but got %v", members)
+		}
+
+		teams, err := teamRepo.GetTeamsForUser(member.ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting teams but got %v", err)
+		}
+		if len(teams) != 1 || teams[0].Name != "platform" {
+			t.Errorf("expected bob to be in the platform team but got %v", teams)
+		}
+
+		if err := teamRepo.RemoveTeamMember(team.ID, member.ID); err != nil {
+			t.Fatalf("expected no error when removing team member but got %v", err)
+		}
+		m, err := teamRepo.GetTeamMember(team.ID, member.ID)
+		if err != nil {
+			t.Errorf("expected no error when getting removed member but got %v", err)
+		}
+		if m != nil {
+			t.Errorf("expected bob to be removed but got %v", m)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+type UserRepository interface {
+	SaveUser(user *models.UserModel) error
+	GetUserByID(id int) (*models.UserModel, error)
+	GetUserByLogin(login string) (*models.UserModel, error)
+}
+
+type sqlUserRepository struct {
+	database *sql.DB
+}
+
+func NewUserRepository(database *sql.DB) UserRepository {
+	return &sqlUserRepository{database: database}
+}
+
+// SaveUser upserts the user by GitHub ID, so the profile and token are refreshed on every sign in
+func (r *sqlUserRepository) SaveUser(user *models.UserModel) error {
+	user.BeforeCreate()
+
+	query := `
+		INSERT INTO public.user_tb (github_id, login, name, avatar_url, access_token)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (github_id) DO UPDATE
+		SET login = EXCLUDED.login,
+			name = EXCLUDED.name,
+			avatar_url = EXCLUDED.avatar_url,
+			access_token = EXCLUDED.access_token,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING id, created_at, updated_at`
+
+	err := r.database.QueryRow(query,
+		user.GithubID,
+		user.Login,
+		user.Name,
+		user.AvatarUrl,
+		user.AccessToken,
+	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert user: %w", err)
+	}
+
+	user.AfterCreate()
+	return nil
+}
+
+// GetUserByID gets a user. Returns nil if the user doesn't exist.
+func (r *sqlUserRepository) GetUserByID(id int) (*models.UserModel, error) {
+	return r.getUser(`SELECT id, github_id, login, name, avatar_url, access_token, created_at, updated_at FROM public.user_tb WHERE id = $1`, id)
+}
+
+// GetUserByLogin gets a user by their GitHub login, ignoring case. Returns nil if the user has never signed in.
+func (r *sqlUserRepository) GetUserByLogin(login string) (*models.UserModel, error) {
+	return r.getUser(`SELECT id, github_id, login, name, avatar_url, access_token, created_at, updated_at FROM public.user_tb WHERE LOWER(login) = LOWER($1)`, login)
+}
+
+func (r *sqlUserRepository) getUser(query string, arg interface{}) (*models.UserModel, error) {
+	user := &models.UserModel{}
+	err := r.database.QueryRow(query, arg).Scan(
+		&user.ID,
+		&user.GithubID,
+		&user.Login,
+		&user.Name,
+		&user.AvatarUrl,
+		&user.AccessToken,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error querying user: %w", err)
+	}
+
+	return user, nil
+}
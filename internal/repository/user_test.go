@@ -0,0 +1,83 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/test"
+)
+
+func TestUserRepository_Integration(t *testing.T) {
+	container, db, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+
+	userRepo := repository.NewUserRepository(db)
+
+	t.Run("Get missing user", func(t *testing.T) {
+		user, err := userRepo.GetUserByID(999)
+		if err != nil {
+			t.Errorf("expected no error when getting missing user but got %v", err)
+		}
+		if user != nil {
+			t.Errorf("expected no user but got %v", user)
+		}
+	})
+
+	t.Run("Save and update user", func(t *testing.T) {
+		user := &models.UserModel{
+			GithubID:    1234,
+			Login:       "jwtly10",
+			AvatarUrl:   "https://avatars.githubusercontent.com/u/1234",
+			AccessToken: []byte("encrypted"),
+		}
+		if err := userRepo.SaveUser(user); err != nil {
+			t.Fatalf("expected no error when saving user but got %v", err)
+		}
+
+		updated := &models.UserModel{
+			GithubID:    1234,
+			Login:       "jwtly10",
+			Name:        "Josh",
+			AccessToken: []byte("encrypted-again"),
+		}
+		if err := userRepo.SaveUser(updated); err != nil {
+			t.Fatalf("expected no error when updating user but got %v", err)
+		}
+		if updated.ID != user.ID {
+			t.Errorf("expected user %d to be updated but got user %d", user.ID, updated.ID)
+		}
+
+		loaded, err := userRepo.GetUserByID(user.ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting user but got %v", err)
+		}
+		if loaded.Name != "Josh" || string(loaded.AccessToken) != "encrypted-again" {
+			t.Errorf("expected user to be updated but was %v", loaded)
+		}
+	})
+
+	t.Run("Get user by login", func(t *testing.T) {
+		user, err := userRepo.GetUserByLogin("JWTLY10")
+		if err != nil {
+			t.Fatalf("expected no error when getting user but got %v", err)
+		}
+		if user == nil || user.GithubID != 1234 {
+			t.Errorf("expected user 1234 but got %v", user)
+		}
+
+		user, err = userRepo.GetUserByLogin("nobody")
+		if err != nil {
+			t.Errorf("expected no error when getting missing user but got %v", err)
+		}
+		if user != nil {
+			t.Errorf("expected no user but got %v", user)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package repository
+
+import "fmt"
+
+// visibleTo is a condition matching the repos (aliased r) the viewer in query param $n can see.
+// Public repos are visible to everyone, team repos to members of their team, and every repo to its owner
+// and to those it was shared with when saving it again. Grants without a user or team share the repo with everyone.
+func visibleTo(n int) string {
+	return repoVisibleToViewer("r", fmt.Sprintf("$%d", n))
+}
+
+// repoVisibleToViewer is visibleTo for the repos aliased alias, with the viewer given by an SQL expression,
+// e.g. a column of another table
+func repoVisibleToViewer(alias, viewer string) string {
+	return fmt.Sprintf(`(
+                %[3]s
+                OR %[1]s.id IN (
+                    SELECT a.repo_id FROM repository_access_tb a
+                    WHERE (a.user_id IS NULL AND a.team_id IS NULL)
+                        OR a.user_id = %[2]s
+                        OR a.team_id IN (SELECT m.team_id FROM team_member_tb m WHERE m.user_id = %[2]s)
+                )
+            )`, alias, viewer, visibleToViewer(alias, viewer))
+}
+
+// visibleToViewer is visibleTo for the rows of the table aliased alias, with the viewer given by an SQL expression,
+// e.g. a column of another table. The table needs visibility, owner_user_id and team_id columns.
+func visibleToViewer(alias, viewer string) string {
+	return fmt.Sprintf(`(
+                %[1]s.visibility = 'public'
+                OR %[1]s.owner_user_id = %[2]s
+                OR (%[1]s.visibility = 'team' AND %[1]s.team_id IN (SELECT m.team_id FROM team_member_tb m WHERE m.user_id = %[2]s))
+            )`, alias, viewer)
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+type WebhookDeliveryRepository interface {
+	RequeueForDelivery(delivery *models.WebhookDeliveryModel, repoID int, changedFiles []string) (bool, error)
+}
+
+type sqlWebhookDeliveryRepository struct {
+	database *sql.DB
+}
+
+func NewWebhookDeliveryRepository(database *sql.DB) WebhookDeliveryRepository {
+	return &sqlWebhookDeliveryRepository{database: database}
+}
+
+// RequeueForDelivery records a delivery and requeues the repo it pushed to, see RequeueRepo.
+// Both happen in one transaction, so a delivery is only recorded once its repo is queued.
+// It returns false without requeueing the repo if the delivery was already recorded.
+func (r *sqlWebhookDeliveryRepository) RequeueForDelivery(delivery *models.WebhookDeliveryModel, repoID int, changedFiles []string) (bool, error) {
+	delivery.BeforeCreate()
+
+	tx, err := r.database.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO public.webhook_delivery_tb (delivery_id, event)
+		VALUES ($1, $2)
+		ON CONFLICT (delivery_id) DO NOTHING
+		RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRow(query, delivery.DeliveryID, delivery.Event).Scan(&delivery.ID, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to insert webhook delivery: %w", err)
+	}
+
+	if err := requeueRepo(tx, repoID, changedFiles); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit webhook delivery: %w", err)
+	}
+
+	delivery.AfterCreate()
+	return true, nil
+}
@@ -0,0 +1,89 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/test"
+)
+
+func TestWebhookDeliveryRepository_Integration(t *testing.T) {
+	container, db, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+
+	deliveryRepo := repository.NewWebhookDeliveryRepository(db)
+	repoRepo := repository.NewRepoRepository(db)
+
+	repo := models.RepositoryModel{
+		Name:     "gin",
+		Author:   "gin-gonic",
+		ApiUrl:   "https://api.github.com/repos/gin-gonic/gin",
+		GhUrl:    "https://github.com/gin-gonic/gin",
+		CloneUrl: "https://github.com/gin-gonic/gin.git",
+	}
+	if err := repoRepo.CreateRepo(&repo); err != nil {
+		t.Fatalf("expected no error when creating repo but got %v", err)
+	}
+	repo.State = "COMPLETED"
+	if err := repoRepo.UpdateRepo(&repo); err != nil {
+		t.Fatalf("expected no error when updating repo but got %v", err)
+	}
+
+	t.Run("Delivery requeues repo", func(t *testing.T) {
+		queued, err := deliveryRepo.RequeueForDelivery(&models.WebhookDeliveryModel{DeliveryID: "delivery-1", Event: "push"}, repo.ID, []string{"a.go"})
+		if err != nil {
+			t.Fatalf("expected no error when requeueing for delivery but got %v", err)
+		}
+		if !queued {
+			t.Error("expected new delivery to requeue repo")
+		}
+
+		loaded, err := repoRepo.GetRepoByID(repo.ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting repo but got %v", err)
+		}
+		if loaded.State != "PENDING" || len(loaded.ChangedFiles) != 1 {
+			t.Errorf("expected repo to be pending with 1 changed file but was '%s' with %v", loaded.State, loaded.ChangedFiles)
+		}
+	})
+
+	t.Run("Duplicate delivery does not requeue repo", func(t *testing.T) {
+		queued, err := deliveryRepo.RequeueForDelivery(&models.WebhookDeliveryModel{DeliveryID: "delivery-1", Event: "push"}, repo.ID, []string{"b.go"})
+		if err != nil {
+			t.Fatalf("expected no error when requeueing for duplicate delivery but got %v", err)
+		}
+		if queued {
+			t.Error("expected duplicate delivery not to requeue repo")
+		}
+
+		loaded, err := repoRepo.GetRepoByID(repo.ID)
+		if err != nil {
+			t.Fatalf("expected no error when getting repo but got %v", err)
+		}
+		if len(loaded.ChangedFiles) != 1 {
+			t.Errorf("expected changed files not to change but were %v", loaded.ChangedFiles)
+		}
+	})
+
+	t.Run("Delivery is not recorded when requeueing fails", func(t *testing.T) {
+		if _, err := deliveryRepo.RequeueForDelivery(&models.WebhookDeliveryModel{DeliveryID: "delivery-2", Event: "push"}, 99, nil); err == nil {
+			t.Fatal("expected error when requeueing repo that does not exist")
+		}
+
+		// The redelivery is handled once the repo can be requeued
+		queued, err := deliveryRepo.RequeueForDelivery(&models.WebhookDeliveryModel{DeliveryID: "delivery-2", Event: "push"}, repo.ID, nil)
+		if err != nil {
+			t.Fatalf("expected no error when requeueing for redelivery but got %v", err)
+		}
+		if !queued {
+			t.Error("expected redelivery to requeue repo")
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package search
+
+import (
+	"context"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/models"
+)
+
+// crawlRetryDelay is how long to wait before retrying a crawl that failed for a reason other than the rate limit
+const crawlRetryDelay = 15 * time.Minute
+
+// Crawler runs saved searches on their schedules, saving the repos found so they are parsed
+type Crawler struct {
+	ghs *GithubSearch
+	log common.Logger
+	// reserve is the number of search requests left for users of the search UI
+	reserve int
+	now     func() time.Time
+}
+
+func NewCrawler(ghs *GithubSearch, log common.Logger, reserve int) *Crawler {
+	return &Crawler{
+		ghs:     ghs,
+		log:     log,
+		reserve: reserve,
+		now:     time.Now,
+	}
+}
+
+// RunDue runs all scheduled searches that are due. Crawls stop early when the search rate limit runs out,
+// and continue from where they stopped once it resets.
+func (c *Crawler) RunDue(ctx context.Context) {
+	due, err := c.ghs.searchRepo.GetDueSearchParams(c.now())
+	if err != nil {
+		c.log.Errorf("Error getting due searches: %v", err)
+		return
+	}
+	if len(due) == 0 {
+		return
+	}
+
+	c.log.Infof("Found %d scheduled searches due to run", len(due))
+	for i := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if !c.crawl(ctx, &due[i]) {
+			c.log.Infof("Search rate limit reached, delaying remaining crawls")
+			return
+		}
+	}
+}
+
+// crawl runs a single scheduled search. Returns false if there is no rate limit left for other crawls.
+func (c *Crawler) crawl(ctx context.Context, params *models.SearchParamsModel) bool {
+	schedule, err := ParseSchedule(params.Schedule)
+	if err != nil {
+		// Schedules are validated when saved, so this is only possible if the db was edited by hand
+		c.log.Errorf("Search '%s' has an invalid schedule, retrying in %v: %v", params.Name, crawlRetryDelay, err)
+		c.delay(params, c.now().Add(crawlRetryDelay))
+		return true
+	}
+
+	rate, err := c.ghs.SearchRateLimit(ctx)
+	if err != nil {
+		c.log.Errorf("Error checking search rate limit: %v", err)
+		return false
+	}
+
+	available := rate.Remaining - c.reserve
+	if available <= 0 {
+		c.log.Infof("Search rate limit too low to crawl '%s' (%d/%d), waiting until %v", params.Name, rate.Remaining, rate.Limit, rate.Reset.Time)
+		c.delay(params, rate.Reset.Time)
+		return false
+	}
+
+	// A completed crawl starts again from the first page, an interrupted crawl is resumed
+	if params.IsComplete() {
+		params.CurrentPage = params.FirstPage()
+		params.ReposFound = 0
+		params.Exhausted = false
+	}
+	params.AutoSave = true
+
+	c.log.Infof("Crawling '%s' from page %d (up to %d pages)", params.Name, params.CurrentPage, available)
+	_, err = c.ghs.RunSavedSearchPages(ctx, params, available)
+	if rle, ok := errors.AsRateLimitedError(err); ok {
+		c.log.Infof("Rate limited crawling '%s', retrying in %v", params.Name, rle.RetryAfter)
+		c.delay(params, c.now().Add(rle.RetryAfter))
+		return false
+	}
+	if err != nil {
+		// Progress is saved after each page, so the retry continues from the failed page
+		c.log.Errorf("Error crawling '%s', retrying in %v: %v", params.Name, crawlRetryDelay, err)
+		c.delay(params, c.now().Add(crawlRetryDelay))
+		return true
+	}
+
+	if !params.IsComplete() {
+		c.delay(params, rate.Reset.Time)
+		return false
+	}
+
+	now := c.now()
+	next := schedule.Next(now)
+	params.LastRunAt = &now
+	params.NextRunAt = &next
+	if err := c.ghs.saveSearchParams(params); err != nil {
+		c.log.Errorf("Error saving crawl '%s': %v", params.Name, err)
+	}
+	c.log.Infof("Crawl '%s' complete, found %d repos. Next run at %v", params.Name, params.ReposFound, next)
+	return true
+}
+
+// delay sets when a crawl should be tried again
+func (c *Crawler) delay(params *models.SearchParamsModel, until time.Time) {
+	params.NextRunAt = &until
+	if err := c.ghs.saveSearchParams(params); err != nil {
+		c.log.Errorf("Error saving crawl '%s': %v", params.Name, err)
+	}
+}
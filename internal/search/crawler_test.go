@@ -0,0 +1,158 @@
+package search
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/mock"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+// memorySearchParamRepo keeps saved searches in memory, so crawls can be tested without a db
+type memorySearchParamRepo struct {
+	repository.SearchParamRepository
+	saved map[string]models.SearchParamsModel
+}
+
+func (m *memorySearchParamRepo) SaveSearchParams(params *models.SearchParamsModel) error {
+	m.saved[params.Name] = *params
+	return nil
+}
+
+func (m *memorySearchParamRepo) GetDueSearchParams(now time.Time) ([]models.SearchParamsModel, error) {
+	due := []models.SearchParamsModel{}
+	for _, p := range m.saved {
+		if p.Schedule != "" && (p.NextRunAt == nil || !p.NextRunAt.After(now)) {
+			due = append(due, p)
+		}
+	}
+	return due, nil
+}
+
+type memoryRepoRepo struct {
+	repository.RepoRepository
+	created []*models.RepositoryModel
+}
+
+func (m *memoryRepoRepo) CreateRepos(repos []*models.RepositoryModel) error {
+	m.created = append(m.created, repos...)
+	return nil
+}
+
+func TestCrawlerRunDue(t *testing.T) {
+	now := time.Date(2024, 7, 10, 10, 0, 0, 0, time.UTC)
+	reset := now.Add(time.Minute)
+	remaining := 7
+	ownerID := 12
+	var requestedPages []int
+
+	client := &mock.MockGithubClient{
+		MockCheckRateLimit: func(ctx context.Context) (*github.RateLimits, error) {
+			return &github.RateLimits{
+				Search: &github.Rate{Limit: 30, Remaining: remaining, Reset: github.Timestamp{Time: reset}},
+			}, nil
+		},
+		MockSearchRepositories: func(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.Repository, *github.Response, error) {
+			requestedPages = append(requestedPages, opts.Page)
+			return []*github.Repository{
+				{
+					Name:     github.String("repo"),
+					Owner:    &github.User{Login: github.String("owner")},
+					PushedAt: &github.Timestamp{Time: now},
+				},
+			}, &github.Response{NextPage: opts.Page + 1}, nil
+		},
+	}
+
+	searchRepo := &memorySearchParamRepo{saved: map[string]models.SearchParamsModel{
+		"crawl": {
+			Name:           "crawl",
+			Query:          "goo.gl in:readme language:go stars:>100",
+			StartPage:      1,
+			PagesToProcess: 3,
+			Schedule:       "@daily",
+			OwnerUserID:    &ownerID,
+		},
+	}}
+	repoRepo := &memoryRepoRepo{}
+
+	ghs := &GithubSearch{
+		client:     client,
+		config:     &common.Config{},
+		log:        common.NewLogger(false, zapcore.DebugLevel),
+		searchRepo: searchRepo,
+		repoRepo:   repoRepo,
+	}
+	crawler := NewCrawler(ghs, ghs.log, 5)
+	crawler.now = func() time.Time { return now }
+
+	// Only 2 requests are available above the reserve, so the crawl stops until the rate limit resets
+	crawler.RunDue(context.Background())
+	saved := searchRepo.saved["crawl"]
+	assert.Equal(t, []int{1, 2}, requestedPages)
+	assert.Equal(t, 3, saved.CurrentPage)
+	assert.Len(t, repoRepo.created, 2)
+	assert.Equal(t, &ownerID, repoRepo.created[0].OwnerUserID, "repos belong to the owner of the search")
+	assert.Equal(t, models.VisibilityPublic, repoRepo.created[0].Visibility)
+	assert.Equal(t, reset, *saved.NextRunAt)
+	assert.Nil(t, saved.LastRunAt)
+
+	// Not due until the rate limit resets
+	crawler.RunDue(context.Background())
+	assert.Len(t, requestedPages, 2)
+
+	// Once reset, the crawl resumes from the page it stopped at, and is scheduled for its next run
+	now = reset
+	remaining = 30
+	crawler.RunDue(context.Background())
+	saved = searchRepo.saved["crawl"]
+	assert.Equal(t, []int{1, 2, 3}, requestedPages)
+	assert.True(t, saved.IsComplete())
+	assert.Equal(t, 3, saved.ReposFound)
+	assert.Equal(t, now, *saved.LastRunAt)
+	assert.Equal(t, time.Date(2024, 7, 11, 0, 0, 0, 0, time.UTC), *saved.NextRunAt)
+
+	// The next scheduled run starts again from the first page
+	now = *saved.NextRunAt
+	crawler.RunDue(context.Background())
+	assert.Equal(t, []int{1, 2, 3, 1, 2, 3}, requestedPages)
+	assert.Equal(t, 3, searchRepo.saved["crawl"].ReposFound)
+}
+
+func TestCrawlerWaitsForRateLimitReserve(t *testing.T) {
+	now := time.Date(2024, 7, 10, 10, 0, 0, 0, time.UTC)
+	reset := now.Add(30 * time.Second)
+
+	client := &mock.MockGithubClient{
+		MockCheckRateLimit: func(ctx context.Context) (*github.RateLimits, error) {
+			return &github.RateLimits{
+				Search: &github.Rate{Limit: 30, Remaining: 5, Reset: github.Timestamp{Time: reset}},
+			}, nil
+		},
+		MockSearchRepositories: func(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.Repository, *github.Response, error) {
+			t.Fatal("expected no searches when the rate limit is at the reserve")
+			return nil, nil, nil
+		},
+	}
+
+	searchRepo := &memorySearchParamRepo{saved: map[string]models.SearchParamsModel{
+		"crawl": {Name: "crawl", Query: "goo.gl", PagesToProcess: 1, Schedule: "@hourly"},
+	}}
+	ghs := &GithubSearch{
+		client:     client,
+		log:        common.NewLogger(false, zapcore.DebugLevel),
+		searchRepo: searchRepo,
+		repoRepo:   &memoryRepoRepo{},
+	}
+	crawler := NewCrawler(ghs, ghs.log, 5)
+	crawler.now = func() time.Time { return now }
+
+	crawler.RunDue(context.Background())
+	assert.Equal(t, reset, *searchRepo.saved["crawl"].NextRunAt)
+}
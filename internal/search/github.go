@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"runtime/debug"
-	"time"
 
 	"github.com/google/go-github/v39/github"
 	"github.com/jwtly10/googl-bye/internal/common"
@@ -21,9 +20,7 @@ type GithubSearch struct {
 	repoRepo   repository.RepoRepository
 }
 
-func NewGithubSearch(config *common.Config, log common.Logger, searchRepo repository.SearchParamRepository, repoRepo repository.RepoRepository) *GithubSearch {
-	ghClient := common.NewGitHubClient(config.GHToken, log)
-
+func NewGithubSearch(config *common.Config, ghClient common.GithubClientI, log common.Logger, searchRepo repository.SearchParamRepository, repoRepo repository.RepoRepository) *GithubSearch {
 	return &GithubSearch{
 		client:     ghClient,
 		config:     config,
@@ -33,6 +30,13 @@ func NewGithubSearch(config *common.Config, log common.Logger, searchRepo reposi
 	}
 }
 
+// WithClient returns a copy of the search that makes requests with another client, e.g. one with a user's token
+func (ghs *GithubSearch) WithClient(client common.GithubClientI) *GithubSearch {
+	withClient := *ghs
+	withClient.client = client
+	return &withClient
+}
+
 func (ghs *GithubSearch) FindUsers(ctx context.Context, userName string) ([]*github.User, error) {
 	users, _, err := ghs.client.SearchForUser(ctx, userName)
 	if err != nil {
@@ -53,49 +57,264 @@ func (ghs *GithubSearch) FindRepositories(ctx context.Context, params *models.Se
 		}
 	}()
 
-	// Log rate limit before continuing
-	res, err := ghs.client.CheckRateLimit(ctx)
-	if err != nil {
-		ghs.log.Errorf("Error checking rate limit: %v", err)
-		return nil, err
+	// Rate limits are handled by the client, which waits for short resets and returns a RateLimitedError otherwise
+	// Pages start at 1, so a StartPage of 0 still processes PagesToProcess pages
+	currentPage := params.FirstPage()
+	endPage := params.EndPage()
+
+	for currentPage < endPage {
+		params.Opts.Page = currentPage
+		repos, nextPage, err := ghs.searchPage(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		allRepos = append(allRepos, repos...)
+
+		if nextPage == 0 {
+			break // No more pages
+		}
+		currentPage = nextPage
 	}
-	ghs.log.Infof("Current search rate limits: %d/%d - Resets: %v", res.Search.Remaining, res.Search.Limit, res.Search.Reset)
-	ghs.log.Infof("Current core rate limits: %d/%d - Resets: %v", res.Core.Remaining, res.Core.Limit, res.Core.Reset)
 
-	currentPage := params.StartPage
-	endPage := params.StartPage + params.PagesToProcess
+	ghs.log.Infof("Found %d repos total", len(allRepos))
+	return allRepos, nil
+}
+
+// RunSavedSearch processes the remaining pages of a named search, starting from params.CurrentPage.
+// Progress is saved after every page, so a search that fails (e.g. when rate limited) can be resumed later.
+// Repos found are saved for parsing when params.AutoSave is set.
+func (ghs *GithubSearch) RunSavedSearch(ctx context.Context, params *models.SearchParamsModel) ([]models.RepositoryModel, error) {
+	return ghs.RunSavedSearchPages(ctx, params, 0)
+}
+
+// RunSavedSearchPages is RunSavedSearch, processing at most maxPages pages (0 for no limit)
+func (ghs *GithubSearch) RunSavedSearchPages(ctx context.Context, params *models.SearchParamsModel, maxPages int) (allRepos []models.RepositoryModel, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ghs.log.Errorf("Panic occurred in RunSavedSearch: %v", r)
+			err = errors.NewInternalError(fmt.Sprintf("panic occurred: %v", r))
+			debug.PrintStack()
+		}
+	}()
+
+	if params.CurrentPage < params.FirstPage() {
+		params.CurrentPage = params.FirstPage()
+	}
 
+	ghs.log.Infof("Running saved search '%s' from page %d to %d", params.Name, params.CurrentPage, params.EndPage()-1)
+
+	for pages := 0; !params.IsComplete() && (maxPages == 0 || pages < maxPages); pages++ {
+		if err := ctx.Err(); err != nil {
+			return allRepos, err
+		}
+
+		params.Opts.Page = params.CurrentPage
+		repos, nextPage, err := ghs.searchPage(ctx, params)
+		if err != nil {
+			return allRepos, err
+		}
+
+		if params.AutoSave && len(repos) > 0 {
+			if err := ghs.saveBatchedRepos(ghs.ownedBySearch(params, repos)); err != nil {
+				return allRepos, err
+			}
+		}
+		allRepos = append(allRepos, repos...)
+
+		params.ReposFound += len(repos)
+		if nextPage == 0 {
+			params.Exhausted = true
+		} else {
+			params.CurrentPage = nextPage
+		}
+
+		if err := ghs.saveSearchParams(params); err != nil {
+			return allRepos, err
+		}
+	}
+
+	ghs.log.Infof("Saved search '%s' found %d repos (%d total), complete: %v", params.Name, len(allRepos), params.ReposFound, params.IsComplete())
+	return allRepos, nil
+}
+
+// FindRepositoriesByCode runs a code search, returning the repos with matching files.
+// Each repo lists the files that matched as CandidateFiles, and is marked high priority for parsing.
+func (ghs *GithubSearch) FindRepositoriesByCode(ctx context.Context, params *models.SearchParamsModel) (allRepos []models.RepositoryModel, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ghs.log.Errorf("Panic occurred in FindRepositoriesByCode: %v", r)
+			err = errors.NewInternalError(fmt.Sprintf("panic occurred: %v", r))
+			debug.PrintStack()
+		}
+	}()
+
+	// Code search returns a result per file, so results are grouped by repo in the order they were first seen
+	repoIndex := make(map[string]int)
+	fileSeen := make(map[string]bool)
+
+	currentPage := params.FirstPage()
+	endPage := params.EndPage()
 	for currentPage < endPage {
 		params.Opts.Page = currentPage
-		ghRepos, resp, err := ghs.client.SearchRepositories(ctx, params.Query, &params.Opts)
+		results, resp, err := ghs.client.SearchCode(ctx, params.Query, &params.Opts)
 		if err != nil {
-			ghs.log.Errorf("Error searching repositories: %v", err)
+			ghs.log.Errorf("Error searching code: %v", err)
 			return nil, err
 		}
-		ghs.log.Infof("Found %d repos from API (Page %d)", len(ghRepos), currentPage)
+		ghs.log.Infof("Found %d code results from API (Page %d)", len(results), currentPage)
 
-		for _, repo := range ghRepos {
-			repoName := getStringOrEmpty(repo.Name)
+		for _, result := range results {
+			if result.Repository == nil {
+				continue
+			}
 
-			parsedRepo, parseErr := ghs.parseRepo(repo)
+			parsedRepo, parseErr := ghs.parseRepo(result.Repository)
 			if parseErr != nil {
-				ghs.log.Errorf("Error parsing repo %s: %v", repoName, parseErr)
+				ghs.log.Errorf("Error parsing repo %s: %v", getStringOrEmpty(result.Repository.Name), parseErr)
 				continue
 			}
 
-			allRepos = append(allRepos, parsedRepo)
+			key := parsedRepo.CacheKey()
+			i, ok := repoIndex[key]
+			if !ok {
+				parsedRepo.Priority = models.PriorityHigh
+				parsedRepo.CandidateFiles = []string{}
+				allRepos = append(allRepos, parsedRepo)
+				i = len(allRepos) - 1
+				repoIndex[key] = i
+			}
+
+			path := getStringOrEmpty(result.Path)
+			if path != "" && !fileSeen[key+"/"+path] {
+				fileSeen[key+"/"+path] = true
+				allRepos[i].CandidateFiles = append(allRepos[i].CandidateFiles, path)
+			}
 		}
 
-		if resp.NextPage == 0 {
+		next := nextPage(resp)
+		if next == 0 {
 			break // No more pages
 		}
-		currentPage = resp.NextPage
+		currentPage = next
 	}
 
-	ghs.log.Infof("Found %d repos total", len(allRepos))
+	ghs.log.Infof("Found %d repos total from code search", len(allRepos))
 	return allRepos, nil
 }
 
+// RepoListOptions filters the repos listed for a user or org.
+// Forks, archived and private repos are excluded unless included.
+type RepoListOptions struct {
+	Org bool
+	// Authenticated lists the repos of the client's user instead of the owner, which includes their private repos
+	Authenticated bool
+	Forks         bool
+	Archived      bool
+	Private       bool
+	// MaxSize excludes repos larger than this (KB). No limit when 0.
+	MaxSize int
+}
+
+// matches checks if a repo should be listed
+func (o RepoListOptions) matches(repo *github.Repository) bool {
+	if repo.GetFork() && !o.Forks {
+		return false
+	}
+	if repo.GetArchived() && !o.Archived {
+		return false
+	}
+	if repo.GetPrivate() && !o.Private {
+		return false
+	}
+	return o.MaxSize == 0 || repo.GetSize() <= o.MaxSize
+}
+
+// ListOwnerRepositories lists every repo of a user or org, calling fn with each repo matching the options as pages are loaded
+func (ghs *GithubSearch) ListOwnerRepositories(ctx context.Context, owner string, opts RepoListOptions, fn func(repo models.RepositoryModel) error) error {
+	listOpts := github.ListOptions{Page: 1, PerPage: 100}
+
+	var listed, matched int
+	for {
+		var repos []*github.Repository
+		var resp *github.Response
+		var err error
+		if opts.Org {
+			repos, resp, err = ghs.client.ListOrgRepositories(ctx, owner, &github.RepositoryListByOrgOptions{Type: "all", Sort: "updated", Direction: "desc", ListOptions: listOpts})
+		} else {
+			user := owner
+			if opts.Authenticated {
+				user = ""
+			}
+			repos, resp, err = ghs.client.ListUserRepositories(ctx, user, &github.RepositoryListOptions{Type: "owner", Sort: "updated", Direction: "desc", ListOptions: listOpts})
+		}
+		if err != nil {
+			ghs.log.Errorf("Error listing repos for %s: %v", owner, err)
+			return err
+		}
+		ghs.log.Debugf("Listed %d repos for %s (Page %d)", len(repos), owner, listOpts.Page)
+
+		for _, repo := range repos {
+			listed++
+			if !opts.matches(repo) {
+				continue
+			}
+
+			parsedRepo, err := ghs.parseRepo(repo)
+			if err != nil {
+				ghs.log.Errorf("Error parsing repo %s: %v", getStringOrEmpty(repo.Name), err)
+				continue
+			}
+
+			matched++
+			if err := fn(parsedRepo); err != nil {
+				return err
+			}
+		}
+
+		next := nextPage(resp)
+		if next == 0 {
+			break // No more pages
+		}
+		listOpts.Page = next
+	}
+
+	ghs.log.Infof("Listed %d repos for %s, %d matched", listed, owner, matched)
+	return nil
+}
+
+// searchPage fetches the page of results set in params.Opts, returning the next page or 0 if this was the last
+func (ghs *GithubSearch) searchPage(ctx context.Context, params *models.SearchParamsModel) ([]models.RepositoryModel, int, error) {
+	ghRepos, resp, err := ghs.client.SearchRepositories(ctx, params.Query, &params.Opts)
+	if err != nil {
+		ghs.log.Errorf("Error searching repositories: %v", err)
+		return nil, 0, err
+	}
+	ghs.log.Infof("Found %d repos from API (Page %d)", len(ghRepos), params.Opts.Page)
+
+	repos := make([]models.RepositoryModel, 0, len(ghRepos))
+	for _, repo := range ghRepos {
+		repoName := getStringOrEmpty(repo.Name)
+
+		parsedRepo, parseErr := ghs.parseRepo(repo)
+		if parseErr != nil {
+			ghs.log.Errorf("Error parsing repo %s: %v", repoName, parseErr)
+			continue
+		}
+
+		repos = append(repos, parsedRepo)
+	}
+
+	return repos, nextPage(resp), nil
+}
+
+// nextPage gets the next page of a listing, or 0 if this was the last page or the response is missing
+func nextPage(resp *github.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.NextPage
+}
+
 func (ghs *GithubSearch) parseRepo(repo *github.Repository) (models.RepositoryModel, error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -108,28 +327,76 @@ func (ghs *GithubSearch) parseRepo(repo *github.Repository) (models.RepositoryMo
 	return models.RepositoryModel{
 		Name:     repoName,
 		Author:   getOwnerName(repo.Owner),
+		Forge:    models.ForgeGitHub,
 		GhUrl:    fmt.Sprintf("https://github.com/%s/%s", getStringOrEmpty(repo.Owner.Login), repoName),
 		CloneUrl: fmt.Sprintf("https://github.com/%s/%s.git", getStringOrEmpty(repo.Owner.Login), repoName),
 		ApiUrl:   getStringOrEmpty(repo.URL),
 		Language: getStringOrEmpty(repo.Language),
 		Stars:    getIntOrZero(repo.StargazersCount),
 		Forks:    getIntOrZero(repo.ForksCount),
-		LastPush: getTimeOrZero(&repo.PushedAt.Time),
+		LastPush: repo.GetPushedAt().Time, // Not included in code search results
+		Private:  repo.GetPrivate(),
 	}, nil
 }
 
-func (ghs *GithubSearch) saveBatchedRepos(batch []models.RepositoryModel) error {
-	for _, repo := range batch {
-		err := ghs.repoRepo.CreateRepo(&repo)
-		if err != nil {
-			ghs.log.Errorf("[%s] Error saving repo: %v", fmt.Sprintf("%s/%s", repo.Author, repo.Name), err)
-			return err
+// ownedBySearch returns copies of the repos found by a saved search, owned by the search's owner.
+// Private repos are skipped for searches without an owner, as they could never be cloned or seen.
+func (ghs *GithubSearch) ownedBySearch(params *models.SearchParamsModel, repos []models.RepositoryModel) []models.RepositoryModel {
+	owned := make([]models.RepositoryModel, 0, len(repos))
+	for _, repo := range repos {
+		if !repo.SetOwner(params.OwnerUserID, params.TeamID) {
+			ghs.log.Debugf("[%s] private repo found by search '%s' without an owner. Ignoring.", repo.CacheKey(), params.Name)
+			continue
 		}
+		owned = append(owned, repo)
+	}
+	return owned
+}
+
+// saveBatchedRepos saves repos for parsing. Repos that are already saved are ignored.
+func (ghs *GithubSearch) saveBatchedRepos(batch []models.RepositoryModel) error {
+	repos := make([]*models.RepositoryModel, 0, len(batch))
+	for i := range batch {
+		repos = append(repos, &batch[i])
+	}
+
+	if err := ghs.repoRepo.CreateRepos(repos); err != nil {
+		ghs.log.Errorf("Error saving batch of %d repos: %v", len(batch), err)
+		return err
 	}
 
 	return nil
 }
 
+// SaveRepos saves repos for parsing. Repos that are already saved are ignored.
+func (ghs *GithubSearch) SaveRepos(repos []models.RepositoryModel) error {
+	return ghs.saveBatchedRepos(repos)
+}
+
+// SaveSearchParams saves a named search
+func (ghs *GithubSearch) SaveSearchParams(params *models.SearchParamsModel) error {
+	return ghs.saveSearchParams(params)
+}
+
+// GetSavedSearch returns the named search the viewer can see, or nil if there is no search with the name
+func (ghs *GithubSearch) GetSavedSearch(name string, viewerID int) (*models.SearchParamsModel, error) {
+	return ghs.searchRepo.GetSearchParamsByName(name, viewerID)
+}
+
+// SearchRateLimit returns the current rate limit of the GitHub search API
+func (ghs *GithubSearch) SearchRateLimit(ctx context.Context) (*github.Rate, error) {
+	res, err := ghs.client.CheckRateLimit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return res.Search, nil
+}
+
+// GetSavedSearches returns the saved searches the viewer can see
+func (ghs *GithubSearch) GetSavedSearches(viewerID int) ([]models.SearchParamsModel, error) {
+	return ghs.searchRepo.GetAllSearchParams(viewerID)
+}
+
 func (ghs *GithubSearch) saveSearchParams(params *models.SearchParamsModel) error {
 	ghs.log.Infof("Updating params for search '%s'", params.Name)
 	err := ghs.searchRepo.SaveSearchParams(params)
@@ -153,13 +420,6 @@ func getIntOrZero(i *int) int {
 	return 0
 }
 
-func getTimeOrZero(t *time.Time) time.Time {
-	if t != nil {
-		return *t
-	}
-	return time.Time{}
-}
-
 // Gets either the login name or the nicely formatted owners name
 // TODO: Removing the nice name for now, only returning the login
 func getOwnerName(owner *github.User) string {
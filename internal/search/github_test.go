@@ -2,6 +2,7 @@ package search
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -125,3 +126,297 @@ func TestFindRepositories(t *testing.T) {
 	assert.Equal(t, 901, repos[1].Forks)
 	assert.NotNil(t, repos[1].LastPush)
 }
+
+func TestFindRepositoriesHonoursPages(t *testing.T) {
+	var pages []int
+	mockClient := &mock.MockGithubClient{
+		MockSearchRepositories: func(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.Repository, *github.Response, error) {
+			pages = append(pages, opts.Page)
+			repos := []*github.Repository{
+				{Name: github.String(fmt.Sprintf("repo%d", opts.Page)), Owner: &github.User{Login: github.String("owner")}},
+			}
+			// There are more pages than the search asks for
+			if opts.Page == 3 {
+				return repos, nil, nil
+			}
+			return repos, &github.Response{NextPage: opts.Page + 1}, nil
+		},
+	}
+
+	gs := &GithubSearch{
+		client: mockClient,
+		config: &common.Config{},
+		log:    common.NewLogger(false, zapcore.DebugLevel),
+	}
+
+	repos, err := gs.FindRepositories(context.Background(), &models.SearchParamsModel{StartPage: 0, PagesToProcess: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, pages)
+	assert.Len(t, repos, 2)
+
+	// A missing response is the last page
+	pages = nil
+	repos, err = gs.FindRepositories(context.Background(), &models.SearchParamsModel{StartPage: 3, PagesToProcess: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3}, pages)
+	assert.Len(t, repos, 1)
+}
+
+func TestRunSavedSearchResumesFromCurrentPage(t *testing.T) {
+	failPage := 2
+	var requestedPages []int
+	mockClient := &mock.MockGithubClient{
+		MockSearchRepositories: func(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.Repository, *github.Response, error) {
+			requestedPages = append(requestedPages, opts.Page)
+			if opts.Page == failPage {
+				return nil, nil, assert.AnError
+			}
+			return []*github.Repository{
+					{
+						Name:     github.String(fmt.Sprintf("repo%d", opts.Page)),
+						Owner:    &github.User{Login: github.String("owner")},
+						PushedAt: &github.Timestamp{Time: time.Now()},
+					},
+				},
+				&github.Response{NextPage: opts.Page + 1},
+				nil
+		},
+	}
+
+	container, db, err := test.NewTestDatabaseWithContainer(test.TestDatabaseConfiguration{
+		RootRelativePath: "../../",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer container.Terminate(context.Background())
+	log := common.NewLogger(false, zapcore.DebugLevel)
+
+	searchRepo := repository.NewSearchParamRepository(db)
+	repoRepo := repository.NewRepoRepository(db)
+
+	gs := &GithubSearch{
+		client:     mockClient,
+		config:     &common.Config{},
+		log:        log,
+		searchRepo: searchRepo,
+		repoRepo:   repoRepo,
+	}
+
+	params := &models.SearchParamsModel{
+		Name:           "resumable",
+		Query:          "goo.gl",
+		StartPage:      1,
+		PagesToProcess: 3,
+		AutoSave:       true,
+	}
+
+	// The second page fails, so only the first page is processed
+	repos, err := gs.RunSavedSearch(context.Background(), params)
+	assert.Error(t, err)
+	assert.Len(t, repos, 1)
+
+	saved, err := searchRepo.GetSearchParamsByName("resumable", repository.NoViewer)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, saved.CurrentPage)
+	assert.Equal(t, 1, saved.ReposFound)
+	assert.False(t, saved.IsComplete())
+
+	// Resuming continues from the failed page
+	failPage = 0
+	repos, err = gs.RunSavedSearch(context.Background(), saved)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 2)
+	assert.Equal(t, []int{1, 2, 2, 3}, requestedPages)
+
+	saved, err = searchRepo.GetSearchParamsByName("resumable", repository.NoViewer)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, saved.CurrentPage)
+	assert.Equal(t, 3, saved.ReposFound)
+	assert.True(t, saved.IsComplete())
+
+	// Auto saved repos are queued for parsing
+	pending, err := repoRepo.GetPendingRepos()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 3)
+}
+
+func TestFindRepositoriesByCode(t *testing.T) {
+	repo1 := &github.Repository{
+		Name:  github.String("repo1"),
+		Owner: &github.User{Login: github.String("owner1")},
+		URL:   github.String("https://api.github.com/repos/owner1/repo1"),
+	}
+	repo2 := &github.Repository{
+		Name:  github.String("repo2"),
+		Owner: &github.User{Login: github.String("owner2")},
+		URL:   github.String("https://api.github.com/repos/owner2/repo2"),
+	}
+
+	var requestedPages []int
+	mockClient := &mock.MockGithubClient{
+		MockSearchCode: func(ctx context.Context, query string, opts *github.SearchOptions) ([]*github.CodeResult, *github.Response, error) {
+			requestedPages = append(requestedPages, opts.Page)
+			if opts.Page == 1 {
+				return []*github.CodeResult{
+					{Path: github.String("README.md"), Repository: repo1},
+					{Path: github.String("docs/links.md"), Repository: repo1},
+					{Path: github.String("main.go"), Repository: repo2},
+				}, &github.Response{NextPage: 2}, nil
+			}
+			return []*github.CodeResult{
+				// Files can be returned again on later pages
+				{Path: github.String("README.md"), Repository: repo1},
+				{Path: github.String("setup.py"), Repository: repo1},
+			}, &github.Response{NextPage: 0}, nil
+		},
+	}
+
+	gs := &GithubSearch{
+		client:   mockClient,
+		config:   &common.Config{},
+		log:      common.NewLogger(false, zapcore.DebugLevel),
+		repoRepo: &memoryRepoRepo{},
+	}
+
+	repos, err := gs.FindRepositoriesByCode(context.Background(), &models.SearchParamsModel{
+		Query:          `"goo.gl/" in:file`,
+		PagesToProcess: 5,
+	})
+	assert.NoError(t, err)
+
+	// Stops at the last page of results
+	assert.Equal(t, []int{1, 2}, requestedPages)
+
+	assert.Len(t, repos, 2)
+	assert.Equal(t, "owner1", repos[0].Author)
+	assert.Equal(t, "repo1", repos[0].Name)
+	assert.Equal(t, "https://github.com/owner1/repo1.git", repos[0].CloneUrl)
+	assert.Equal(t, models.PriorityHigh, repos[0].Priority)
+	assert.Equal(t, []string{"README.md", "docs/links.md", "setup.py"}, repos[0].CandidateFiles)
+
+	assert.Equal(t, "repo2", repos[1].Name)
+	assert.Equal(t, models.PriorityHigh, repos[1].Priority)
+	assert.Equal(t, []string{"main.go"}, repos[1].CandidateFiles)
+}
+
+func TestListOwnerRepositories(t *testing.T) {
+	var requestedPages []int
+	mockClient := &mock.MockGithubClient{
+		MockListOrgRepos: func(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error) {
+			assert.Equal(t, "googl-bye", org)
+			assert.Equal(t, 100, opts.PerPage)
+			requestedPages = append(requestedPages, opts.Page)
+			if opts.Page == 1 {
+				return []*github.Repository{
+					{Name: github.String("api"), Owner: &github.User{Login: github.String(org)}, Size: github.Int(100)},
+					{Name: github.String("fork"), Owner: &github.User{Login: github.String(org)}, Fork: github.Bool(true)},
+				}, &github.Response{NextPage: 2}, nil
+			}
+			return []*github.Repository{
+				{Name: github.String("old"), Owner: &github.User{Login: github.String(org)}, Archived: github.Bool(true)},
+				{Name: github.String("secret"), Owner: &github.User{Login: github.String(org)}, Private: github.Bool(true)},
+				{Name: github.String("huge"), Owner: &github.User{Login: github.String(org)}, Size: github.Int(60000)},
+			}, &github.Response{NextPage: 0}, nil
+		},
+	}
+
+	gs := &GithubSearch{
+		client: mockClient,
+		config: &common.Config{},
+		log:    common.NewLogger(false, zapcore.DebugLevel),
+	}
+
+	var names []string
+	err := gs.ListOwnerRepositories(context.Background(), "googl-bye", RepoListOptions{Org: true, Archived: true, MaxSize: 50000}, func(repo models.RepositoryModel) error {
+		names = append(names, repo.Name)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// Every page is listed, with forks, private and large repos filtered out
+	assert.Equal(t, []int{1, 2}, requestedPages)
+	assert.Equal(t, []string{"api", "old"}, names)
+}
+
+func TestListOwnerRepositoriesStopsOnError(t *testing.T) {
+	mockClient := &mock.MockGithubClient{
+		MockListUserRepos: func(ctx context.Context, user string, opts *github.RepositoryListOptions) ([]*github.Repository, *github.Response, error) {
+			return []*github.Repository{
+				{Name: github.String("one"), Owner: &github.User{Login: github.String(user)}},
+				{Name: github.String("two"), Owner: &github.User{Login: github.String(user)}},
+			}, &github.Response{NextPage: opts.Page + 1}, nil
+		},
+	}
+
+	gs := &GithubSearch{
+		client: mockClient,
+		config: &common.Config{},
+		log:    common.NewLogger(false, zapcore.DebugLevel),
+	}
+
+	var listed int
+	err := gs.ListOwnerRepositories(context.Background(), "jwtly10", RepoListOptions{}, func(repo models.RepositoryModel) error {
+		listed++
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 1, listed)
+}
+
+func TestListOwnerRepositoriesForAuthenticatedUser(t *testing.T) {
+	mockClient := &mock.MockGithubClient{
+		MockListUserRepos: func(ctx context.Context, user string, opts *github.RepositoryListOptions) ([]*github.Repository, *github.Response, error) {
+			// The authenticated user's repos are listed with an empty user
+			assert.Equal(t, "", user)
+			return []*github.Repository{
+				{Name: github.String("public"), Owner: &github.User{Login: github.String("jwtly10")}},
+				{Name: github.String("secret"), Owner: &github.User{Login: github.String("jwtly10")}, Private: github.Bool(true)},
+			}, &github.Response{NextPage: 0}, nil
+		},
+	}
+
+	gs := (&GithubSearch{
+		config: &common.Config{},
+		log:    common.NewLogger(false, zapcore.DebugLevel),
+	}).WithClient(mockClient)
+
+	var repos []models.RepositoryModel
+	err := gs.ListOwnerRepositories(context.Background(), "jwtly10", RepoListOptions{Authenticated: true, Private: true}, func(repo models.RepositoryModel) error {
+		repos = append(repos, repo)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, repos, 2)
+	assert.False(t, repos[0].Private)
+	assert.True(t, repos[1].Private)
+	assert.Equal(t, "https://github.com/jwtly10/secret.git", repos[1].CloneUrl)
+}
+
+func TestListOwnerRepositoriesWithoutResponse(t *testing.T) {
+	calls := 0
+	mockClient := &mock.MockGithubClient{
+		MockListUserRepos: func(ctx context.Context, user string, opts *github.RepositoryListOptions) ([]*github.Repository, *github.Response, error) {
+			calls++
+			return []*github.Repository{
+				{Name: github.String("one"), Owner: &github.User{Login: github.String(user)}},
+			}, nil, nil
+		},
+	}
+
+	gs := &GithubSearch{
+		client: mockClient,
+		config: &common.Config{},
+		log:    common.NewLogger(false, zapcore.DebugLevel),
+	}
+
+	var listed int
+	err := gs.ListOwnerRepositories(context.Background(), "jwtly10", RepoListOptions{}, func(repo models.RepositoryModel) error {
+		listed++
+		return nil
+	})
+	assert.NoError(t, err)
+	// A missing response is the last page
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, listed)
+}
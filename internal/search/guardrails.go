@@ -0,0 +1,125 @@
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+)
+
+// Guardrails limit the repos a GitHub search can find, so parsing isn't swamped with large or unpopular repos.
+// Queries without size/stars qualifiers get the defaults added. Queries with their own are checked against the limits.
+type Guardrails struct {
+	// MaxSizeKB is the default max repo size
+	MaxSizeKB int
+	// MaxSizeLimitKB is the largest max repo size a query can ask for
+	MaxSizeLimitKB int
+	// MinStars is the default, repos must have more than this many stars
+	MinStars int
+	// MinStarsLimit is the lowest number of stars a query can ask for
+	MinStarsLimit int
+}
+
+func NewGuardrails(config *common.Config) Guardrails {
+	return Guardrails{
+		MaxSizeKB:      config.SearchMaxSizeKB,
+		MaxSizeLimitKB: config.SearchMaxSizeLimitKB,
+		MinStars:       config.SearchMinStars,
+		MinStarsLimit:  config.SearchMinStarsLimit,
+	}
+}
+
+// Apply returns the effective query, adding default qualifiers and validating the query's own qualifiers
+func (g Guardrails) Apply(query string) (string, error) {
+	size, err := findRange(query, "size")
+	if err != nil {
+		return "", err
+	}
+	stars, err := findRange(query, "stars")
+	if err != nil {
+		return "", err
+	}
+
+	effective := strings.TrimSpace(query)
+
+	if size == nil {
+		effective += fmt.Sprintf(" size:<=%d", g.MaxSizeKB)
+	} else if size.max < 0 || size.max > g.MaxSizeLimitKB {
+		return "", fmt.Errorf("size qualifier must limit repos to at most %d KB", g.MaxSizeLimitKB)
+	}
+
+	if stars == nil {
+		effective += fmt.Sprintf(" stars:>%d", g.MinStars)
+	} else if stars.min < g.MinStarsLimit {
+		return "", fmt.Errorf("stars qualifier must require at least %d stars", g.MinStarsLimit)
+	}
+
+	return strings.TrimSpace(effective), nil
+}
+
+// qualifierRange is the inclusive range of a numeric qualifier. A bound of -1 is unbounded.
+type qualifierRange struct {
+	min int
+	max int
+}
+
+// findRange parses the range of a numeric qualifier (e.g. 'stars:>100', 'size:10..500'). Returns nil if the query doesn't have it.
+func findRange(query, qualifier string) (*qualifierRange, error) {
+	var found *qualifierRange
+	prefix := qualifier + ":"
+	for _, term := range strings.Fields(query) {
+		// A negated qualifier excludes a range, so it can't keep repos within the limits
+		if strings.HasPrefix(strings.ToLower(term), "-"+prefix) {
+			return nil, fmt.Errorf("query can't negate the %s qualifier", qualifier)
+		}
+		if !strings.HasPrefix(strings.ToLower(term), prefix) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("query can only have one %s qualifier", qualifier)
+		}
+
+		r, err := parseRange(term[len(prefix):])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s qualifier '%s'", qualifier, term)
+		}
+		found = r
+	}
+	return found, nil
+}
+
+func parseRange(value string) (*qualifierRange, error) {
+	r := &qualifierRange{min: 0, max: -1}
+
+	var err error
+	switch {
+	case strings.HasPrefix(value, ">="):
+		r.min, err = strconv.Atoi(value[2:])
+	case strings.HasPrefix(value, ">"):
+		r.min, err = strconv.Atoi(value[1:])
+		r.min++
+	case strings.HasPrefix(value, "<="):
+		r.max, err = strconv.Atoi(value[2:])
+	case strings.HasPrefix(value, "<"):
+		r.max, err = strconv.Atoi(value[1:])
+		r.max--
+	case strings.Contains(value, ".."):
+		from, to, _ := strings.Cut(value, "..")
+		if from != "*" {
+			if r.min, err = strconv.Atoi(from); err != nil {
+				return nil, err
+			}
+		}
+		if to != "*" {
+			r.max, err = strconv.Atoi(to)
+		}
+	default:
+		r.min, err = strconv.Atoi(value)
+		r.max = r.min
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,44 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuardrailsApply(t *testing.T) {
+	guardrails := Guardrails{MaxSizeKB: 50000, MaxSizeLimitKB: 100000, MinStars: 300, MinStarsLimit: 10}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		wantErr  bool
+	}{
+		{"Adds defaults", "language:go", "language:go size:<=50000 stars:>300", false},
+		{"Keeps size within limit", "language:go size:<=80000", "language:go size:<=80000 stars:>300", false},
+		{"Keeps size range", "size:10..2000 stars:>=50", "size:10..2000 stars:>=50", false},
+		{"Keeps stars above limit", "stars:>10", "stars:>10 size:<=50000", false},
+		{"Rejects size over limit", "size:<=200000", "", true},
+		{"Rejects unbounded size", "size:>10", "", true},
+		{"Rejects stars under limit", "stars:>5", "", true},
+		{"Rejects unbounded stars", "stars:<100", "", true},
+		{"Rejects duplicate qualifier", "stars:>100 stars:>200", "", true},
+		{"Rejects malformed qualifier", "size:<=big", "", true},
+		{"Rejects negated size", "size:<=1000 -size:<=10", "", true},
+		{"Rejects negated stars", "-stars:<10", "", true},
+		{"Rejects negated qualifier in any case", "-Stars:<10", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := guardrails.Apply(tt.query)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, query)
+		})
+	}
+}
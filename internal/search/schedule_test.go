@@ -0,0 +1,65 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	// Wednesday
+	from := time.Date(2024, 7, 10, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 7, 10, 10, 45, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2024, 7, 10, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 7, 10, 11, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, 7, 11, 10, 30, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, 7, 11, 2, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 7, 11, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2024, 7, 11, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2024, 7, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 7, 14, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 7, 14, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"15,45 8-9 * * *", time.Date(2024, 7, 11, 8, 15, 0, 0, time.UTC)},
+		// Day of month OR day of week when both are restricted
+		{"0 0 1 * 5", time.Date(2024, 7, 12, 0, 0, 0, 0, time.UTC)},
+		{"@every 6h", time.Date(2024, 7, 10, 16, 30, 15, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, s.Next(from))
+		})
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every 1m",
+		"@every soon",
+		"@yearly",
+	} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := ParseSchedule(spec)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+)
+
+type AdminService struct {
+	log    common.Logger
+	client *common.GithubClient
+}
+
+func NewAdminService(client *common.GithubClient, l common.Logger) *AdminService {
+	return &AdminService{
+		log:    l,
+		client: client,
+	}
+}
+
+// GetTokenUsage lists the requests made and last known rate limits of each github token
+func (as *AdminService) GetTokenUsage(r *http.Request) ([]common.TokenUsage, error) {
+	return as.client.TokenUsage(), nil
+}
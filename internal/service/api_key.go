@@ -0,0 +1,148 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/auth"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+)
+
+type ApiKeyService struct {
+	log     common.Logger
+	apiKeys repository.ApiKeyRepository
+}
+
+func NewApiKeyService(apiKeys repository.ApiKeyRepository, l common.Logger) *ApiKeyService {
+	return &ApiKeyService{
+		log:     l,
+		apiKeys: apiKeys,
+	}
+}
+
+type createApiKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// GetApiKeys lists the signed in user's API keys
+func (aks *ApiKeyService) GetApiKeys(r *http.Request) ([]models.ApiKeyModel, error) {
+	user, err := signedInUser(r)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := aks.apiKeys.GetApiKeysForUser(user.ID)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when getting api keys: %v", err.Error()))
+	}
+	return keys, nil
+}
+
+// CreateApiKey creates an API key for the signed in user. The key is only returned here, only its hash is stored.
+func (aks *ApiKeyService) CreateApiKey(r *http.Request) (*models.CreatedApiKey, error) {
+	user, err := signedInUser(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req createApiKeyRequest
+	if err := decodeStrict(r, &req); err != nil {
+		return nil, err
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, errors.NewBadRequestError("missing required field: name")
+	}
+	scopes, err := validateScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	// Keys can't create keys with more access than they have
+	if callerKey, ok := auth.ApiKeyFromContext(r.Context()); ok {
+		for _, scope := range scopes {
+			if !callerKey.HasScope(scope) {
+				return nil, errors.NewForbiddenError(fmt.Sprintf("api key is missing the '%s' scope, so can't create a key with it", scope))
+			}
+		}
+	}
+
+	key, prefix, err := auth.NewApiKey()
+	if err != nil {
+		aks.log.Errorf("Error creating api key: %v", err)
+		return nil, errors.NewInternalError("error creating api key")
+	}
+
+	apiKey := models.ApiKeyModel{
+		UserID:  user.ID,
+		Name:    req.Name,
+		Prefix:  prefix,
+		KeyHash: auth.HashToken(key),
+		Scopes:  scopes,
+	}
+	if err := aks.apiKeys.CreateApiKey(&apiKey); err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when creating api key: %v", err.Error()))
+	}
+
+	aks.log.Infof("User '%s' created api key '%s' with scopes %v", user.Login, apiKey.Name, apiKey.Scopes)
+	return &models.CreatedApiKey{ApiKeyModel: apiKey, Key: key}, nil
+}
+
+// RevokeApiKey revokes the signed in user's API key with the {id} path value
+func (aks *ApiKeyService) RevokeApiKey(r *http.Request) error {
+	user, err := signedInUser(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return errors.NewBadRequestError(fmt.Sprintf("invalid api key id: '%s'", r.PathValue("id")))
+	}
+
+	revoked, err := aks.apiKeys.RevokeApiKey(id, user.ID, time.Now())
+	if err != nil {
+		return errors.NewInternalError(fmt.Sprintf("error when revoking api key: %v", err.Error()))
+	}
+	if !revoked {
+		return errors.NewNotFoundError(fmt.Sprintf("api key with id %d not found", id))
+	}
+
+	aks.log.Infof("User '%s' revoked api key %d", user.Login, id)
+	return nil
+}
+
+// validateScopes checks API key scopes, removing duplicates
+func validateScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("missing required field: scopes, expected any of %v", models.ApiKeyScopes))
+	}
+
+	seen := make(map[string]bool)
+	valid := []string{}
+	for _, scope := range scopes {
+		if !contains(models.ApiKeyScopes, scope) {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("invalid scope '%s', expected any of %v", scope, models.ApiKeyScopes))
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			valid = append(valid, scope)
+		}
+	}
+	return valid, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
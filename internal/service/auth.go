@@ -0,0 +1,281 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/auth"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+)
+
+const (
+	SessionCookieName    = "googl_bye_session"
+	OAuthStateCookieName = "googl_bye_oauth_state"
+	// oauthStateMaxAge is how long users have to sign in on GitHub
+	oauthStateMaxAge = 10 * time.Minute
+	// apiKeyTouchInterval limits how often an API key's last use is written
+	apiKeyTouchInterval = time.Minute
+)
+
+type AuthService struct {
+	log        common.Logger
+	oauth      *auth.GithubOAuth
+	cipher     *auth.TokenCipher
+	users      repository.UserRepository
+	sessions   repository.SessionRepository
+	apiKeys    repository.ApiKeyRepository
+	sessionTTL time.Duration
+	// secureCookies is set when the app is served over https
+	secureCookies bool
+}
+
+// NewAuthService creates the service for signing in with GitHub. Signing in is disabled when oauth is nil.
+func NewAuthService(oauth *auth.GithubOAuth, cipher *auth.TokenCipher, users repository.UserRepository, sessions repository.SessionRepository, apiKeys repository.ApiKeyRepository, config *common.Config, l common.Logger) *AuthService {
+	return &AuthService{
+		log:           l,
+		oauth:         oauth,
+		cipher:        cipher,
+		users:         users,
+		sessions:      sessions,
+		apiKeys:       apiKeys,
+		sessionTTL:    time.Duration(config.SessionMaxAgeHours) * time.Hour,
+		secureCookies: strings.HasPrefix(config.GHOAuthRedirectURL, "https://"),
+	}
+}
+
+// Enabled checks if signing in is configured
+func (as *AuthService) Enabled() bool {
+	return as.oauth != nil
+}
+
+// StartLogin returns the GitHub URL to redirect to, and the cookie holding the state to check on the callback
+func (as *AuthService) StartLogin(r *http.Request) (string, *http.Cookie, error) {
+	if !as.Enabled() {
+		return "", nil, errors.NewNotFoundError("signing in with github is not configured")
+	}
+
+	state, err := auth.NewRandomToken()
+	if err != nil {
+		as.log.Errorf("Error creating oauth state: %v", err)
+		return "", nil, errors.NewInternalError("error starting sign in")
+	}
+
+	return as.oauth.AuthCodeURL(state), as.cookie(OAuthStateCookieName, state, oauthStateMaxAge), nil
+}
+
+// CompleteLogin handles the callback from GitHub, creating a session for the user.
+// Returns the cookies to set, the session cookie and the cleared state cookie.
+func (as *AuthService) CompleteLogin(r *http.Request) ([]*http.Cookie, error) {
+	if !as.Enabled() {
+		return nil, errors.NewNotFoundError("signing in with github is not configured")
+	}
+
+	if msg := r.URL.Query().Get("error_description"); msg != "" {
+		return nil, errors.NewUnauthorizedError(msg)
+	}
+
+	state, err := r.Cookie(OAuthStateCookieName)
+	if err != nil || state.Value == "" || state.Value != r.URL.Query().Get("state") {
+		return nil, errors.NewUnauthorizedError("invalid oauth state, please sign in again")
+	}
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		return nil, errors.NewBadRequestError("missing oauth code")
+	}
+
+	token, err := as.oauth.Exchange(r.Context(), code)
+	if err != nil {
+		as.log.Errorf("Error exchanging oauth code: %v", err)
+		return nil, errors.NewUnauthorizedError("github sign in failed")
+	}
+
+	ghUser, err := as.oauth.User(r.Context(), token)
+	if err != nil {
+		as.log.Errorf("Error getting github user: %v", err)
+		return nil, errors.NewInternalError("error getting github user")
+	}
+
+	encrypted, err := as.cipher.Encrypt(token.AccessToken)
+	if err != nil {
+		as.log.Errorf("Error encrypting github token: %v", err)
+		return nil, errors.NewInternalError("error saving user")
+	}
+
+	user := &models.UserModel{
+		GithubID:    ghUser.Id,
+		Login:       ghUser.Login,
+		Name:        ghUser.Name,
+		AvatarUrl:   ghUser.AvatarUrl,
+		AccessToken: encrypted,
+	}
+	if err := as.users.SaveUser(user); err != nil {
+		as.log.Errorf("Error saving user: %v", err)
+		return nil, errors.NewInternalError("error saving user")
+	}
+
+	sessionToken, err := auth.NewRandomToken()
+	if err != nil {
+		as.log.Errorf("Error creating session token: %v", err)
+		return nil, errors.NewInternalError("error creating session")
+	}
+
+	session := &models.SessionModel{
+		TokenHash: auth.HashToken(sessionToken),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(as.sessionTTL),
+	}
+	if err := as.sessions.CreateSession(session); err != nil {
+		as.log.Errorf("Error creating session: %v", err)
+		return nil, errors.NewInternalError("error creating session")
+	}
+
+	// Sign ins are rare enough that expired sessions can be cleaned up here
+	if deleted, err := as.sessions.DeleteExpiredSessions(time.Now()); err != nil {
+		as.log.Warnf("Error deleting expired sessions: %v", err)
+	} else if deleted > 0 {
+		as.log.Infof("Deleted %d expired sessions", deleted)
+	}
+
+	as.log.Infof("User '%s' signed in", user.Login)
+
+	return []*http.Cookie{
+		as.cookie(SessionCookieName, sessionToken, as.sessionTTL),
+		as.cookie(OAuthStateCookieName, "", -1),
+	}, nil
+}
+
+// Authenticate gets the user of the request's API key or session. The key is nil for requests with a session.
+func (as *AuthService) Authenticate(r *http.Request) (*models.UserModel, *models.ApiKeyModel, error) {
+	if token, ok := auth.BearerToken(r); ok {
+		return as.authenticateApiKey(token)
+	}
+
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, nil, errors.NewUnauthorizedError("not signed in")
+	}
+
+	session, err := as.sessions.GetSessionByTokenHash(auth.HashToken(cookie.Value), time.Now())
+	if err != nil {
+		as.log.Errorf("Error getting session: %v", err)
+		return nil, nil, errors.NewInternalError("error getting session")
+	}
+	if session == nil {
+		return nil, nil, errors.NewUnauthorizedError("session has expired, please sign in again")
+	}
+
+	user, err := as.user(session.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, nil, nil
+}
+
+// authenticateApiKey gets the user of an API key, and records that the key was used
+func (as *AuthService) authenticateApiKey(token string) (*models.UserModel, *models.ApiKeyModel, error) {
+	key, err := as.apiKeys.GetApiKeyByHash(auth.HashToken(token))
+	if err != nil {
+		as.log.Errorf("Error getting api key: %v", err)
+		return nil, nil, errors.NewInternalError("error getting api key")
+	}
+	if key == nil {
+		return nil, nil, errors.NewUnauthorizedError("invalid or revoked api key")
+	}
+
+	user, err := as.user(key.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := as.apiKeys.TouchApiKey(key.ID, now); err != nil {
+			as.log.Warnf("Error updating last use of api key %d: %v", key.ID, err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+
+	return user, key, nil
+}
+
+// user gets the user a session or API key belongs to
+func (as *AuthService) user(id int) (*models.UserModel, error) {
+	user, err := as.users.GetUserByID(id)
+	if err != nil {
+		as.log.Errorf("Error getting user %d: %v", id, err)
+		return nil, errors.NewInternalError("error getting user")
+	}
+	if user == nil {
+		return nil, errors.NewUnauthorizedError("user no longer exists")
+	}
+	return user, nil
+}
+
+// Logout deletes the request's session, returning the cookie that clears it
+func (as *AuthService) Logout(r *http.Request) (*http.Cookie, error) {
+	if cookie, err := r.Cookie(SessionCookieName); err == nil && cookie.Value != "" {
+		if err := as.sessions.DeleteSession(auth.HashToken(cookie.Value)); err != nil {
+			as.log.Errorf("Error deleting session: %v", err)
+			return nil, errors.NewInternalError("error signing out")
+		}
+	}
+	return as.cookie(SessionCookieName, "", -1), nil
+}
+
+// GetCurrentUser gets the signed in user, set by the auth middleware
+func (as *AuthService) GetCurrentUser(r *http.Request) (*models.UserModel, error) {
+	return signedInUser(r)
+}
+
+// UserToken decrypts the user's GitHub token
+func (as *AuthService) UserToken(user *models.UserModel) (string, error) {
+	if as.cipher == nil {
+		return "", errors.NewInternalError("signing in with github is not configured")
+	}
+	token, err := as.cipher.Decrypt(user.AccessToken)
+	if err != nil {
+		as.log.Errorf("Error decrypting token of user '%s': %v", user.Login, err)
+		return "", errors.NewInternalError("error getting github token")
+	}
+	return token, nil
+}
+
+// RepoToken gets the token of a private repo's owner, so the parser can clone it
+func (as *AuthService) RepoToken(repo *models.RepositoryModel) (string, error) {
+	if repo.OwnerUserID == nil {
+		return "", fmt.Errorf("private repo '%s' has no owner", repo.CacheKey())
+	}
+	user, err := as.users.GetUserByID(*repo.OwnerUserID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", fmt.Errorf("owner %d of private repo '%s' no longer exists", *repo.OwnerUserID, repo.CacheKey())
+	}
+	return as.UserToken(user)
+}
+
+// cookie creates a cookie for the API. A negative max age deletes the cookie.
+func (as *AuthService) cookie(name, value string, maxAge time.Duration) *http.Cookie {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   as.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if maxAge < 0 {
+		c.MaxAge = -1
+	} else {
+		c.MaxAge = int(maxAge.Seconds())
+	}
+	return c
+}
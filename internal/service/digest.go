@@ -0,0 +1,190 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	netmail "net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/jwtly10/googl-bye/internal/auth"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/digest"
+	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/mail"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+)
+
+// maxDigestAuthorLength is the length of the author column
+const maxDigestAuthorLength = 255
+
+type DigestService struct {
+	log           common.Logger
+	subscriptions repository.DigestSubscriptionRepository
+	sender        mail.Sender
+	appURL        string
+}
+
+// NewDigestService creates the service for digest subscriptions. Subscribing is disabled when sender is nil,
+// as the confirmation email can't be sent.
+func NewDigestService(subscriptions repository.DigestSubscriptionRepository, sender mail.Sender, appURL string, l common.Logger) *DigestService {
+	return &DigestService{
+		log:           l,
+		subscriptions: subscriptions,
+		sender:        sender,
+		appURL:        appURL,
+	}
+}
+
+type createSubscriptionRequest struct {
+	Email  string `json:"email"`
+	Author string `json:"author"`
+}
+
+// GetSubscriptions lists the signed in user's digest subscriptions
+func (ds *DigestService) GetSubscriptions(r *http.Request) ([]models.DigestSubscriptionModel, error) {
+	user, err := signedInUser(r)
+	if err != nil {
+		return nil, err
+	}
+
+	subs, err := ds.subscriptions.GetSubscriptionsForUser(user.ID)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when getting digest subscriptions: %v", err.Error()))
+	}
+	return subs, nil
+}
+
+// CreateSubscription subscribes an email to digests of the links found in an author's repos.
+// The email is sent a link to confirm the subscription, and digests are only sent once it is confirmed,
+// with the links found after that. Subscribing again returns the existing subscription.
+func (ds *DigestService) CreateSubscription(r *http.Request) (*models.DigestSubscriptionModel, error) {
+	user, err := signedInUser(r)
+	if err != nil {
+		return nil, err
+	}
+	if ds.sender == nil {
+		return nil, errors.NewNotFoundError("digest emails are not configured")
+	}
+
+	var req createSubscriptionRequest
+	if err := decodeStrict(r, &req); err != nil {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if addr, err := netmail.ParseAddress(email); err != nil || addr.Address != email {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("invalid email '%s'", req.Email))
+	}
+
+	author := strings.TrimSpace(req.Author)
+	if author == "" {
+		return nil, errors.NewBadRequestError("missing required field: author")
+	}
+	if len(author) > maxDigestAuthorLength {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("author must be at most %d characters", maxDigestAuthorLength))
+	}
+
+	token, err := auth.NewRandomToken()
+	if err != nil {
+		ds.log.Errorf("Error creating unsubscribe token: %v", err)
+		return nil, errors.NewInternalError("error creating digest subscription")
+	}
+	confirmToken, err := auth.NewRandomToken()
+	if err != nil {
+		ds.log.Errorf("Error creating confirm token: %v", err)
+		return nil, errors.NewInternalError("error creating digest subscription")
+	}
+
+	sub := models.DigestSubscriptionModel{
+		Email:            email,
+		Author:           author,
+		UserID:           user.ID,
+		UnsubscribeToken: token,
+		ConfirmToken:     confirmToken,
+	}
+	created, err := ds.subscriptions.CreateSubscription(&sub)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when creating digest subscription: %v", err.Error()))
+	}
+	if !created {
+		return &sub, nil
+	}
+
+	msg := mail.Message{
+		To:      []string{sub.Email},
+		Subject: fmt.Sprintf("Confirm your goo.gl link digest for %s's repos", sub.Author),
+		Body: fmt.Sprintf("%s asked to send this address a digest of the goo.gl links found in %s's repos.\n\n"+
+			"Confirm the subscription: %s\n\nIf you didn't expect this email, you can ignore it and won't be sent any digests.\n",
+			user.Login, sub.Author, digest.ConfirmURL(ds.appURL, sub.ConfirmToken)),
+	}
+	if err := ds.sender.Send(msg); err != nil {
+		// Forget the subscription, so subscribing again sends a new confirmation
+		if _, err := ds.subscriptions.DeleteSubscription(sub.ID, user.ID); err != nil {
+			ds.log.Errorf("Error deleting unconfirmed digest subscription %d: %v", sub.ID, err)
+		}
+		return nil, errors.NewInternalError(fmt.Sprintf("error when sending digest confirmation: %v", err.Error()))
+	}
+
+	ds.log.Infof("User '%s' subscribed to digests of '%s', waiting for confirmation", user.Login, sub.Author)
+	return &sub, nil
+}
+
+// ConfirmSubscription confirms the subscription with the 'token' form value, from the link in the confirmation email.
+// It doesn't need the recipient to be signed in.
+func (ds *DigestService) ConfirmSubscription(r *http.Request) error {
+	token := r.PostFormValue("token")
+	if token == "" {
+		return errors.NewBadRequestError("missing required field: token")
+	}
+
+	confirmed, err := ds.subscriptions.ConfirmSubscription(token)
+	if err != nil {
+		return errors.NewInternalError(fmt.Sprintf("error when confirming digest subscription: %v", err.Error()))
+	}
+	if !confirmed {
+		return errors.NewNotFoundError("digest subscription not found, it may already be confirmed")
+	}
+	return nil
+}
+
+// DeleteSubscription deletes the signed in user's digest subscription with the {id} path value
+func (ds *DigestService) DeleteSubscription(r *http.Request) error {
+	user, err := signedInUser(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return errors.NewBadRequestError(fmt.Sprintf("invalid digest subscription id: '%s'", r.PathValue("id")))
+	}
+
+	deleted, err := ds.subscriptions.DeleteSubscription(id, user.ID)
+	if err != nil {
+		return errors.NewInternalError(fmt.Sprintf("error when deleting digest subscription: %v", err.Error()))
+	}
+	if !deleted {
+		return errors.NewNotFoundError(fmt.Sprintf("digest subscription with id %d not found", id))
+	}
+	return nil
+}
+
+// Unsubscribe deletes the subscription with the 'token' form value, from the link at the end of every digest.
+// It doesn't need the recipient to be signed in.
+func (ds *DigestService) Unsubscribe(r *http.Request) error {
+	token := r.PostFormValue("token")
+	if token == "" {
+		return errors.NewBadRequestError("missing required field: token")
+	}
+
+	deleted, err := ds.subscriptions.DeleteSubscriptionByToken(token)
+	if err != nil {
+		return errors.NewInternalError(fmt.Sprintf("error when deleting digest subscription: %v", err.Error()))
+	}
+	if !deleted {
+		return errors.NewNotFoundError("digest subscription not found, it may already be unsubscribed")
+	}
+	return nil
+}
@@ -2,33 +2,140 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v39/github"
+	"github.com/jwtly10/googl-bye/internal/auth"
 	"github.com/jwtly10/googl-bye/internal/common"
 	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/forge"
 	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
 	"github.com/jwtly10/googl-bye/internal/search"
-	"github.com/jwtly10/googl-bye/internal/utils"
 )
 
+// UserTokens gets the GitHub tokens of signed in users
+type UserTokens interface {
+	UserToken(user *models.UserModel) (string, error)
+}
+
 type GithubService struct {
-	log common.Logger
-	ghs search.GithubSearch
+	log        common.Logger
+	ghs        search.GithubSearch
+	forges     *forge.Registry
+	cache      *common.RepoCache
+	guardrails search.Guardrails
+	userTokens UserTokens
+	teams      repository.TeamRepository
 }
 
-func NewGithubService(ghc search.GithubSearch, log common.Logger) *GithubService {
+func NewGithubService(ghc search.GithubSearch, forges *forge.Registry, cache *common.RepoCache, guardrails search.Guardrails, userTokens UserTokens, teams repository.TeamRepository, log common.Logger) *GithubService {
 	return &GithubService{
-		log: log,
-		ghs: ghc,
+		log:        log,
+		ghs:        ghc,
+		forges:     forges,
+		cache:      cache,
+		guardrails: guardrails,
+		userTokens: userTokens,
+		teams:      teams,
+	}
+}
+
+// codeSearchQuery finds files containing goo.gl links. Requests can narrow it with their own qualifiers.
+const codeSearchQuery = `"goo.gl/" in:file`
+
+type codeSearchRequest struct {
+	Query          string `json:"query"`
+	StartPage      int    `json:"startPage"`
+	PagesToProcess int    `json:"pagesToProcess"`
+}
+
+// GithubCodeSearch discovers repos containing goo.gl links using GitHub code search.
+// Repos not already in the cache are saved as high priority for parsing, with the files that matched, owned by the signed in user.
+func (gs *GithubService) GithubCodeSearch(r *http.Request) (*models.CodeSearchResult, error) {
+	var req codeSearchRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil && err != io.EOF {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("error decoding request body: %v", err.Error()))
+	}
+
+	if req.StartPage < 0 || req.PagesToProcess < 0 {
+		return nil, errors.NewBadRequestError("startPage and pagesToProcess must not be negative")
+	}
+	if req.PagesToProcess == 0 {
+		req.PagesToProcess = 1
+	}
+
+	// The query is used as qualifiers, unless it already searches for goo.gl itself
+	query := strings.TrimSpace(req.Query)
+	if !strings.Contains(query, "goo.gl") {
+		query = strings.TrimSpace(codeSearchQuery + " " + query)
+	}
+
+	searchParams := &models.SearchParamsModel{
+		Query: query,
+		Opts: github.SearchOptions{
+			ListOptions: github.ListOptions{
+				PerPage: 100,
+			},
+		},
+		StartPage:      req.StartPage,
+		PagesToProcess: req.PagesToProcess,
+	}
+
+	gs.log.Infof("Running code search for 'Query: %s', 'StartPage': %d, 'PagesToProcess': %d", searchParams.Query, searchParams.StartPage, searchParams.PagesToProcess)
+
+	found, err := gs.ghs.FindRepositoriesByCode(r.Context(), searchParams)
+	if err != nil {
+		return nil, searchError("error searching code", err)
+	}
+
+	var ownerID *int
+	if user, ok := auth.UserFromContext(r.Context()); ok {
+		ownerID = &user.ID
+	}
+
+	result := &models.CodeSearchResult{
+		Query: searchParams.Query,
+		Repos: []models.RepositoryModel{},
+	}
+	for _, repo := range found {
+		if !repo.SetOwner(ownerID, nil) {
+			gs.log.Debugf("[%s] private repo found by code search without a signed in user. Ignoring.", repo.CacheKey())
+			continue
+		}
+		if gs.cache.Saved(repo.CacheKey(), viewerID(r)) {
+			gs.log.Debugf("[%s] repo found in cache. Ignoring.", repo.CacheKey())
+			result.Skipped++
+			continue
+		}
+		result.Repos = append(result.Repos, repo)
+	}
+
+	if err := gs.ghs.SaveRepos(result.Repos); err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when batch saving repositories: %v", err.Error()))
+	}
+
+	// Update the cache once all rows saved
+	for i := range result.Repos {
+		gs.cache.SetRepo(&result.Repos[i])
 	}
+
+	gs.log.Infof("Code search found %d repos: %d saved (%d were cache hits)", len(found), len(result.Repos), result.Skipped)
+
+	return result, nil
 }
 
-func (gs *GithubService) GithubSearchRepos(r *http.Request) ([]models.RepositoryModel, error) {
+// GithubSearchRepos runs a search, returning the repos found and the query that was actually searched
+func (gs *GithubService) GithubSearchRepos(r *http.Request) (*models.SearchResult, error) {
 	searchParams, err := gs.validateBodyFromRequest(r)
 	if err != nil {
 		return nil, err
@@ -36,14 +143,28 @@ func (gs *GithubService) GithubSearchRepos(r *http.Request) ([]models.Repository
 
 	gs.log.Infof("Github search Params: %v", searchParams)
 
-	// Force max repo size of 50MB (TODO: Review)
-	if !strings.Contains(searchParams.Query, "size:<=50000") {
-		searchParams.Query = searchParams.Query + " size:<=50000"
+	repos, err := gs.searchRepos(r.Context(), searchParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SearchResult{Query: searchParams.Query, Repos: repos}, nil
+}
+
+func (gs *GithubService) searchRepos(ctx context.Context, searchParams *models.SearchParamsModel) ([]models.RepositoryModel, error) {
+	// Other forges don't support GitHub's search qualifiers, so the query is passed through as is
+	if searchParams.Forge != "" && searchParams.Forge != models.ForgeGitHub {
+		if searchParams.Name != "" {
+			return nil, errors.NewBadRequestError("saved searches are only supported for github")
+		}
+		return gs.forgeSearchRepos(ctx, searchParams)
 	}
-	// Force minimum stars > 300 (TODO: Review)
-	if !strings.Contains(searchParams.Query, "stars") {
-		searchParams.Query = searchParams.Query + " stars:>300"
+
+	query, err := gs.guardrails.Apply(searchParams.Query)
+	if err != nil {
+		return nil, errors.NewBadRequestError(err.Error())
 	}
+	searchParams.Query = query
 
 	githubOpts := &github.SearchOptions{
 		Sort:  searchParams.Opts.Sort,
@@ -55,14 +176,58 @@ func (gs *GithubService) GithubSearchRepos(r *http.Request) ([]models.Repository
 	}
 
 	searchParams.Opts = *githubOpts
-	// TODO: Remove Name from table
-	searchParams.Name = utils.GenerateShortID()
+
+	// Named searches are saved, so their progress can be resumed later
+	if searchParams.Name != "" {
+		if err := gs.setSearchOwnership(ctx, searchParams); err != nil {
+			return nil, err
+		}
+		return gs.startSavedSearch(ctx, searchParams)
+	}
 
 	gs.log.Infof("Running search for params 'Query: %s', 'Params: %v' 'StartPage': %d, 'CurrentPage': %d, 'PagesToProcess': %d", searchParams.Query, searchParams.Opts, searchParams.StartPage, searchParams.CurrentPage, searchParams.PagesToProcess)
 
-	res, err := gs.ghs.FindRepositories(r.Context(), searchParams)
+	res, err := gs.ghs.FindRepositories(ctx, searchParams)
+	if err != nil {
+		return nil, searchError("error finding repositories", err)
+	}
+
+	if len(res) == 0 {
+		return []models.RepositoryModel{}, nil
+	}
+
+	return res, nil
+}
+
+// startSavedSearch saves a named search, replacing any previous search of its owner with the same name, and runs it
+func (gs *GithubService) startSavedSearch(ctx context.Context, searchParams *models.SearchParamsModel) ([]models.RepositoryModel, error) {
+	searchParams.Forge = models.ForgeGitHub
+	searchParams.CurrentPage = searchParams.FirstPage()
+	searchParams.ReposFound = 0
+	searchParams.Exhausted = false
+	searchParams.LastRunAt = nil
+	searchParams.NextRunAt = nil
+
+	// Scheduled searches run now, then in the background from their next scheduled time
+	if searchParams.Schedule != "" {
+		schedule, err := search.ParseSchedule(searchParams.Schedule)
+		if err != nil {
+			return nil, errors.NewBadRequestError(err.Error())
+		}
+		now := time.Now()
+		next := schedule.Next(now)
+		searchParams.LastRunAt = &now
+		searchParams.NextRunAt = &next
+	}
+
+	// Save before running, so the search can be resumed even if the first page fails
+	if err := gs.ghs.SaveSearchParams(searchParams); err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error saving search '%s': %v", searchParams.Name, err.Error()))
+	}
+
+	res, err := gs.ghs.RunSavedSearch(ctx, searchParams)
 	if err != nil {
-		return nil, errors.NewInternalError(fmt.Sprintf("error finding repositories: %v", err.Error()))
+		return nil, searchError(fmt.Sprintf("error finding repositories, resume search '%s' from page %d", searchParams.Name, searchParams.CurrentPage), err)
 	}
 
 	if len(res) == 0 {
@@ -72,6 +237,220 @@ func (gs *GithubService) GithubSearchRepos(r *http.Request) ([]models.Repository
 	return res, nil
 }
 
+// GetSavedSearches lists the saved searches the caller can see and their progress
+func (gs *GithubService) GetSavedSearches(r *http.Request) ([]models.SearchParamsModel, error) {
+	searches, err := gs.ghs.GetSavedSearches(viewerID(r))
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error getting saved searches: %v", err.Error()))
+	}
+
+	return searches, nil
+}
+
+// GetSavedSearch gets the saved search with the {name} path value
+func (gs *GithubService) GetSavedSearch(r *http.Request) (*models.SearchParamsModel, error) {
+	return gs.getSavedSearch(r, r.PathValue("name"))
+}
+
+// ResumeSavedSearch continues the saved search with the {name} path value from its current page.
+// The optional 'pages' query param processes that many more pages once the original pages are done.
+func (gs *GithubService) ResumeSavedSearch(r *http.Request) (*models.SavedSearchResult, error) {
+	searchParams, err := gs.getManagedSearch(r, r.PathValue("name"))
+	if err != nil {
+		return nil, err
+	}
+
+	if pages := r.URL.Query().Get("pages"); pages != "" {
+		extraPages, err := strconv.Atoi(pages)
+		if err != nil || extraPages < 1 {
+			return nil, errors.NewBadRequestError("pages must be a positive number")
+		}
+		if searchParams.IsComplete() && !searchParams.Exhausted {
+			searchParams.PagesToProcess = searchParams.CurrentPage - searchParams.FirstPage() + extraPages
+		}
+	}
+
+	if searchParams.Exhausted {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("search '%s' has no more results", searchParams.Name))
+	}
+	if searchParams.IsComplete() {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("search '%s' has processed all of its pages, use 'pages' to process more", searchParams.Name))
+	}
+
+	gs.log.Infof("Resuming search '%s' from page %d", searchParams.Name, searchParams.CurrentPage)
+
+	res, err := gs.ghs.RunSavedSearch(r.Context(), searchParams)
+	if err != nil {
+		return nil, searchError(fmt.Sprintf("error finding repositories, resume search '%s' from page %d", searchParams.Name, searchParams.CurrentPage), err)
+	}
+
+	if res == nil {
+		res = []models.RepositoryModel{}
+	}
+	return &models.SavedSearchResult{Search: searchParams, Repos: res}, nil
+}
+
+type scheduleRequest struct {
+	Schedule string `json:"schedule"`
+}
+
+// SetSavedSearchSchedule sets the schedule the saved search with the {name} path value is crawled on.
+// An empty schedule stops the search being crawled.
+func (gs *GithubService) SetSavedSearchSchedule(r *http.Request) (*models.SearchParamsModel, error) {
+	searchParams, err := gs.getManagedSearch(r, r.PathValue("name"))
+	if err != nil {
+		return nil, err
+	}
+
+	var req scheduleRequest
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("error decoding request body: %v", err.Error()))
+	}
+
+	if searchParams.Forge != "" && searchParams.Forge != models.ForgeGitHub {
+		return nil, errors.NewBadRequestError("only github searches can be scheduled")
+	}
+
+	searchParams.Schedule = strings.TrimSpace(req.Schedule)
+	searchParams.NextRunAt = nil
+	if searchParams.Schedule != "" {
+		schedule, err := search.ParseSchedule(searchParams.Schedule)
+		if err != nil {
+			return nil, errors.NewBadRequestError(err.Error())
+		}
+		next := schedule.Next(time.Now())
+		searchParams.NextRunAt = &next
+	}
+
+	if err := gs.ghs.SaveSearchParams(searchParams); err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error saving search '%s': %v", searchParams.Name, err.Error()))
+	}
+
+	gs.log.Infof("Search '%s' schedule set to '%s'", searchParams.Name, searchParams.Schedule)
+	return searchParams, nil
+}
+
+// getSavedSearch gets the named search the caller can see
+func (gs *GithubService) getSavedSearch(r *http.Request, name string) (*models.SearchParamsModel, error) {
+	searchParams, err := gs.ghs.GetSavedSearch(name, viewerID(r))
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error getting search '%s': %v", name, err.Error()))
+	}
+	if searchParams == nil {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("search '%s' not found", name))
+	}
+
+	return searchParams, nil
+}
+
+// getManagedSearch is getSavedSearch, requiring the caller to be allowed to change the search.
+// Searches can be changed by their owner and members of their team. Searches without an owner can be changed by anyone.
+func (gs *GithubService) getManagedSearch(r *http.Request, name string) (*models.SearchParamsModel, error) {
+	searchParams, err := gs.getSavedSearch(r, name)
+	if err != nil {
+		return nil, err
+	}
+	if searchParams.OwnerUserID == nil {
+		return searchParams, nil
+	}
+
+	user, err := signedInUser(r)
+	if err != nil {
+		return nil, err
+	}
+	if *searchParams.OwnerUserID == user.ID {
+		return searchParams, nil
+	}
+	if searchParams.Visibility == models.VisibilityTeam && searchParams.TeamID != nil {
+		member, err := gs.teams.GetTeamMember(*searchParams.TeamID, user.ID)
+		if err != nil {
+			return nil, errors.NewInternalError(fmt.Sprintf("error when getting team member: %v", err.Error()))
+		}
+		if member != nil {
+			return searchParams, nil
+		}
+	}
+
+	return nil, errors.NewForbiddenError(fmt.Sprintf("only the owner of search '%s' can change it", name))
+}
+
+// setSearchOwnership sets who owns a named search being saved, and checks its visibility.
+// Searches are owned by the signed in user, and are private unless another visibility is given.
+// Only signed in users can save team or private searches, and team searches must be for one of their teams.
+func (gs *GithubService) setSearchOwnership(ctx context.Context, searchParams *models.SearchParamsModel) error {
+	searchParams.OwnerUserID = nil
+
+	user, signedIn := auth.UserFromContext(ctx)
+	if searchParams.Visibility == "" {
+		searchParams.Visibility = models.VisibilityPublic
+		if signedIn {
+			searchParams.Visibility = models.VisibilityPrivate
+		}
+	}
+
+	switch searchParams.Visibility {
+	case models.VisibilityPublic, models.VisibilityPrivate:
+	case models.VisibilityTeam:
+		if searchParams.TeamID == nil {
+			return errors.NewBadRequestError(fmt.Sprintf("missing teamId for team search '%s'", searchParams.Name))
+		}
+	default:
+		return errors.NewBadRequestError(fmt.Sprintf("invalid visibility '%s', expected '%s', '%s' or '%s'", searchParams.Visibility, models.VisibilityPublic, models.VisibilityTeam, models.VisibilityPrivate))
+	}
+
+	if !signedIn {
+		if searchParams.Visibility != models.VisibilityPublic || searchParams.TeamID != nil {
+			return errors.NewUnauthorizedError(fmt.Sprintf("sign in to save %s search '%s'", searchParams.Visibility, searchParams.Name))
+		}
+		return nil
+	}
+	searchParams.OwnerUserID = &user.ID
+
+	if searchParams.TeamID != nil {
+		member, err := gs.teams.GetTeamMember(*searchParams.TeamID, user.ID)
+		if err != nil {
+			return errors.NewInternalError(fmt.Sprintf("error when getting team member: %v", err.Error()))
+		}
+		if member == nil {
+			return errors.NewNotFoundError(fmt.Sprintf("team with id %d not found", *searchParams.TeamID))
+		}
+	}
+
+	return nil
+}
+
+// forgeSearchRepos runs a search against a non GitHub forge, processing the requested pages
+func (gs *GithubService) forgeSearchRepos(ctx context.Context, searchParams *models.SearchParamsModel) ([]models.RepositoryModel, error) {
+	f, err := gs.forges.Get(searchParams.Forge)
+	if err != nil {
+		return nil, errors.NewBadRequestError(err.Error())
+	}
+
+	gs.log.Infof("Running %s search for params 'Query: %s', 'StartPage': %d, 'PagesToProcess': %d", f.Name(), searchParams.Query, searchParams.StartPage, searchParams.PagesToProcess)
+
+	allRepos := []models.RepositoryModel{}
+	page := searchParams.StartPage
+	for i := 0; i < searchParams.PagesToProcess; i++ {
+		repos, nextPage, err := f.SearchRepositories(ctx, searchParams.Query, page, 50)
+		if err != nil {
+			return nil, searchError("error finding repositories", err)
+		}
+		allRepos = append(allRepos, repos...)
+
+		if nextPage == 0 {
+			break
+		}
+		page = nextPage
+	}
+
+	gs.log.Infof("Found %d repos total", len(allRepos))
+	return allRepos, nil
+}
+
+// GithubSearchReposForUser lists all of a user's repos, excluding forks and repos over the max size guardrail.
+// Signed in users searching for themselves also get their private repos.
 func (gs *GithubService) GithubSearchReposForUser(r *http.Request) ([]models.RepositoryModel, error) {
 	username := r.URL.Query().Get("username")
 	if username == "" {
@@ -80,32 +459,103 @@ func (gs *GithubService) GithubSearchReposForUser(r *http.Request) ([]models.Rep
 
 	gs.log.Infof("Github repo search for user : %v", username)
 
-	searchParams := &models.SearchParamsModel{
-		Query: fmt.Sprintf("user:%s size:<=50000", username), // Also limit to 50MB
-		Opts: github.SearchOptions{
-			Sort:  "updated",
-			Order: "desc",
-			ListOptions: github.ListOptions{
-				PerPage: 50,
-			},
-		},
-		StartPage:      0,
-		CurrentPage:    0,
-		PagesToProcess: 2, // At 50 per page, we only load at most 100 repos TODO: This breaks for users with more than 100 repos
+	ghs := &gs.ghs
+	opts := search.RepoListOptions{Archived: true, MaxSize: gs.guardrails.MaxSizeKB}
+	if user, ok := auth.UserFromContext(r.Context()); ok && strings.EqualFold(user.Login, username) {
+		token, err := gs.userTokens.UserToken(user)
+		if err != nil {
+			return nil, err
+		}
+		ghs = ghs.WithClient(common.NewGitHubClient(token, gs.log))
+		opts.Authenticated = true
+		opts.Private = true
 	}
 
-	gs.log.Infof("Running user repo search for params 'Query: %s', 'Params: %v' 'StartPage': %d, 'CurrentPage': %d, 'PagesToProcess': %d", searchParams.Query, searchParams.Opts, searchParams.StartPage, searchParams.CurrentPage, searchParams.PagesToProcess)
-
-	res, err := gs.ghs.FindRepositories(r.Context(), searchParams)
+	res := []models.RepositoryModel{}
+	err := ghs.ListOwnerRepositories(r.Context(), username, opts, func(repo models.RepositoryModel) error {
+		res = append(res, repo)
+		return nil
+	})
 	if err != nil {
-		return nil, errors.NewInternalError(fmt.Sprintf("error finding repositories: %v", err.Error()))
+		return nil, searchError("error finding repositories", err)
 	}
 
-	if len(res) == 0 {
-		return []models.RepositoryModel{}, nil
+	return res, nil
+}
+
+// OwnerRepoListing is a validated request to list a user's or org's repos
+type OwnerRepoListing struct {
+	Owner   string
+	Options search.RepoListOptions
+	// ghs lists with the signed in user's token, for private repos. The server's tokens are used when nil.
+	ghs *search.GithubSearch
+}
+
+// NewOwnerRepoListing validates a listing of the repos of the {owner} path value.
+// 'type' is 'user' (default) or 'org'. 'forks', 'archived' and 'private' include those repos when true.
+// Private repos are listed with the signed in user's own token, so only those they can see are listed.
+func (gs *GithubService) NewOwnerRepoListing(r *http.Request) (*OwnerRepoListing, error) {
+	listing := &OwnerRepoListing{Owner: r.PathValue("owner")}
+	if listing.Owner == "" {
+		return nil, errors.NewBadRequestError("missing required field: owner")
 	}
 
-	return res, nil
+	switch ownerType := r.URL.Query().Get("type"); ownerType {
+	case "", "user":
+	case "org":
+		listing.Options.Org = true
+	default:
+		return nil, errors.NewBadRequestError(fmt.Sprintf("invalid type '%s', expected 'user' or 'org'", ownerType))
+	}
+
+	for _, filter := range []struct {
+		param   string
+		include *bool
+	}{
+		{"forks", &listing.Options.Forks},
+		{"archived", &listing.Options.Archived},
+		{"private", &listing.Options.Private},
+	} {
+		value := r.URL.Query().Get(filter.param)
+		if value == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("%s must be true or false", filter.param))
+		}
+		*filter.include = b
+	}
+
+	if listing.Options.Private {
+		user, err := signedInUser(r)
+		if err != nil {
+			return nil, errors.NewUnauthorizedError("sign in to list private repos")
+		}
+		token, err := gs.userTokens.UserToken(user)
+		if err != nil {
+			return nil, err
+		}
+		listing.ghs = gs.ghs.WithClient(common.NewGitHubClient(token, gs.log))
+		// Users' private repos are only listed for the user themselves
+		listing.Options.Authenticated = !listing.Options.Org && strings.EqualFold(user.Login, listing.Owner)
+	}
+
+	return listing, nil
+}
+
+// StreamOwnerRepos lists the repos of the owner, calling fn with each repo as pages are loaded
+func (gs *GithubService) StreamOwnerRepos(ctx context.Context, l *OwnerRepoListing, fn func(repo models.RepositoryModel) error) error {
+	gs.log.Infof("Listing repos for %s with options: %+v", l.Owner, l.Options)
+
+	ghs := &gs.ghs
+	if l.ghs != nil {
+		ghs = l.ghs
+	}
+	if err := ghs.ListOwnerRepositories(ctx, l.Owner, l.Options, fn); err != nil {
+		return searchError(fmt.Sprintf("error listing repositories for %s", l.Owner), err)
+	}
+	return nil
 }
 
 func (gs *GithubService) GithubSearchUsers(r *http.Request) ([]models.GithubUser, error) {
@@ -119,7 +569,7 @@ func (gs *GithubService) GithubSearchUsers(r *http.Request) ([]models.GithubUser
 
 	res, err := gs.ghs.FindUsers(r.Context(), username)
 	if err != nil {
-		return nil, errors.NewInternalError(fmt.Sprintf("error finding user: %v", err.Error()))
+		return nil, searchError("error finding user", err)
 	}
 
 	var users []models.GithubUser
@@ -167,10 +617,6 @@ func (gs *GithubService) validateBodyFromRequest(r *http.Request) (*models.Searc
 
 	// Check if all required fields are present
 	missingFields := []string{}
-	// See above TODO: Remove name from this model
-	// if searchParams.Name == "" {
-	// 	missingFields = append(missingFields, "name")
-	// }
 	if searchParams.Query == "" {
 		missingFields = append(missingFields, "query")
 	}
@@ -194,3 +640,12 @@ func (gs *GithubService) validateBodyFromRequest(r *http.Request) (*models.Searc
 
 	return &searchParams, nil
 }
+
+// searchError wraps errors from searches as internal errors.
+// Rate limit errors are returned as is, so they are handled as 429s.
+func searchError(msg string, err error) error {
+	if rle, ok := errors.AsRateLimitedError(err); ok {
+		return rle
+	}
+	return errors.NewInternalError(fmt.Sprintf("%s: %v", msg, err.Error()))
+}
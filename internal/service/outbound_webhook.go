@@ -0,0 +1,191 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jwtly10/googl-bye/internal/auth"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/webhook"
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+type OutboundWebhookService struct {
+	log      common.Logger
+	webhooks repository.OutboundWebhookRepository
+}
+
+func NewOutboundWebhookService(webhooks repository.OutboundWebhookRepository, l common.Logger) *OutboundWebhookService {
+	return &OutboundWebhookService{
+		log:      l,
+		webhooks: webhooks,
+	}
+}
+
+type createWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	Format string   `json:"format"`
+}
+
+// GetWebhooks lists the signed in user's webhooks
+func (ows *OutboundWebhookService) GetWebhooks(r *http.Request) ([]models.OutboundWebhookModel, error) {
+	user, err := signedInUser(r)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks, err := ows.webhooks.GetWebhooksForUser(user.ID)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when getting webhooks: %v", err.Error()))
+	}
+	return hooks, nil
+}
+
+// CreateWebhook creates a webhook for the signed in user. A secret is generated if one isn't given.
+// The secret is only returned here.
+func (ows *OutboundWebhookService) CreateWebhook(r *http.Request) (*models.CreatedOutboundWebhook, error) {
+	user, err := signedInUser(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req createWebhookRequest
+	if err := decodeStrict(r, &req); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("invalid url '%s', expected an http or https url", req.URL))
+	}
+	// Webhooks can't be used to reach the server's own network
+	if err := webhook.CheckURL(r.Context(), u); err != nil {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("invalid url '%s', %v", req.URL, err))
+	}
+
+	events, err := validateWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Format {
+	case "":
+		req.Format = models.WebhookFormatJSON
+	case models.WebhookFormatJSON, models.WebhookFormatSlack:
+	default:
+		return nil, errors.NewBadRequestError(fmt.Sprintf("invalid format '%s', expected '%s' or '%s'", req.Format, models.WebhookFormatJSON, models.WebhookFormatSlack))
+	}
+
+	if req.Secret == "" {
+		req.Secret, err = auth.NewRandomToken()
+		if err != nil {
+			ows.log.Errorf("Error creating webhook secret: %v", err)
+			return nil, errors.NewInternalError("error creating webhook")
+		}
+	}
+
+	hook := models.OutboundWebhookModel{
+		UserID: user.ID,
+		URL:    u.String(),
+		Secret: req.Secret,
+		Events: events,
+		Format: req.Format,
+		Active: true,
+	}
+	if err := ows.webhooks.CreateWebhook(&hook); err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when creating webhook: %v", err.Error()))
+	}
+
+	ows.log.Infof("User '%s' created webhook %d for %v", user.Login, hook.ID, hook.Events)
+	return &models.CreatedOutboundWebhook{OutboundWebhookModel: hook, Secret: hook.Secret}, nil
+}
+
+// DeleteWebhook deletes the signed in user's webhook with the {id} path value
+func (ows *OutboundWebhookService) DeleteWebhook(r *http.Request) error {
+	user, err := signedInUser(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return errors.NewBadRequestError(fmt.Sprintf("invalid webhook id: '%s'", r.PathValue("id")))
+	}
+
+	deleted, err := ows.webhooks.DeleteWebhook(id, user.ID)
+	if err != nil {
+		return errors.NewInternalError(fmt.Sprintf("error when deleting webhook: %v", err.Error()))
+	}
+	if !deleted {
+		return errors.NewNotFoundError(fmt.Sprintf("webhook with id %d not found", id))
+	}
+	return nil
+}
+
+// GetDeliveries gets the latest delivery attempts of the signed in user's webhook with the {id} path value.
+// The optional 'limit' query param defaults to 50.
+func (ows *OutboundWebhookService) GetDeliveries(r *http.Request) ([]models.OutboundDeliveryModel, error) {
+	user, err := signedInUser(r)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("invalid webhook id: '%s'", r.PathValue("id")))
+	}
+
+	limit := defaultDeliveriesLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 1 || limit > maxDeliveriesLimit {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("invalid limit '%s', expected 1 to %d", l, maxDeliveriesLimit))
+		}
+	}
+
+	hook, err := ows.webhooks.GetWebhookForUser(id, user.ID)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when getting webhook: %v", err.Error()))
+	}
+	if hook == nil {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("webhook with id %d not found", id))
+	}
+
+	deliveries, err := ows.webhooks.GetDeliveries(hook.ID, limit)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when getting webhook deliveries: %v", err.Error()))
+	}
+	return deliveries, nil
+}
+
+// validateWebhookEvents checks webhook events, removing duplicates
+func validateWebhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("missing required field: events, expected any of %v", models.WebhookEvents))
+	}
+
+	seen := make(map[string]bool)
+	valid := []string{}
+	for _, event := range events {
+		if !contains(models.WebhookEvents, event) {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("invalid event '%s', expected any of %v", event, models.WebhookEvents))
+		}
+		if !seen[event] {
+			seen[event] = true
+			valid = append(valid, event)
+		}
+	}
+	return valid, nil
+}
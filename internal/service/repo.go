@@ -6,25 +6,37 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/jwtly10/googl-bye/internal/auth"
 	"github.com/jwtly10/googl-bye/internal/common"
 	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/export"
+	"github.com/jwtly10/googl-bye/internal/forge"
 	"github.com/jwtly10/googl-bye/internal/models"
 	"github.com/jwtly10/googl-bye/internal/repository"
 )
 
 type RepoService struct {
-	log   common.Logger
-	r     repository.RepoRepository
-	cache *common.RepoCache
+	log        common.Logger
+	r          repository.RepoRepository
+	links      *repository.RepoLinkRepository
+	teams      repository.TeamRepository
+	forges     *forge.Registry
+	userTokens UserTokens
+	cache      *common.RepoCache
 }
 
-func NewRepoService(r repository.RepoRepository, l common.Logger, c *common.RepoCache) *RepoService {
+func NewRepoService(r repository.RepoRepository, links *repository.RepoLinkRepository, teams repository.TeamRepository, forges *forge.Registry, userTokens UserTokens, l common.Logger, c *common.RepoCache) *RepoService {
 	return &RepoService{
-		r:     r,
-		log:   l,
-		cache: c,
+		r:          r,
+		links:      links,
+		teams:      teams,
+		forges:     forges,
+		userTokens: userTokens,
+		log:        l,
+		cache:      c,
 	}
 }
 
@@ -41,14 +53,23 @@ func (rs *RepoService) SaveRepo(r *http.Request) error {
 
 	// For each repo. Check if cache exists for it
 	// This means that the rows are already in the DB and we can just simulate that it 'saved'.
+	// Only repos the caller can see are cached for them, so this doesn't leak which private repos are saved.
 	for _, repo := range reposFromReq {
-		key := fmt.Sprintf("%s/%s", repo.Author, repo.Name)
-		if _, ok := rs.cache.Get(key); ok {
+		key := repo.CacheKey()
+		if rs.cache.Saved(key, viewerID(r)) {
 			rs.log.Debugf("[%s] repo found in cache. Ignoring.", key)
 			cacheHits++
-		} else {
-			reposToSave = append(reposToSave, repo)
+			continue
 		}
+
+		// Only repos that need saving are looked up on the forge
+		if err := rs.setFromForge(r, repo); err != nil {
+			return err
+		}
+		if err := rs.setOwnership(r, repo); err != nil {
+			return err
+		}
+		reposToSave = append(reposToSave, repo)
 	}
 
 	err = rs.r.CreateRepos(reposToSave)
@@ -58,8 +79,7 @@ func (rs *RepoService) SaveRepo(r *http.Request) error {
 
 	// Update the cache once all rows saved
 	for _, savedRepo := range reposToSave {
-		key := fmt.Sprintf("%s/%s", savedRepo.Author, savedRepo.Name)
-		rs.cache.Set(key, true)
+		rs.cache.SetRepo(savedRepo)
 	}
 
 	rs.log.Infof("%d repos in save request: %d saved (%d were cache hits)", len(reposFromReq), len(reposFromReq)-cacheHits, cacheHits)
@@ -67,6 +87,165 @@ func (rs *RepoService) SaveRepo(r *http.Request) error {
 	return nil
 }
 
+// CreateRepoIssue raises an issue against the repo with the {id} path value, listing the links found in it.
+// Only the repo's owner, or an owner of its team, can raise issues. They are raised with the user's own token, so
+// only GitHub is supported. Each repo only gets one issue, later requests return it. Returns true if the issue was raised.
+func (rs *RepoService) CreateRepoIssue(r *http.Request) (*models.CreatedIssue, bool, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, false, errors.NewBadRequestError(fmt.Sprintf("invalid repo id: '%s'", r.PathValue("id")))
+	}
+	user, err := signedInUser(r)
+	if err != nil {
+		return nil, false, errors.NewUnauthorizedError("sign in to raise issues")
+	}
+
+	repo, err := rs.links.GetRepositoryWithLinksByID(id, viewerID(r))
+	if err == repository.ErrRepoNotFound {
+		return nil, false, errors.NewNotFoundError(fmt.Sprintf("repo with id %d not found", id))
+	}
+	if err != nil {
+		return nil, false, errors.NewInternalError(fmt.Sprintf("error when getting links for repo %d: %v", id, err.Error()))
+	}
+
+	existing, err := rs.r.GetRepoIssueUrl(id)
+	if err != nil {
+		return nil, false, errors.NewInternalError(fmt.Sprintf("error when getting issue of repo %d: %v", id, err.Error()))
+	}
+	if existing != "" {
+		return &models.CreatedIssue{Url: existing}, false, nil
+	}
+
+	if err := rs.checkRepoOwner(id, user.ID); err != nil {
+		return nil, false, err
+	}
+	if len(repo.Links) == 0 {
+		return nil, false, errors.NewBadRequestError(fmt.Sprintf("repo with id %d has no links to raise an issue for", id))
+	}
+	// Other forges would be called with the server's token, raising the issue as the server's account
+	if repo.Forge != models.ForgeGitHub {
+		return nil, false, errors.NewBadRequestError(fmt.Sprintf("issues can only be raised on %s, where they are raised with your own token", models.ForgeGitHub))
+	}
+
+	f, err := rs.forgeFor(r, repo.Forge)
+	if err != nil {
+		return nil, false, err
+	}
+
+	url, err := f.CreateIssue(r.Context(), repo.Author, repo.Name, export.NewRepoIssue(repo))
+	if err != nil {
+		return nil, false, searchError(fmt.Sprintf("error when raising issue against repo %d", id), err)
+	}
+	if err := rs.r.SaveRepoIssue(id, user.ID, url); err != nil {
+		return nil, false, errors.NewInternalError(fmt.Sprintf("error when saving issue %s of repo %d: %v", url, id, err.Error()))
+	}
+
+	rs.log.Infof("User %d raised issue against repo %d: %s", user.ID, id, url)
+	return &models.CreatedIssue{Url: url}, true, nil
+}
+
+// checkRepoOwner checks that a user owns a repo, or is an owner of the team it was saved for
+func (rs *RepoService) checkRepoOwner(id, userID int) error {
+	repo, err := rs.r.GetRepoByID(id)
+	if err != nil {
+		return errors.NewInternalError(fmt.Sprintf("error when getting repo %d: %v", id, err.Error()))
+	}
+	if repo.OwnerUserID != nil && *repo.OwnerUserID == userID {
+		return nil
+	}
+	if repo.TeamID != nil {
+		member, err := rs.teams.GetTeamMember(*repo.TeamID, userID)
+		if err != nil {
+			return errors.NewInternalError(fmt.Sprintf("error when getting team of repo %d: %v", id, err.Error()))
+		}
+		if member != nil && member.Role == models.TeamRoleOwner {
+			return nil
+		}
+	}
+	return errors.NewForbiddenError("only the repo's owner can raise issues against it")
+}
+
+// forgeFor gets the forge to call for the request. GitHub is called with the signed in user's own token,
+// so private repos they can see are found, and issues are raised as them.
+func (rs *RepoService) forgeFor(r *http.Request, name string) (forge.Forge, error) {
+	f, err := rs.forges.Get(name)
+	if err != nil {
+		return nil, errors.NewBadRequestError(err.Error())
+	}
+	if user, ok := auth.UserFromContext(r.Context()); ok && f.Name() == models.ForgeGitHub {
+		token, err := rs.userTokens.UserToken(user)
+		if err != nil {
+			return nil, err
+		}
+		f = forge.NewGithubForge(common.NewGitHubClient(token, rs.log))
+	}
+	return f, nil
+}
+
+// setFromForge sets if a repo being saved is private, and its urls, from the forge, as the request can't be trusted with them.
+// The clone url is where the owner's token is sent when parsing private repos, so the client's is always ignored.
+func (rs *RepoService) setFromForge(r *http.Request, repo *models.RepositoryModel) error {
+	f, err := rs.forgeFor(r, repo.ForgeName())
+	if err != nil {
+		return err
+	}
+
+	found, err := f.GetRepository(r.Context(), repo.Author, repo.Name)
+	if err != nil {
+		return searchError(fmt.Sprintf("error when getting repository '%s'", repo.CacheKey()), err)
+	}
+	if found == nil {
+		return errors.NewNotFoundError(fmt.Sprintf("repo '%s' not found on %s", repo.CacheKey(), f.Name()))
+	}
+	repo.Private = found.Private
+	repo.CloneUrl = f.CloneURL(found.Author, found.Name)
+	repo.GhUrl = found.GhUrl
+	repo.ApiUrl = found.ApiUrl
+	return nil
+}
+
+// setOwnership sets who owns a repo being saved, and checks its visibility.
+// Repos are owned by the signed in user. Only signed in users can save team or private repos, and team repos must be for one of their teams.
+func (rs *RepoService) setOwnership(r *http.Request, repo *models.RepositoryModel) error {
+	repo.OwnerUserID = nil
+	repo.Visibility = repo.VisibilityOrDefault()
+
+	switch repo.Visibility {
+	case models.VisibilityPublic:
+		if repo.Private {
+			return errors.NewBadRequestError(fmt.Sprintf("private repo '%s' can't be public", repo.CacheKey()))
+		}
+	case models.VisibilityTeam:
+		if repo.TeamID == nil {
+			return errors.NewBadRequestError(fmt.Sprintf("missing teamId for team repo '%s'", repo.CacheKey()))
+		}
+	case models.VisibilityPrivate:
+	default:
+		return errors.NewBadRequestError(fmt.Sprintf("invalid visibility '%s', expected '%s', '%s' or '%s'", repo.Visibility, models.VisibilityPublic, models.VisibilityTeam, models.VisibilityPrivate))
+	}
+
+	user, signedIn := auth.UserFromContext(r.Context())
+	if !signedIn {
+		if repo.Visibility != models.VisibilityPublic || repo.TeamID != nil {
+			return errors.NewUnauthorizedError(fmt.Sprintf("sign in to save %s repo '%s'", repo.Visibility, repo.CacheKey()))
+		}
+		return nil
+	}
+	repo.OwnerUserID = &user.ID
+
+	if repo.TeamID != nil {
+		member, err := rs.teams.GetTeamMember(*repo.TeamID, user.ID)
+		if err != nil {
+			return errors.NewInternalError(fmt.Sprintf("error when getting team member: %v", err.Error()))
+		}
+		if member == nil {
+			return errors.NewNotFoundError(fmt.Sprintf("team with id %d not found", *repo.TeamID))
+		}
+	}
+
+	return nil
+}
+
 func (rs *RepoService) validateBodyFromRequest(r *http.Request) ([]*models.RepositoryModel, error) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -106,19 +285,16 @@ func validateRepository(repo *models.RepositoryModel) error {
 	if repo.Author == "" {
 		missingFields = append(missingFields, "author")
 	}
-	if repo.ApiUrl == "" {
-		missingFields = append(missingFields, "apiUrl")
-	}
-	if repo.CloneUrl == "" {
-		missingFields = append(missingFields, "cloneUrl")
-	}
-	if repo.GhUrl == "" {
-		missingFields = append(missingFields, "ghUrl")
-	}
 
 	if len(missingFields) > 0 {
 		return fmt.Errorf("missing required fields: %s", strings.Join(missingFields, ", "))
 	}
 
+	switch repo.Forge {
+	case "", models.ForgeGitHub, models.ForgeGitLab, models.ForgeGitea:
+	default:
+		return fmt.Errorf("unsupported forge: %s", repo.Forge)
+	}
+
 	return nil
 }
@@ -2,10 +2,14 @@ package service
 
 import (
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 
+	"github.com/jwtly10/googl-bye/internal/auth"
 	"github.com/jwtly10/googl-bye/internal/common"
 	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/export"
 	"github.com/jwtly10/googl-bye/internal/models"
 	"github.com/jwtly10/googl-bye/internal/repository"
 )
@@ -23,7 +27,7 @@ func NewRepoLinkService(r repository.RepoLinkRepository, l common.Logger) *RepoL
 }
 
 func (rls *RepoLinkService) GetRepoLinks(r *http.Request) ([]*models.RepoWithLinks, error) {
-	repoLinks, err := rls.r.GetRepositoryWithLinks()
+	repoLinks, err := rls.r.GetRepositoryWithLinks(viewerID(r))
 	if err != nil {
 		return nil, errors.NewInternalError(fmt.Sprintf("error when getting repo links: %v", err.Error()))
 	}
@@ -39,10 +43,74 @@ func (rls *RepoLinkService) GetUserRepoLinks(r *http.Request) ([]*models.RepoWit
 
 	rls.log.Infof("Getting repo links for user: %s", username)
 
-	repoLinks, err := rls.r.GetRepositoryWithLinksForUser(username)
+	repoLinks, err := rls.r.GetRepositoryWithLinksForUser(username, viewerID(r))
 	if err != nil {
 		return nil, errors.NewInternalError(fmt.Sprintf("error when getting repo links for user %v: %v", username, err.Error()))
 	}
 
 	return repoLinks, nil
 }
+
+// GetRepoSarif renders the links of the repo with the {id} path value as SARIF
+func (rls *RepoLinkService) GetRepoSarif(r *http.Request) (*export.SarifLog, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("invalid repo id: '%s'", r.PathValue("id")))
+	}
+
+	repo, err := rls.r.GetRepositoryWithLinksByID(id, viewerID(r))
+	if err == repository.ErrRepoNotFound {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("repo with id %d not found", id))
+	}
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when getting links for repo %d: %v", id, err.Error()))
+	}
+
+	return export.NewRepoSarif(repo), nil
+}
+
+// RepoLinkExport is a validated export request
+type RepoLinkExport struct {
+	Format string
+	Filter repository.RepoLinkFilter
+}
+
+// NewRepoLinkExport validates the export query params. 'format' is required, 'username' and 'name' filter
+// the repos the same as the list views
+func (rls *RepoLinkService) NewRepoLinkExport(r *http.Request) (*RepoLinkExport, error) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		return nil, errors.NewBadRequestError("missing required field: format")
+	}
+	if _, err := export.NewLinkWriter(format, io.Discard); err != nil {
+		return nil, errors.NewBadRequestError(err.Error())
+	}
+
+	return &RepoLinkExport{
+		Format: format,
+		Filter: repository.RepoLinkFilter{
+			Author:   r.URL.Query().Get("username"),
+			Name:     r.URL.Query().Get("name"),
+			ViewerID: viewerID(r),
+		},
+	}, nil
+}
+
+// WriteRepoLinkExport streams the repos and links matching the export to lw
+func (rls *RepoLinkService) WriteRepoLinkExport(e *RepoLinkExport, lw export.LinkWriter) error {
+	rls.log.Infof("Exporting repo links as %s with filter: %+v", e.Format, e.Filter)
+
+	if err := rls.r.StreamRepositoryLinks(e.Filter, lw.WriteRow); err != nil {
+		return errors.NewInternalError(fmt.Sprintf("error when exporting repo links: %v", err.Error()))
+	}
+
+	return lw.Close()
+}
+
+// viewerID is the signed in user of the request, who can see their own private repos
+func viewerID(r *http.Request) int {
+	if user, ok := auth.UserFromContext(r.Context()); ok {
+		return user.ID
+	}
+	return repository.NoViewer
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/parser"
+)
+
+type ScanService struct {
+	log    common.Logger
+	parser *parser.RepoParser
+	config *common.Config
+}
+
+func NewScanService(p *parser.RepoParser, config *common.Config, l common.Logger) *ScanService {
+	return &ScanService{
+		log:    l,
+		parser: p,
+		config: config,
+	}
+}
+
+// scanTimeout limits how long a scan can take, as it runs while the request waits
+const scanTimeout = 5 * time.Minute
+
+type scanPathRequest struct {
+	Path string `json:"path"`
+}
+
+// Scan parses either an uploaded archive (multipart form, field 'archive') or a local path (JSON body)
+// The links found are returned as a report, nothing is saved to the database.
+// w is only used to close the connection when an upload is too large.
+func (ss *ScanService) Scan(w http.ResponseWriter, r *http.Request) (*models.ScanReport, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return ss.scanUpload(w, r)
+	}
+	return ss.scanPath(r)
+}
+
+func (ss *ScanService) scanPath(r *http.Request) (*models.ScanReport, error) {
+	if ss.config.ScanLocalRoot == "" {
+		return nil, errors.NewBadRequestError("scanning local paths is not enabled on this server")
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error reading request body: %v", err.Error()))
+	}
+
+	var req scanPathRequest
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("error decoding request body: %v", err.Error()))
+	}
+	if req.Path == "" {
+		return nil, errors.NewBadRequestError("missing required field: path")
+	}
+
+	dir, err := ss.resolveLocalPath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), scanTimeout)
+	defer cancel()
+	links, err := ss.parser.ParseDirectory(ctx, req.Path, dir)
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, scanTimeoutError()
+	}
+	if err != nil {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("error scanning path: %v", err.Error()))
+	}
+
+	return NewScanReport(req.Path, links), nil
+}
+
+// resolveLocalPath makes sure the requested path is within the configured scan root.
+// Symlinks are resolved first, so a link in the scan root can't point outside of it.
+// Symlinks within the path aren't followed when it is scanned.
+func (ss *ScanService) resolveLocalPath(path string) (string, error) {
+	root, err := filepath.Abs(ss.config.ScanLocalRoot)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+	if err != nil {
+		return "", errors.NewInternalError(fmt.Sprintf("invalid scan root: %v", err.Error()))
+	}
+
+	dir := path
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	dir, err = filepath.EvalSymlinks(filepath.Clean(dir))
+	if err != nil {
+		return "", errors.NewBadRequestError(fmt.Sprintf("path '%s' does not exist", path))
+	}
+
+	if dir != root && !strings.HasPrefix(dir, root+string(os.PathSeparator)) {
+		return "", errors.NewBadRequestError("path must be within the servers scan root")
+	}
+
+	return dir, nil
+}
+
+func (ss *ScanService) scanUpload(w http.ResponseWriter, r *http.Request) (*models.ScanReport, error) {
+	maxBytes := int64(ss.config.ScanMaxUploadMB) * 1024 * 1024
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("error reading upload (max %dMB): %v", ss.config.ScanMaxUploadMB, err.Error()))
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("archive")
+	if err != nil {
+		return nil, errors.NewBadRequestError("missing required field: archive")
+	}
+	defer file.Close()
+
+	if !parser.IsSupportedArchive(header.Filename) {
+		return nil, errors.NewBadRequestError("archive must be a .zip, .tar.gz, .tgz or .tar file")
+	}
+
+	ss.log.Infof("Scanning uploaded archive '%s' (%d bytes)", header.Filename, header.Size)
+
+	// Save the upload to disk, as zip archives need random access
+	archive, err := os.CreateTemp("", "scan-upload-*")
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error saving upload: %v", err.Error()))
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	if _, err := io.Copy(archive, file); err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error saving upload: %v", err.Error()))
+	}
+
+	dir, err := os.MkdirTemp("", "scan-extract-")
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error creating temp dir: %v", err.Error()))
+	}
+	defer os.RemoveAll(dir)
+
+	if err := parser.ExtractArchive(archive.Name(), header.Filename, dir); err != nil {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("error extracting archive: %v", err.Error()))
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), scanTimeout)
+	defer cancel()
+	links, err := ss.parser.ParseDirectory(ctx, header.Filename, dir)
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, scanTimeoutError()
+	}
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error scanning archive: %v", err.Error()))
+	}
+
+	// The extracted files are deleted once we return, so the absolute path is meaningless
+	for i := range links {
+		links[i].Path = links[i].File
+	}
+
+	return NewScanReport(header.Filename, links), nil
+}
+
+func scanTimeoutError() error {
+	return errors.NewBadRequestError(fmt.Sprintf("scan took longer than %v, try scanning less files", scanTimeout))
+}
+
+// NewScanReport builds a report of the links found in a scan
+func NewScanReport(name string, links []models.ParserLinksModel) *models.ScanReport {
+	report := &models.ScanReport{
+		Name:      name,
+		ScannedAt: time.Now(),
+		LinkCount: len(links),
+		Links:     make([]models.Link, 0, len(links)),
+	}
+	for _, l := range links {
+		report.Links = append(report.Links, models.NewLink(l))
+	}
+	return report
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+)
+
+const (
+	// statsCacheTTL is how long computed stats are reused. The dashboard polls, and the queries scan every link.
+	statsCacheTTL = 30 * time.Second
+
+	defaultStatsTop  = 10
+	maxStatsTop      = 50
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+)
+
+type StatsService struct {
+	log common.Logger
+	r   repository.StatsRepository
+	// mu only guards the maps, it is never held while querying
+	mu       sync.Mutex
+	cache    map[string]statsCacheEntry
+	inflight map[string]*statsCall
+}
+
+type statsCacheEntry struct {
+	stats   *models.StatsModel
+	expires time.Time
+}
+
+// statsCall is a query in progress, that requests for the same key wait on rather than running it again
+type statsCall struct {
+	done  chan struct{}
+	stats *models.StatsModel
+	err   error
+}
+
+func NewStatsService(r repository.StatsRepository, l common.Logger) *StatsService {
+	return &StatsService{
+		r:        r,
+		log:      l,
+		cache:    make(map[string]statsCacheEntry),
+		inflight: make(map[string]*statsCall),
+	}
+}
+
+// GetStats returns the dashboard stats. Optional query params 'top' and 'days' size the ranked lists and time series.
+func (ss *StatsService) GetStats(r *http.Request) (*models.StatsModel, error) {
+	top, err := parseBoundedInt(r, "top", defaultStatsTop, maxStatsTop)
+	if err != nil {
+		return nil, err
+	}
+	days, err := parseBoundedInt(r, "days", defaultStatsDays, maxStatsDays)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stats only count the repos the viewer can see, so they are cached per viewer
+	viewer := viewerID(r)
+	key := fmt.Sprintf("%d:%d:%d", top, days, viewer)
+
+	ss.mu.Lock()
+	if entry, ok := ss.cache[key]; ok && time.Now().Before(entry.expires) {
+		ss.mu.Unlock()
+		return entry.stats, nil
+	}
+	// Concurrent requests for the same stats wait for the query already running, rather than all running it
+	if call, ok := ss.inflight[key]; ok {
+		ss.mu.Unlock()
+		<-call.done
+		return call.stats, call.err
+	}
+	call := &statsCall{done: make(chan struct{})}
+	ss.inflight[key] = call
+	ss.mu.Unlock()
+
+	call.stats, call.err = ss.r.GetStats(top, days, viewer)
+	if call.err != nil {
+		call.err = errors.NewInternalError(fmt.Sprintf("error when getting stats: %v", call.err.Error()))
+	}
+
+	ss.mu.Lock()
+	delete(ss.inflight, key)
+	if call.err == nil {
+		// Expired entries are dropped, as there can be an entry for every user
+		for k, entry := range ss.cache {
+			if time.Now().After(entry.expires) {
+				delete(ss.cache, k)
+			}
+		}
+		ss.cache[key] = statsCacheEntry{stats: call.stats, expires: time.Now().Add(statsCacheTTL)}
+	}
+	ss.mu.Unlock()
+	close(call.done)
+
+	return call.stats, call.err
+}
+
+func parseBoundedInt(r *http.Request, param string, defaultValue, maxValue int) (int, error) {
+	raw := r.URL.Query().Get(param)
+	if raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 || value > maxValue {
+		return 0, errors.NewBadRequestError(fmt.Sprintf("%s must be a number between 1 and %d", param, maxValue))
+	}
+	return value, nil
+}
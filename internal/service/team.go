@@ -0,0 +1,254 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jwtly10/googl-bye/internal/auth"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+)
+
+type TeamService struct {
+	log   common.Logger
+	teams repository.TeamRepository
+	users repository.UserRepository
+}
+
+func NewTeamService(teams repository.TeamRepository, users repository.UserRepository, l common.Logger) *TeamService {
+	return &TeamService{
+		log:   l,
+		teams: teams,
+		users: users,
+	}
+}
+
+type createTeamRequest struct {
+	Name string `json:"name"`
+}
+
+type addTeamMemberRequest struct {
+	Login string `json:"login"`
+	Role  string `json:"role"`
+}
+
+// GetTeams lists the signed in user's teams
+func (ts *TeamService) GetTeams(r *http.Request) ([]models.TeamModel, error) {
+	user, err := signedInUser(r)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := ts.teams.GetTeamsForUser(user.ID)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when getting teams: %v", err.Error()))
+	}
+	return teams, nil
+}
+
+// CreateTeam creates a team owned by the signed in user
+func (ts *TeamService) CreateTeam(r *http.Request) (*models.TeamModel, error) {
+	user, err := signedInUser(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req createTeamRequest
+	if err := decodeStrict(r, &req); err != nil {
+		return nil, err
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return nil, errors.NewBadRequestError("missing required field: name")
+	}
+
+	team := &models.TeamModel{Name: req.Name}
+	if err := ts.teams.CreateTeam(team, user.ID); err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("team '%s' already exists", req.Name))
+		}
+		return nil, errors.NewInternalError(fmt.Sprintf("error when creating team: %v", err.Error()))
+	}
+
+	ts.log.Infof("User '%s' created team '%s'", user.Login, team.Name)
+	return team, nil
+}
+
+// GetTeam gets the team with the {id} path value and its members. Only members can see a team.
+func (ts *TeamService) GetTeam(r *http.Request) (*models.TeamWithMembers, error) {
+	teamID, _, err := ts.membership(r)
+	if err != nil {
+		return nil, err
+	}
+
+	team, err := ts.teams.GetTeamByID(teamID)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when getting team: %v", err.Error()))
+	}
+	if team == nil {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("team with id %d not found", teamID))
+	}
+
+	members, err := ts.teams.GetTeamMembers(teamID)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when getting team members: %v", err.Error()))
+	}
+
+	return &models.TeamWithMembers{TeamModel: *team, Members: members}, nil
+}
+
+// AddTeamMember adds a user to the team with the {id} path value. Users must have signed in before they can be added.
+func (ts *TeamService) AddTeamMember(r *http.Request) (*models.TeamMemberModel, error) {
+	teamID, _, err := ts.ownership(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req addTeamMemberRequest
+	if err := decodeStrict(r, &req); err != nil {
+		return nil, err
+	}
+	if req.Login == "" {
+		return nil, errors.NewBadRequestError("missing required field: login")
+	}
+	switch req.Role {
+	case "":
+		req.Role = models.TeamRoleMember
+	case models.TeamRoleMember, models.TeamRoleOwner:
+	default:
+		return nil, errors.NewBadRequestError(fmt.Sprintf("invalid role '%s', expected '%s' or '%s'", req.Role, models.TeamRoleMember, models.TeamRoleOwner))
+	}
+
+	user, err := ts.users.GetUserByLogin(req.Login)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when getting user: %v", err.Error()))
+	}
+	if user == nil {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("user '%s' has not signed in yet", req.Login))
+	}
+
+	if req.Role != models.TeamRoleOwner {
+		if err := ts.checkLastOwner(teamID, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	member := &models.TeamMemberModel{TeamID: teamID, UserID: user.ID, Role: req.Role, Login: user.Login}
+	if err := ts.teams.AddTeamMember(member); err != nil {
+		return nil, errors.NewInternalError(fmt.Sprintf("error when adding team member: %v", err.Error()))
+	}
+
+	return member, nil
+}
+
+// RemoveTeamMember removes the user with the {userId} path value from the team with the {id} path value.
+// Owners can remove anyone, and members can remove themselves.
+func (ts *TeamService) RemoveTeamMember(r *http.Request) error {
+	teamID, caller, err := ts.membership(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := strconv.Atoi(r.PathValue("userId"))
+	if err != nil {
+		return errors.NewBadRequestError(fmt.Sprintf("invalid user id: '%s'", r.PathValue("userId")))
+	}
+	if caller.Role != models.TeamRoleOwner && caller.UserID != userID {
+		return errors.NewForbiddenError("only team owners can remove other members")
+	}
+	if err := ts.checkLastOwner(teamID, userID); err != nil {
+		return err
+	}
+
+	if err := ts.teams.RemoveTeamMember(teamID, userID); err != nil {
+		return errors.NewInternalError(fmt.Sprintf("error when removing team member: %v", err.Error()))
+	}
+	return nil
+}
+
+// checkLastOwner stops the last owner of a team being removed or made a member, which would leave nobody able to manage it
+func (ts *TeamService) checkLastOwner(teamID, userID int) error {
+	members, err := ts.teams.GetTeamMembers(teamID)
+	if err != nil {
+		return errors.NewInternalError(fmt.Sprintf("error when getting team members: %v", err.Error()))
+	}
+
+	owners, isOwner := 0, false
+	for _, member := range members {
+		if member.Role == models.TeamRoleOwner {
+			owners++
+			isOwner = isOwner || member.UserID == userID
+		}
+	}
+	if isOwner && owners == 1 {
+		return errors.NewBadRequestError("a team must have an owner, add another owner first")
+	}
+	return nil
+}
+
+// membership gets the signed in user's membership of the team with the {id} path value.
+// Teams the user isn't a member of are not found, so their existence isn't leaked.
+func (ts *TeamService) membership(r *http.Request) (int, *models.TeamMemberModel, error) {
+	user, err := signedInUser(r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	teamID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, errors.NewBadRequestError(fmt.Sprintf("invalid team id: '%s'", r.PathValue("id")))
+	}
+
+	member, err := ts.teams.GetTeamMember(teamID, user.ID)
+	if err != nil {
+		return 0, nil, errors.NewInternalError(fmt.Sprintf("error when getting team member: %v", err.Error()))
+	}
+	if member == nil {
+		return 0, nil, errors.NewNotFoundError(fmt.Sprintf("team with id %d not found", teamID))
+	}
+
+	return teamID, member, nil
+}
+
+// ownership is membership, requiring the signed in user to own the team
+func (ts *TeamService) ownership(r *http.Request) (int, *models.TeamMemberModel, error) {
+	teamID, member, err := ts.membership(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if member.Role != models.TeamRoleOwner {
+		return 0, nil, errors.NewForbiddenError("only team owners can manage members")
+	}
+	return teamID, member, nil
+}
+
+// signedInUser gets the signed in user, set by the auth middleware
+func signedInUser(r *http.Request) (*models.UserModel, error) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return nil, errors.NewUnauthorizedError("not signed in")
+	}
+	return user, nil
+}
+
+// decodeStrict decodes a JSON request body, rejecting unknown fields
+func decodeStrict(r *http.Request, v interface{}) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return errors.NewInternalError(fmt.Sprintf("error reading request body: %v", err.Error()))
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errors.NewBadRequestError(fmt.Sprintf("error decoding request body: %v", err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/errors"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+	"github.com/jwtly10/googl-bye/internal/webhook"
+)
+
+// maxWebhookBodyBytes is the largest payload GitHub sends
+const maxWebhookBodyBytes = 25 << 20
+
+type WebhookService struct {
+	log        common.Logger
+	secret     string
+	repos      repository.RepoRepository
+	deliveries repository.WebhookDeliveryRepository
+}
+
+// NewWebhookService creates the service for GitHub webhooks. Webhooks are disabled when the secret is empty.
+func NewWebhookService(secret string, repos repository.RepoRepository, deliveries repository.WebhookDeliveryRepository, l common.Logger) *WebhookService {
+	return &WebhookService{
+		log:        l,
+		secret:     secret,
+		repos:      repos,
+		deliveries: deliveries,
+	}
+}
+
+// HandleGithubWebhook verifies a GitHub webhook delivery, and requeues tracked repos that were pushed to.
+// Each delivery only requeues its repo once, redeliveries of deliveries that queued a repo are ignored.
+// w is only used to close the connection when the payload is too large.
+func (ws *WebhookService) HandleGithubWebhook(w http.ResponseWriter, r *http.Request) (*models.WebhookResult, error) {
+	if ws.secret == "" {
+		return nil, errors.NewNotFoundError("github webhooks are not configured")
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("error reading webhook body: %v", err.Error()))
+	}
+	if !webhook.VerifyGithubSignature(ws.secret, body, r.Header.Get(webhook.GithubSignatureHeader)) {
+		return nil, errors.NewUnauthorizedError("invalid webhook signature")
+	}
+
+	result := &models.WebhookResult{
+		DeliveryID: r.Header.Get(webhook.GithubDeliveryHeader),
+		Event:      r.Header.Get(webhook.GithubEventHeader),
+	}
+	if result.DeliveryID == "" || result.Event == "" {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("missing required headers: %s, %s", webhook.GithubDeliveryHeader, webhook.GithubEventHeader))
+	}
+
+	if result.Event != "push" {
+		return ws.ignore(result, fmt.Sprintf("'%s' events are not handled", result.Event)), nil
+	}
+
+	var event webhook.PushEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("error decoding push event: %v", err.Error()))
+	}
+	result.Repo = event.Repository.FullName
+
+	if err := ws.requeue(&event, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// requeue queues the repo of a push event to be parsed again, only parsing the changed files when they are known
+func (ws *WebhookService) requeue(event *webhook.PushEvent, result *models.WebhookResult) error {
+	if event.Deleted {
+		ws.ignore(result, "branch was deleted")
+		return nil
+	}
+	if !event.IsDefaultBranch() {
+		ws.ignore(result, fmt.Sprintf("push to '%s' is not to the default branch", event.Ref))
+		return nil
+	}
+
+	repo, err := ws.repos.GetRepoByName(models.ForgeGitHub, event.Owner(), event.Repository.Name)
+	if err != nil {
+		return errors.NewInternalError(fmt.Sprintf("error when getting repo: %v", err.Error()))
+	}
+	if repo == nil {
+		ws.ignore(result, "repo is not tracked")
+		return nil
+	}
+
+	files, ok := event.ChangedFiles()
+	if ok && len(files) == 0 {
+		ws.ignore(result, "no files changed")
+		return nil
+	}
+	// The delivery is only recorded once the repo is queued, so GitHub can redeliver it after an error
+	queued, err := ws.deliveries.RequeueForDelivery(&models.WebhookDeliveryModel{DeliveryID: result.DeliveryID, Event: result.Event}, repo.ID, files)
+	if err != nil {
+		return errors.NewInternalError(fmt.Sprintf("error when requeueing repo: %v", err.Error()))
+	}
+	if !queued {
+		ws.log.Infof("Ignoring redelivery of webhook '%s'", result.DeliveryID)
+		result.Status = models.WebhookDuplicate
+		return nil
+	}
+
+	ws.log.Infof("[%s] Requeued after push, parsing %d changed files (0 is all files)", repo.CacheKey(), len(files))
+	result.Status = models.WebhookQueued
+	result.ChangedFiles = len(files)
+	return nil
+}
+
+func (ws *WebhookService) ignore(result *models.WebhookResult, reason string) *models.WebhookResult {
+	ws.log.Debugf("Ignoring webhook '%s': %s", result.DeliveryID, reason)
+	result.Status = models.WebhookIgnored
+	result.Reason = reason
+	return result
+}
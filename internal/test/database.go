@@ -3,9 +3,11 @@ package test
 import (
 	"context"
 	"database/sql"
+	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/jwtly10/googl-bye/internal/migrate"
 	_ "github.com/lib/pq"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
@@ -14,6 +16,8 @@ import (
 
 type TestDatabaseConfiguration struct {
 	RootRelativePath string
+	// SkipMigrations leaves the database empty, for testing migrations
+	SkipMigrations bool
 }
 
 func NewTestDatabaseWithContainer(config TestDatabaseConfiguration) (*postgres.PostgresContainer, *sql.DB, error) {
@@ -24,7 +28,6 @@ func NewTestDatabaseWithContainer(config TestDatabaseConfiguration) (*postgres.P
 			"DATABASE_PORT":     "5432",
 			"DATABASE_SSL_MODE": "disable",
 		}),
-		postgres.WithInitScripts(filepath.Join(config.RootRelativePath, "db/init.sql")),
 		testcontainers.WithWaitStrategy(
 			wait.ForLog("database system is ready to accept connections").
 				WithOccurrence(2).
@@ -43,5 +46,18 @@ func NewTestDatabaseWithContainer(config TestDatabaseConfiguration) (*postgres.P
 		return nil, nil, err
 	}
 
+	if config.SkipMigrations {
+		return postgresContainer, db, nil
+	}
+
+	// The schema is created by the migrations on disk, the same ones embedded in the app
+	migrator, err := migrate.NewMigrator(db, os.DirFS(filepath.Join(config.RootRelativePath, "db/migrations")))
+	if err == nil {
+		_, err = migrator.Up(context.Background())
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
 	return postgresContainer, db, nil
 }
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// FakeSMTPServer is a local SMTP server that keeps the emails it receives, for testing senders.
+// It only supports the commands needed to send mail, without TLS or auth.
+type FakeSMTPServer struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	emails []FakeEmail
+}
+
+// FakeEmail is an email received by a FakeSMTPServer
+type FakeEmail struct {
+	From string
+	To   []string
+	// Data is the message, headers and body, with CRLF line endings
+	Data string
+}
+
+// NewFakeSMTPServer starts a fake SMTP server on a random local port, which is stopped when the test ends
+func NewFakeSMTPServer(t *testing.T) *FakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &FakeSMTPServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// Host and Port are where the server is listening
+func (s *FakeSMTPServer) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+func (s *FakeSMTPServer) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Emails returns the emails received so far
+func (s *FakeSMTPServer) Emails() []FakeEmail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]FakeEmail(nil), s.emails...)
+}
+
+func (s *FakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost fake smtp")
+
+	var email FakeEmail
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch cmd {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 localhost")
+		case "MAIL":
+			email = FakeEmail{From: addressOf(line)}
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			email.To = append(email.To, addressOf(line))
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			email.Data = strings.ReplaceAll(string(data), "\n", "\r\n")
+			s.mu.Lock()
+			s.emails = append(s.emails, email)
+			s.mu.Unlock()
+			text.PrintfLine("250 OK")
+		case "RSET", "NOOP":
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// addressOf gets the address of a MAIL FROM:<a> or RCPT TO:<a> command
+func addressOf(line string) string {
+	start, end := strings.Index(line, "<"), strings.LastIndex(line, ">")
+	if start == -1 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
@@ -3,8 +3,11 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jwtly10/googl-bye/internal/errors"
 )
@@ -24,6 +27,20 @@ func WriteErrorResponse(w http.ResponseWriter, statusCode int, errResponse Error
 		http.Error(w, `{"error": "INTERNAL_SERVER_ERROR", "message": "THIS SHOULD NOT HAPPEN. WE WERE UNABLE TO PARSE THE REAL ERROR MSG INTO JSON"}`, http.StatusInternalServerError)
 	}
 }
+
+// WriteJSON writes a JSON response with the status code
+func WriteJSON(w http.ResponseWriter, statusCode int, res interface{}) {
+	jsonResponse, err := json.Marshal(res)
+	if err != nil {
+		HandleInternalError(w, fmt.Errorf("error marshaling response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(jsonResponse)
+}
+
 func HandleBadRequest(w http.ResponseWriter, err error) {
 	statusCode := http.StatusBadRequest
 	errorResponse := ErrorResponse{Error: "BAD_REQUEST_ERROR", Message: err.Error()}
@@ -43,6 +60,15 @@ func HandleValidationError(w http.ResponseWriter, err error) {
 }
 
 func HandleCustomErrors(w http.ResponseWriter, err error) {
+	statusCode, errorResponse := ErrorResponseFor(err)
+	if e, ok := err.(*errors.RateLimitedError); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(e.RetryAfter)))
+	}
+	WriteErrorResponse(w, statusCode, errorResponse)
+}
+
+// ErrorResponseFor gets the status code and response for an error, the same as HandleCustomErrors writes
+func ErrorResponseFor(err error) (int, ErrorResponse) {
 	var statusCode int
 	var errorResponse ErrorResponse
 
@@ -53,6 +79,15 @@ func HandleCustomErrors(w http.ResponseWriter, err error) {
 	case *errors.BadRequestError:
 		statusCode = http.StatusBadRequest
 		errorResponse = ErrorResponse{Error: "BAD_REQUEST_ERROR", Message: e.Error()}
+	case *errors.UnauthorizedError:
+		statusCode = http.StatusUnauthorized
+		errorResponse = ErrorResponse{Error: "UNAUTHORIZED", Message: e.Error()}
+	case *errors.ForbiddenError:
+		statusCode = http.StatusForbidden
+		errorResponse = ErrorResponse{Error: "FORBIDDEN", Message: e.Error()}
+	case *errors.RateLimitedError:
+		statusCode = http.StatusTooManyRequests
+		errorResponse = ErrorResponse{Error: "RATE_LIMITED", Message: e.Error()}
 	case *errors.InternalError:
 		statusCode = http.StatusInternalServerError
 		errorResponse = ErrorResponse{Error: "INTERNAL_SERVER_ERROR", Message: e.Error()}
@@ -61,7 +96,17 @@ func HandleCustomErrors(w http.ResponseWriter, err error) {
 		errorResponse = ErrorResponse{Error: "UNKNOWN_ERROR", Message: e.Error()}
 	}
 
-	WriteErrorResponse(w, statusCode, errorResponse)
+	errorResponse.Message = capitalizeFirstLetter(errorResponse.Message)
+	return statusCode, errorResponse
+}
+
+// retryAfterSeconds rounds up, so clients never retry before the limit resets
+func retryAfterSeconds(d time.Duration) int {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
 }
 
 func capitalizeFirstLetter(s string) string {
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jwtly10/googl-bye/internal/errors"
 	"github.com/jwtly10/googl-bye/internal/utils"
@@ -153,6 +154,7 @@ func TestHandleCustomErrors(t *testing.T) {
 		expectedCode    int
 		expectedError   string
 		expectedMessage string
+		expectedRetry   string
 	}{
 		{
 			name:            "Not found error",
@@ -175,6 +177,28 @@ func TestHandleCustomErrors(t *testing.T) {
 			expectedError:   "INTERNAL_SERVER_ERROR",
 			expectedMessage: "internal error",
 		},
+		{
+			name:            "Rate limited error",
+			inputError:      &errors.RateLimitedError{Message: "rate limited", Resource: "search", RetryAfter: 1500 * time.Millisecond},
+			expectedCode:    http.StatusTooManyRequests,
+			expectedError:   "RATE_LIMITED",
+			expectedMessage: "rate limited",
+			expectedRetry:   "2",
+		},
+		{
+			name:            "Unauthorized error",
+			inputError:      &errors.UnauthorizedError{Message: "not signed in"},
+			expectedCode:    http.StatusUnauthorized,
+			expectedError:   "UNAUTHORIZED",
+			expectedMessage: "not signed in",
+		},
+		{
+			name:            "Forbidden error",
+			inputError:      &errors.ForbiddenError{Message: "missing scope"},
+			expectedCode:    http.StatusForbidden,
+			expectedError:   "FORBIDDEN",
+			expectedMessage: "missing scope",
+		},
 		{
 			name:            "Unknown error",
 			inputError:      fmt.Errorf("unknown error"),
@@ -208,6 +232,33 @@ func TestHandleCustomErrors(t *testing.T) {
 			if strings.ToLower(body.Message) != strings.ToLower(tt.expectedMessage) {
 				t.Errorf("expected message '%s', got '%s'", tt.expectedMessage, body.Message)
 			}
+
+			if retry := result.Header.Get("Retry-After"); retry != tt.expectedRetry {
+				t.Errorf("expected Retry-After '%s', got '%s'", tt.expectedRetry, retry)
+			}
 		})
 	}
 }
+
+func TestWriteJSON(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	utils.WriteJSON(recorder, http.StatusCreated, map[string]string{"name": "ci"})
+
+	result := recorder.Result()
+	defer result.Body.Close()
+
+	if result.StatusCode != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, result.StatusCode)
+	}
+	if contentType := result.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected content type 'application/json', got '%s'", contentType)
+	}
+
+	var body map[string]string
+	if err := json.NewDecoder(result.Body).Decode(&body); err != nil {
+		t.Fatal("could not decode response body")
+	}
+	if body["name"] != "ci" {
+		t.Errorf("expected name 'ci', got '%s'", body["name"])
+	}
+}
@@ -0,0 +1,264 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/auth"
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/jwtly10/googl-bye/internal/repository"
+)
+
+// Headers of outbound webhook deliveries. The signature is computed the same way as GitHub's X-Hub-Signature-256.
+const (
+	EventHeader     = "X-Googl-Bye-Event"
+	DeliveryHeader  = "X-Googl-Bye-Delivery"
+	SignatureHeader = "X-Googl-Bye-Signature-256"
+)
+
+const (
+	// defaultMaxAttempts is how many times a delivery is tried before giving up
+	defaultMaxAttempts = 4
+	// defaultBackoff is the wait before the first retry, doubling on each retry
+	defaultBackoff  = 2 * time.Second
+	deliveryTimeout = 10 * time.Second
+	// deliveryRetention is how long delivery attempts are kept, checked at most once every pruneInterval
+	deliveryRetention = 30 * 24 * time.Hour
+	pruneInterval     = time.Hour
+)
+
+// Payload is the JSON body of an outbound webhook delivery
+type Payload struct {
+	Event      string      `json:"event"`
+	DeliveryID string      `json:"deliveryId"`
+	SentAt     time.Time   `json:"sentAt"`
+	Repo       PayloadRepo `json:"repo"`
+	LinksFound int         `json:"linksFound"`
+}
+
+// PayloadRepo is the repo a delivery is about
+type PayloadRepo struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Author   string `json:"author"`
+	Forge    string `json:"forge"`
+	Url      string `json:"url"`
+	State    string `json:"state"`
+	ErrorMsg string `json:"errorMsg,omitempty"`
+}
+
+// slackPayload is a message for Slack incoming webhooks, and other chat apps compatible with them
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// Dispatcher sends events to the outbound webhooks subscribed to them, retrying failed deliveries with backoff.
+// Every attempt is logged, so users can see why their webhook isn't receiving events, and kept for 30 days.
+// Deliveries are only sent to public addresses.
+type Dispatcher struct {
+	webhooks    repository.OutboundWebhookRepository
+	client      *http.Client
+	log         common.Logger
+	maxAttempts int
+	backoff     time.Duration
+	now         func() time.Time
+
+	pruneMu    sync.Mutex
+	lastPruned time.Time
+}
+
+func NewDispatcher(webhooks repository.OutboundWebhookRepository, log common.Logger) *Dispatcher {
+	return &Dispatcher{
+		webhooks:    webhooks,
+		client:      newClient(deliveryTimeout, PublicAddress),
+		log:         log,
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		now:         time.Now,
+	}
+}
+
+// RepoParsed sends the events for a repo that finished parsing, in the background.
+// Retries stop when ctx is cancelled.
+func (d *Dispatcher) RepoParsed(ctx context.Context, repo models.RepositoryModel, links int) {
+	for _, event := range eventsFor(repo, links) {
+		go d.Dispatch(ctx, event, repo, links)
+	}
+	go d.prune()
+}
+
+// prune deletes delivery attempts older than deliveryRetention, unless it already did in the last pruneInterval
+func (d *Dispatcher) prune() {
+	d.pruneMu.Lock()
+	defer d.pruneMu.Unlock()
+
+	now := d.now()
+	if now.Sub(d.lastPruned) < pruneInterval {
+		return
+	}
+	d.lastPruned = now
+
+	deleted, err := d.webhooks.DeleteDeliveriesBefore(now.Add(-deliveryRetention))
+	if err != nil {
+		d.log.Warnf("Error deleting old webhook deliveries: %v", err)
+	} else if deleted > 0 {
+		d.log.Infof("Deleted %d old webhook deliveries", deleted)
+	}
+}
+
+// Dispatch sends an event about a repo to the webhooks subscribed to it, and waits for the deliveries to finish
+func (d *Dispatcher) Dispatch(ctx context.Context, event string, repo models.RepositoryModel, links int) {
+	hooks, err := d.webhooks.GetWebhooksForEvent(event, repo.ID)
+	if err != nil {
+		d.log.Errorf("[%s] Error getting webhooks for '%s': %v", repo.CacheKey(), event, err)
+		return
+	}
+
+	done := make(chan struct{}, len(hooks))
+	for _, hook := range hooks {
+		go func(hook models.OutboundWebhookModel) {
+			defer func() { done <- struct{}{} }()
+			d.deliver(ctx, hook, event, repo, links)
+		}(hook)
+	}
+	for range hooks {
+		<-done
+	}
+}
+
+// deliver sends an event to a webhook, retrying until it succeeds, fails permanently or runs out of attempts
+func (d *Dispatcher) deliver(ctx context.Context, hook models.OutboundWebhookModel, event string, repo models.RepositoryModel, links int) {
+	deliveryID, err := auth.NewRandomToken()
+	if err != nil {
+		d.log.Errorf("Error creating delivery id for webhook %d: %v", hook.ID, err)
+		return
+	}
+
+	body, err := payloadFor(hook.Format, Payload{
+		Event:      event,
+		DeliveryID: deliveryID,
+		SentAt:     d.now().UTC(),
+		Repo: PayloadRepo{
+			ID:       repo.ID,
+			Name:     repo.Name,
+			Author:   repo.Author,
+			Forge:    repo.ForgeName(),
+			Url:      repo.GhUrl,
+			State:    repo.State,
+			ErrorMsg: repo.ErrorMsg,
+		},
+		LinksFound: links,
+	})
+	if err != nil {
+		d.log.Errorf("Error creating payload for webhook %d: %v", hook.ID, err)
+		return
+	}
+
+	wait := d.backoff
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		delivery := d.attempt(ctx, hook, event, deliveryID, body)
+		delivery.Attempt = attempt
+		if err := d.webhooks.CreateDelivery(delivery); err != nil {
+			d.log.Errorf("Error logging delivery of webhook %d: %v", hook.ID, err)
+		}
+
+		if delivery.Success {
+			d.log.Infof("[%s] Delivered '%s' to webhook %d", repo.CacheKey(), event, hook.ID)
+			return
+		}
+		if !retryable(delivery) || attempt == d.maxAttempts {
+			d.log.Warnf("[%s] Giving up delivering '%s' to webhook %d after %d attempts: %s", repo.CacheKey(), event, hook.ID, attempt, delivery.ErrorMsg)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+			wait *= 2
+		}
+	}
+}
+
+// attempt makes one delivery, returning its log entry
+func (d *Dispatcher) attempt(ctx context.Context, hook models.OutboundWebhookModel, event, deliveryID string, body []byte) *models.OutboundDeliveryModel {
+	delivery := &models.OutboundDeliveryModel{
+		WebhookID:  hook.ID,
+		DeliveryID: deliveryID,
+		Event:      event,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.ErrorMsg = err.Error()
+		return delivery
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "googl-bye-webhook")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, deliveryID)
+	req.Header.Set(SignatureHeader, "sha256="+hex.EncodeToString(Sign(hook.Secret, body)))
+
+	start := d.now()
+	resp, err := d.client.Do(req)
+	delivery.DurationMs = int(d.now().Sub(start).Milliseconds())
+	if err != nil {
+		delivery.ErrorMsg = err.Error()
+		return delivery
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		delivery.ErrorMsg = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+	return delivery
+}
+
+// retryable checks if a failed delivery might succeed later. Other client errors, like a 404, won't.
+func retryable(delivery *models.OutboundDeliveryModel) bool {
+	return delivery.StatusCode == 0 || delivery.StatusCode == http.StatusTooManyRequests || delivery.StatusCode >= 500
+}
+
+// eventsFor gets the events for a repo that finished parsing
+func eventsFor(repo models.RepositoryModel, links int) []string {
+	if repo.State != "COMPLETED" {
+		return []string{models.EventRepoFailed}
+	}
+	if links > 0 {
+		return []string{models.EventRepoCompleted, models.EventRepoLinksFound}
+	}
+	return []string{models.EventRepoCompleted}
+}
+
+// payloadFor creates the body of a delivery in a webhook's format
+func payloadFor(format string, payload Payload) ([]byte, error) {
+	if format != models.WebhookFormatSlack {
+		return json.Marshal(payload)
+	}
+
+	repo := fmt.Sprintf("<%s|%s/%s>", payload.Repo.Url, payload.Repo.Author, payload.Repo.Name)
+	var text string
+	switch payload.Event {
+	case models.EventRepoLinksFound:
+		text = fmt.Sprintf(":warning: Found %d goo.gl links in %s", payload.LinksFound, repo)
+	case models.EventRepoFailed:
+		text = fmt.Sprintf(":x: Parsing %s failed (%s)", repo, payload.Repo.State)
+		if payload.Repo.ErrorMsg != "" {
+			text += ": " + payload.Repo.ErrorMsg
+		}
+	default:
+		text = fmt.Sprintf(":white_check_mark: Parsed %s, found %d goo.gl links", repo, payload.LinksFound)
+	}
+	return json.Marshal(slackPayload{Text: text})
+}
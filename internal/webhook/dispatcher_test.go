@@ -0,0 +1,279 @@
+package webhook
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jwtly10/googl-bye/internal/common"
+	"github.com/jwtly10/googl-bye/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+type fakeWebhooks struct {
+	mu         sync.Mutex
+	hooks      []models.OutboundWebhookModel
+	deliveries []models.OutboundDeliveryModel
+	pruned     []time.Time
+}
+
+func (f *fakeWebhooks) CreateWebhook(webhook *models.OutboundWebhookModel) error { return nil }
+func (f *fakeWebhooks) GetWebhooksForUser(userID int) ([]models.OutboundWebhookModel, error) {
+	return nil, nil
+}
+func (f *fakeWebhooks) GetWebhookForUser(id, userID int) (*models.OutboundWebhookModel, error) {
+	return nil, nil
+}
+func (f *fakeWebhooks) DeleteWebhook(id, userID int) (bool, error) { return false, nil }
+func (f *fakeWebhooks) GetDeliveries(webhookID, limit int) ([]models.OutboundDeliveryModel, error) {
+	return nil, nil
+}
+
+func (f *fakeWebhooks) GetWebhooksForEvent(event string, repoID int) ([]models.OutboundWebhookModel, error) {
+	var hooks []models.OutboundWebhookModel
+	for _, hook := range f.hooks {
+		for _, e := range hook.Events {
+			if e == event {
+				hooks = append(hooks, hook)
+			}
+		}
+	}
+	return hooks, nil
+}
+
+func (f *fakeWebhooks) CreateDelivery(delivery *models.OutboundDeliveryModel) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deliveries = append(f.deliveries, *delivery)
+	return nil
+}
+
+func (f *fakeWebhooks) DeleteDeliveriesBefore(before time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pruned = append(f.pruned, before)
+	return 0, nil
+}
+
+// newTestDispatcher creates a dispatcher that can deliver to test servers on loopback addresses
+func newTestDispatcher(hooks *fakeWebhooks) *Dispatcher {
+	d := NewDispatcher(hooks, common.NewLogger(false, zapcore.DebugLevel))
+	d.client = newClient(deliveryTimeout, func(netip.Addr) bool { return true })
+	d.backoff = time.Millisecond
+	return d
+}
+
+var parsedRepo = models.RepositoryModel{
+	Model:  models.Model{ID: 1},
+	Name:   "googl-bye",
+	Author: "jwtly10",
+	GhUrl:  "https://github.com/jwtly10/googl-bye",
+	State:  "COMPLETED",
+}
+
+func TestDispatcherRetriesFailedDeliveries(t *testing.T) {
+	var requests []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		if len(requests) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	hooks := &fakeWebhooks{hooks: []models.OutboundWebhookModel{
+		{Model: models.Model{ID: 7}, URL: server.URL, Secret: "secret", Events: []string{models.EventRepoLinksFound}, Format: models.WebhookFormatJSON},
+	}}
+	newTestDispatcher(hooks).Dispatch(context.Background(), models.EventRepoLinksFound, parsedRepo, 3)
+
+	assert.Len(t, requests, 3)
+	for i, r := range requests {
+		assert.Equal(t, models.EventRepoLinksFound, r.Header.Get(EventHeader))
+		assert.Equal(t, requests[0].Header.Get(DeliveryHeader), r.Header.Get(DeliveryHeader))
+		assert.Equal(t, "sha256="+hex.EncodeToString(Sign("secret", bodies[i])), r.Header.Get(SignatureHeader))
+	}
+
+	var payload Payload
+	assert.NoError(t, json.Unmarshal(bodies[0], &payload))
+	assert.Equal(t, models.EventRepoLinksFound, payload.Event)
+	assert.Equal(t, "googl-bye", payload.Repo.Name)
+	assert.Equal(t, 3, payload.LinksFound)
+
+	// Every attempt is logged
+	assert.Len(t, hooks.deliveries, 3)
+	assert.Equal(t, 1, hooks.deliveries[0].Attempt)
+	assert.Equal(t, http.StatusBadGateway, hooks.deliveries[0].StatusCode)
+	assert.False(t, hooks.deliveries[0].Success)
+	assert.Equal(t, 3, hooks.deliveries[2].Attempt)
+	assert.True(t, hooks.deliveries[2].Success)
+}
+
+func TestDispatcherDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	hooks := &fakeWebhooks{hooks: []models.OutboundWebhookModel{
+		{Model: models.Model{ID: 7}, URL: server.URL, Secret: "secret", Events: []string{models.EventRepoCompleted}},
+	}}
+	newTestDispatcher(hooks).Dispatch(context.Background(), models.EventRepoCompleted, parsedRepo, 0)
+
+	assert.Equal(t, 1, attempts)
+	assert.Len(t, hooks.deliveries, 1)
+	assert.Equal(t, "unexpected status 404 Not Found", hooks.deliveries[0].ErrorMsg)
+}
+
+func TestDispatcherGivesUpAfterMaxAttempts(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	hooks := &fakeWebhooks{hooks: []models.OutboundWebhookModel{
+		{Model: models.Model{ID: 7}, URL: server.URL, Secret: "secret", Events: []string{models.EventRepoFailed}},
+	}}
+	newTestDispatcher(hooks).Dispatch(context.Background(), models.EventRepoFailed, parsedRepo, 0)
+
+	assert.Equal(t, defaultMaxAttempts, attempts)
+	assert.Len(t, hooks.deliveries, defaultMaxAttempts)
+}
+
+func TestDispatcherOnlyDeliversToPublicAddresses(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	hooks := &fakeWebhooks{hooks: []models.OutboundWebhookModel{
+		{Model: models.Model{ID: 7}, URL: server.URL, Secret: "secret", Events: []string{models.EventRepoCompleted}},
+	}}
+	d := NewDispatcher(hooks, common.NewLogger(false, zapcore.DebugLevel))
+	d.backoff = time.Millisecond
+	d.Dispatch(context.Background(), models.EventRepoCompleted, parsedRepo, 0)
+
+	assert.Equal(t, 0, attempts)
+	assert.NotEmpty(t, hooks.deliveries)
+	assert.False(t, hooks.deliveries[0].Success)
+	assert.Contains(t, hooks.deliveries[0].ErrorMsg, "not a public address")
+}
+
+func TestDispatcherDoesNotFollowRedirects(t *testing.T) {
+	redirected := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			redirected = true
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	}))
+	defer server.Close()
+
+	hooks := &fakeWebhooks{hooks: []models.OutboundWebhookModel{
+		{Model: models.Model{ID: 7}, URL: server.URL, Secret: "secret", Events: []string{models.EventRepoCompleted}},
+	}}
+	newTestDispatcher(hooks).Dispatch(context.Background(), models.EventRepoCompleted, parsedRepo, 0)
+
+	assert.False(t, redirected)
+	assert.Len(t, hooks.deliveries, 1)
+	assert.Equal(t, http.StatusFound, hooks.deliveries[0].StatusCode)
+	assert.False(t, hooks.deliveries[0].Success)
+}
+
+func TestDispatcherPrunesOldDeliveries(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	hooks := &fakeWebhooks{}
+	d := newTestDispatcher(hooks)
+	d.now = func() time.Time { return now }
+
+	d.prune()
+	// Pruning again within the interval does nothing
+	now = now.Add(time.Minute)
+	d.prune()
+
+	assert.Equal(t, []time.Time{time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)}, hooks.pruned)
+
+	now = now.Add(pruneInterval)
+	d.prune()
+	assert.Len(t, hooks.pruned, 2)
+}
+
+func TestPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{"140.82.112.3", true},
+		{"2606:50c0:8000::153", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fd00:ec2::254", false},
+		{"fe80::1", false},
+		{"100.100.100.200", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+	for _, test := range tests {
+		t.Run(test.addr, func(t *testing.T) {
+			assert.Equal(t, test.public, PublicAddress(netip.MustParseAddr(test.addr)))
+		})
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	for _, raw := range []string{"http://127.0.0.1:8080/hook", "http://[::1]/hook", "http://169.254.169.254/latest/meta-data", "http://localhost/hook"} {
+		u, err := url.Parse(raw)
+		assert.NoError(t, err)
+		assert.Error(t, CheckURL(context.Background(), u), raw)
+	}
+
+	u, err := url.Parse("https://140.82.112.3/hook")
+	assert.NoError(t, err)
+	assert.NoError(t, CheckURL(context.Background(), u))
+}
+
+func TestSlackPayload(t *testing.T) {
+	body, err := payloadFor(models.WebhookFormatSlack, Payload{
+		Event:      models.EventRepoLinksFound,
+		Repo:       PayloadRepo{Name: "googl-bye", Author: "jwtly10", Url: "https://github.com/jwtly10/googl-bye"},
+		LinksFound: 3,
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"text":":warning: Found 3 goo.gl links in <https://github.com/jwtly10/googl-bye|jwtly10/googl-bye>"}`, string(body))
+}
+
+func TestEventsFor(t *testing.T) {
+	assert.Equal(t, []string{models.EventRepoCompleted}, eventsFor(parsedRepo, 0))
+	assert.Equal(t, []string{models.EventRepoCompleted, models.EventRepoLinksFound}, eventsFor(parsedRepo, 2))
+
+	failed := parsedRepo
+	failed.State = "TIMEOUT"
+	assert.Equal(t, []string{models.EventRepoFailed}, eventsFor(failed, 0))
+}
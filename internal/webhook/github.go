@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// GitHub webhook headers
+const (
+	GithubEventHeader     = "X-GitHub-Event"
+	GithubDeliveryHeader  = "X-GitHub-Delivery"
+	GithubSignatureHeader = "X-Hub-Signature-256"
+)
+
+// maxPushCommits is the most commits GitHub includes in a push event. Pushes with more may be missing changed files.
+const maxPushCommits = 20
+
+// VerifyGithubSignature checks the X-Hub-Signature-256 header of a webhook is the HMAC of its body with the secret
+func VerifyGithubSignature(secret string, body []byte, signature string) bool {
+	hexMac, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	mac, err := hex.DecodeString(hexMac)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(mac, Sign(secret, body))
+}
+
+// Sign returns the HMAC-SHA256 of body with the secret
+func Sign(secret string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// PushEvent is the part of a GitHub push event payload that is needed to requeue a repo
+type PushEvent struct {
+	Ref        string `json:"ref"`
+	Deleted    bool   `json:"deleted"`
+	Forced     bool   `json:"forced"`
+	Repository struct {
+		Name          string `json:"name"`
+		FullName      string `json:"full_name"`
+		DefaultBranch string `json:"default_branch"`
+		Owner         struct {
+			Login string `json:"login"`
+			Name  string `json:"name"`
+		} `json:"owner"`
+	} `json:"repository"`
+	Commits []PushCommit `json:"commits"`
+}
+
+// PushCommit is a commit of a push event, with the files it changed
+type PushCommit struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+// Owner is the login of the repo's owner
+func (e *PushEvent) Owner() string {
+	// Owners of push events only have a name in older payloads
+	if e.Repository.Owner.Login != "" {
+		return e.Repository.Owner.Login
+	}
+	return e.Repository.Owner.Name
+}
+
+// IsDefaultBranch checks if the push was to the branch repos are parsed from
+func (e *PushEvent) IsDefaultBranch() bool {
+	return e.Repository.DefaultBranch != "" && e.Ref == "refs/heads/"+e.Repository.DefaultBranch
+}
+
+// ChangedFiles lists the files the push added, removed or modified. It returns false when they can't be
+// known from the payload, because history was rewritten or there were too many commits, so the whole repo must be parsed.
+func (e *PushEvent) ChangedFiles() ([]string, bool) {
+	if e.Forced || len(e.Commits) == 0 || len(e.Commits) >= maxPushCommits {
+		return nil, false
+	}
+
+	seen := make(map[string]bool)
+	files := []string{}
+	for _, commit := range e.Commits {
+		for _, list := range [][]string{commit.Added, commit.Removed, commit.Modified} {
+			for _, file := range list {
+				if !seen[file] {
+					seen[file] = true
+					files = append(files, file)
+				}
+			}
+		}
+	}
+	return files, true
+}
@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyGithubSignature(t *testing.T) {
+	body := []byte(`{"zen":"Keep it logically awesome."}`)
+	signature := "sha256=" + hex.EncodeToString(Sign("secret", body))
+
+	assert.True(t, VerifyGithubSignature("secret", body, signature))
+	assert.False(t, VerifyGithubSignature("other secret", body, signature))
+	assert.False(t, VerifyGithubSignature("secret", []byte(`{"zen":"tampered"}`), signature))
+	assert.False(t, VerifyGithubSignature("secret", body, ""))
+	assert.False(t, VerifyGithubSignature("secret", body, "sha1="+hex.EncodeToString(Sign("secret", body))))
+	assert.False(t, VerifyGithubSignature("secret", body, "sha256=not-hex"))
+}
+
+func TestPushEvent(t *testing.T) {
+	payload := `{
+		"ref": "refs/heads/main",
+		"forced": false,
+		"repository": {"name": "googl-bye", "full_name": "jwtly10/googl-bye", "default_branch": "main", "owner": {"login": "jwtly10"}},
+		"commits": [
+			{"added": ["new.go"], "removed": [], "modified": ["README.md"]},
+			{"added": [], "removed": ["old.go"], "modified": ["README.md"]}
+		]
+	}`
+
+	var event PushEvent
+	assert.NoError(t, json.Unmarshal([]byte(payload), &event))
+	assert.Equal(t, "jwtly10", event.Owner())
+	assert.True(t, event.IsDefaultBranch())
+
+	files, ok := event.ChangedFiles()
+	assert.True(t, ok)
+	assert.Equal(t, []string{"new.go", "README.md", "old.go"}, files)
+
+	event.Ref = "refs/heads/feature"
+	assert.False(t, event.IsDefaultBranch())
+
+	// Changed files of force pushes can't be trusted
+	event.Forced = true
+	_, ok = event.ChangedFiles()
+	assert.False(t, ok)
+}
+
+func TestPushEventWithTooManyCommits(t *testing.T) {
+	var event PushEvent
+	for i := 0; i < maxPushCommits; i++ {
+		event.Commits = append(event.Commits, PushCommit{Modified: []string{fmt.Sprintf("file%d.go", i)}})
+	}
+
+	_, ok := event.ChangedFiles()
+	assert.False(t, ok)
+}
@@ -26,7 +26,8 @@ export const searchGithubRepos = async (searchParams) => {
             `${API_BASE_URL}/search`,
             JSON.stringify(processedParams)
         );
-        return handleResponse(response);
+        // The query that was actually searched, with the default guardrails added, is sent as a header
+        return { repos: handleResponse(response), query: response.headers['x-search-query'] };
     } catch (error) {
         return handleError(error);
     }
@@ -82,3 +83,44 @@ export const searchRepoLinksForUser = async (username) => {
         return handleError(error);
     }
 };
+
+// Signing in redirects to GitHub, then back to the app with a session cookie
+export const githubLoginUrl = `${API_BASE_URL}/auth/login`;
+
+export const getCurrentUser = async () => {
+    try {
+        const response = await axios.get(`${API_BASE_URL}/me`);
+        return handleResponse(response);
+    } catch (error) {
+        return handleError(error);
+    }
+};
+
+export const logout = async () => {
+    try {
+        const response = await axios.post(`${API_BASE_URL}/auth/logout`);
+        return handleResponse(response);
+    } catch (error) {
+        return handleError(error);
+    }
+};
+
+// Gets the dashboard statistics of the repos the user can see
+export const getStats = async () => {
+    try {
+        const response = await axios.get(`${API_BASE_URL}/stats`);
+        return handleResponse(response);
+    } catch (error) {
+        return handleError(error);
+    }
+};
+
+// Subscribes an email to a digest of new links found in an author's repos
+export const subscribeToDigest = async (email, author) => {
+    try {
+        const response = await axios.post(`${API_BASE_URL}/digests`, JSON.stringify({ email, author }));
+        return handleResponse(response);
+    } catch (error) {
+        return handleError(error);
+    }
+};
@@ -0,0 +1,38 @@
+import { useState } from 'react';
+import { Button, Card, Stack, TextField, Typography } from '@mui/material';
+
+export default function DigestSubscribeForm({ author, handleSubscribe }) {
+    const [email, setEmail] = useState('');
+    const [isSubscribing, setIsSubscribing] = useState(false);
+
+    const handleSubmit = async (e) => {
+        e.preventDefault();
+        setIsSubscribing(true);
+        await handleSubscribe(email);
+        setIsSubscribing(false);
+    };
+
+    return (
+        <Card sx={{ mb: 4, p: 3 }}>
+            <Typography variant="subtitle1">Get new links by email</Typography>
+            <Typography variant="body2" color="text.secondary" sx={{ mb: 2 }}>
+                We'll email you a digest when new goo.gl links are found in {author}'s repositories.
+            </Typography>
+            <Stack component="form" direction="row" spacing={2} onSubmit={handleSubmit}>
+                <TextField
+                    fullWidth
+                    size="small"
+                    type="email"
+                    placeholder="Email"
+                    value={email}
+                    onChange={(e) => setEmail(e.target.value)}
+                    autoComplete="email"
+                    required
+                />
+                <Button type="submit" variant="contained" disabled={isSubscribing || !email}>
+                    Subscribe
+                </Button>
+            </Stack>
+        </Card>
+    );
+}
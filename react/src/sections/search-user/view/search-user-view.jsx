@@ -33,8 +33,10 @@ import {
     searchGithubUsersRepo,
     saveRepos,
     searchRepoLinksForUser,
+    subscribeToDigest,
 } from 'src/api/client';
 import UserGrid from 'src/components/search-user/searchUserGrid';
+import DigestSubscribeForm from 'src/components/search-user/digestSubscribeForm';
 
 // ----------------------------------------------------------------------
 
@@ -279,6 +281,21 @@ export default function SearchUserPage() {
         });
     };
 
+    const handleSubscribe = async (email) => {
+        try {
+            await subscribeToDigest(email, selectedUser);
+        } catch (e) {
+            console.error('Error subscribing to digest', e);
+            setErrorToast({ open: true, message: e.response.data.message });
+            return;
+        }
+
+        setSuccessToast({
+            open: true,
+            message: `Sent a confirmation email to ${email}. Digests of new links in ${selectedUser}'s repos start once it is confirmed.`,
+        });
+    };
+
     const notFound = !issuesFiltered.length && !!filterName;
 
     return (
@@ -317,6 +334,10 @@ export default function SearchUserPage() {
                 </Card>
             ) : null}
 
+            {showRepoIssues && selectedUser && (
+                <DigestSubscribeForm author={selectedUser} handleSubscribe={handleSubscribe} />
+            )}
+
             {showRepoIssues && (
                 <Card>
                     <IssueTableToolbar
@@ -178,12 +178,11 @@ export default function SearchPage() {
 
         try {
             const res = await searchGithubRepos(searchParams);
-            if (res === null) {
-                setRepos([]);
-                saveToLocalStorage([]);
-            } else {
-                setRepos(res);
-                saveToLocalStorage(res);
+            const found = res?.repos ?? [];
+            setRepos(found);
+            saveToLocalStorage(found);
+            if (res?.query) {
+                setSuccessToast({ open: true, message: `Searched: ${res.query}` });
             }
 
             console.log('Search completed!');